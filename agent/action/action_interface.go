@@ -22,3 +22,9 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// ProgressReporter is implemented by asynchronous actions that can tell
+// how far they have come while their task is running
+type ProgressReporter interface {
+	Progress() interface{}
+}
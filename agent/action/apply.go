@@ -54,7 +54,18 @@ func (a ApplyAction) IsLoggable() bool {
 	return true
 }
 
-func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
+// ApplyOptions is the optional second argument of apply and prepare
+type ApplyOptions struct {
+	// DryRun returns the changes the desired spec would make
+	// without touching the disk or the job supervisor
+	DryRun bool `json:"dry_run"`
+}
+
+func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec, options ...ApplyOptions) (interface{}, error) {
+	if len(options) > 0 && options[0].DryRun {
+		return diffWithCurrentSpec(a.specService, desiredSpec)
+	}
+
 	settings := a.settingsService.GetSettings()
 
 	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, settings)
@@ -82,6 +93,15 @@ func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 	return "applied", nil
 }
 
+func diffWithCurrentSpec(specService boshas.V1Service, desiredSpec boshas.V1ApplySpec) (boshas.SpecDiff, error) {
+	currentSpec, err := specService.Get()
+	if err != nil {
+		return boshas.SpecDiff{}, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	return boshas.Diff(currentSpec, desiredSpec), nil
+}
+
 func (a ApplyAction) writeInstanceData(spec boshas.V1ApplySpec) error {
 	err := a.writeInstanceField("id", spec.NodeID)
 	if err != nil {
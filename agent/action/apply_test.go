@@ -264,5 +264,42 @@ var _ = Describe("ApplyAction", func() {
 				})
 			})
 		})
+
+		Context("when running as a dry run", func() {
+			currentApplySpec := boshas.V1ApplySpec{
+				ConfigurationHash: "fake-current-config-hash",
+				PersistentDisk:    1024,
+			}
+			desiredApplySpec := boshas.V1ApplySpec{
+				ConfigurationHash: "fake-desired-config-hash",
+				PersistentDisk:    2048,
+			}
+
+			BeforeEach(func() {
+				specService.Spec = currentApplySpec
+			})
+
+			It("returns the diff between current and desired spec", func() {
+				value, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(boshas.Diff(currentApplySpec, desiredApplySpec)))
+			})
+
+			It("does not apply or persist the desired spec", func() {
+				_, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(applier.Applied).To(BeFalse())
+				Expect(specService.ActionsCalled).To(Equal([]string{"Get"}))
+				Expect(fs.FileExists(path.Join(dirProvider.InstanceDir(), "id"))).To(BeFalse())
+			})
+
+			It("returns error when current spec cannot be retrieved", func() {
+				specService.GetErr = errors.New("fake-get-error")
+
+				_, err := applyAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+			})
+		})
 	})
 })
@@ -6,6 +6,7 @@ import (
 	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	blobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	"github.com/cloudfoundry/bosh-agent/agent/utils"
@@ -34,6 +35,7 @@ func NewFactory(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
+	freezeWatchdog FreezeWatchdog,
 	logger boshlog.Logger,
 	blobstoreDelegator blobdelegator.BlobstoreDelegator) Factory {
 	compressor := platform.GetCompressor()
@@ -54,13 +56,14 @@ func NewFactory(
 
 			// VM admin
 			"ssh":                        NewSSH(settingsService, platform, dirProvider, logger),
-			"fetch_logs":                 NewFetchLogs(compressor, copier, blobstoreDelegator, dirProvider),
-			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobstoreDelegator),
+			"fetch_logs":                 NewFetchLogs(compressor, copier, blobstoreDelegator, dirProvider, platform.GetFs()),
+			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobstoreDelegator, platform.GetFs()),
+			"tail_logs":                  NewTailLogs(logtail.NewTailer(platform.GetFs(), dirProvider)),
 			"update_settings":            NewUpdateSettings(settingsService, platform, certManager, logger, utils.NewAgentKiller()),
 			"shutdown":                   NewShutdown(platform),
 
 			// Job management
-			"prepare":    NewPrepare(applier),
+			"prepare":    NewPrepare(applier, specService),
 			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
 			"start":      NewStart(jobSupervisor, applier, specService),
 			"stop":       NewStop(jobSupervisor),
@@ -81,8 +84,11 @@ func NewFactory(
 			"migrate_disk":           NewMigrateDisk(platform, dirProvider),
 			"mount_disk":             NewMountDisk(settingsService, platform, dirProvider, logger),
 			"unmount_disk":           NewUnmountDisk(settingsService, platform),
+			"resize_disk":            NewResizeDisk(settingsService, platform, logger),
 			"add_persistent_disk":    NewAddPersistentDiskAction(settingsService),
 			"remove_persistent_disk": NewRemovePersistentDiskAction(settingsService),
+			"freeze_disk":            NewFreezeDisk(settingsService, platform, dirProvider, specService, jobScriptProvider, freezeWatchdog, logger),
+			"thaw_disk":              NewThawDisk(settingsService, platform, dirProvider, freezeWatchdog),
 
 			// ARP cache management
 			"delete_arp_entries": NewDeleteARPEntries(platform),
@@ -1,6 +1,7 @@
 package action_test
 
 import (
+	"code.cloudfoundry.org/clock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -42,6 +44,7 @@ var _ = Describe("concreteFactory", func() {
 		logger            boshlog.Logger
 		fileSystem        *fakesys.FakeFileSystem
 		blobDelegator     *fakeblobdelegator.FakeBlobstoreDelegator
+		freezeWatchdog    boshaction.FreezeWatchdog
 	)
 
 	BeforeEach(func() {
@@ -62,6 +65,7 @@ var _ = Describe("concreteFactory", func() {
 		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		blobDelegator = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		freezeWatchdog = boshaction.NewFreezeWatchdog(platform, fileSystem, "/fake-freeze-deadlines.json", clock.NewClock(), logger)
 
 		factory = boshaction.NewFactory(
 			settingsService,
@@ -74,6 +78,7 @@ var _ = Describe("concreteFactory", func() {
 			jobSupervisor,
 			specService,
 			jobScriptProvider,
+			freezeWatchdog,
 			logger,
 			blobDelegator,
 		)
@@ -107,14 +112,20 @@ var _ = Describe("concreteFactory", func() {
 	It("fetch_logs", func() {
 		action, err := factory.Create("fetch_logs")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewFetchLogs(platform.GetCompressor(), platform.GetCopier(), blobDelegator, platform.GetDirProvider())))
+		Expect(action).To(Equal(boshaction.NewFetchLogs(platform.GetCompressor(), platform.GetCopier(), blobDelegator, platform.GetDirProvider(), platform.GetFs())))
 	})
 
 	It("fetch_logs_with_signed_url", func() {
 		ac, err := factory.Create("fetch_logs_with_signed_url")
 		Expect(err).ToNot(HaveOccurred())
 
-		Expect(ac).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(platform.GetCompressor(), platform.GetCopier(), platform.GetDirProvider(), blobDelegator)))
+		Expect(ac).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(platform.GetCompressor(), platform.GetCopier(), platform.GetDirProvider(), blobDelegator, platform.GetFs())))
+	})
+
+	It("tail_logs", func() {
+		action, err := factory.Create("tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewTailLogs(logtail.NewTailer(platform.GetFs(), platform.GetDirProvider()))))
 	})
 
 	It("get_task", func() {
@@ -144,7 +155,20 @@ var _ = Describe("concreteFactory", func() {
 	It("migrate_disk", func() {
 		action, err := factory.Create("migrate_disk")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewMigrateDisk(platform, platform.GetDirProvider())))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(boshaction.MigrateDiskAction{}))
+	})
+
+	It("freeze_disk", func() {
+		action, err := factory.Create("freeze_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewFreezeDisk(settingsService, platform, platform.GetDirProvider(), specService, jobScriptProvider, freezeWatchdog, logger)))
+	})
+
+	It("thaw_disk", func() {
+		action, err := factory.Create("thaw_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewThawDisk(settingsService, platform, platform.GetDirProvider(), freezeWatchdog)))
 	})
 
 	It("mount_disk", func() {
@@ -195,6 +219,12 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewUnmountDisk(settingsService, platform)))
 	})
 
+	It("resize_disk", func() {
+		action, err := factory.Create("resize_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewResizeDisk(settingsService, platform, logger)))
+	})
+
 	It("compile_package", func() {
 		action, err := factory.Create("compile_package")
 		Expect(err).ToNot(HaveOccurred())
@@ -224,7 +254,7 @@ var _ = Describe("concreteFactory", func() {
 	It("prepare", func() {
 		action, err := factory.Create("prepare")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewPrepare(applier)))
+		Expect(action).To(Equal(boshaction.NewPrepare(applier, specService)))
 	})
 
 	It("delete_arp_entries", func() {
@@ -55,6 +55,8 @@ type TestAction struct {
 	Canceled  bool
 	CancelErr error
 
+	ProgressValue interface{}
+
 	ProtocolVersion boshaction.ProtocolVersion
 }
 
@@ -84,3 +86,7 @@ func (a *TestAction) Cancel() error {
 	a.Canceled = true
 	return a.CancelErr
 }
+
+func (a *TestAction) Progress() interface{} {
+	return a.ProgressValue
+}
@@ -7,13 +7,21 @@ import (
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+type FetchLogsResponse struct {
+	BlobstoreID string        `json:"blobstore_id"`
+	SHA1Digest  string        `json:"sha1"`
+	Manifest    *LogsManifest `json:"manifest,omitempty"`
+}
+
 type FetchLogsAction struct {
 	compressor  boshcmd.Compressor
 	copier      boshcmd.Copier
 	blobstore   blobstore_delegator.BlobstoreDelegator
 	settingsDir boshdirs.Provider
+	bundler     logsBundler
 }
 
 func NewFetchLogs(
@@ -21,11 +29,13 @@ func NewFetchLogs(
 	copier boshcmd.Copier,
 	blobstore blobstore_delegator.BlobstoreDelegator,
 	settingsDir boshdirs.Provider,
+	fs boshsys.FileSystem,
 ) (action FetchLogsAction) {
 	action.compressor = compressor
 	action.copier = copier
 	action.blobstore = blobstore
 	action.settingsDir = settingsDir
+	action.bundler = logsBundler{fs: fs}
 	return
 }
 
@@ -41,8 +51,8 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logType string, filters []string) (map[string]string, error) {
-	value := map[string]string{}
+func (a FetchLogsAction) Run(logType string, filters []string, options ...FetchLogsOptions) (FetchLogsResponse, error) {
+	var value FetchLogsResponse
 	var logsDir string
 
 	switch logType {
@@ -60,7 +70,17 @@ func (a FetchLogsAction) Run(logType string, filters []string) (map[string]strin
 		return value, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
+	var tmpDir string
+	var manifest *LogsManifest
+	var err error
+
+	if len(options) > 0 && options[0].isSet() {
+		var bundleManifest LogsManifest
+		tmpDir, bundleManifest, err = a.bundler.CopyToTemp(logsDir, filters, options[0])
+		manifest = &bundleManifest
+	} else {
+		tmpDir, err = a.copier.FilteredCopyToTemp(logsDir, filters)
+	}
 	if err != nil {
 		return value, bosherr.WrapError(err, "Copying filtered files to temp directory")
 	}
@@ -81,7 +101,7 @@ func (a FetchLogsAction) Run(logType string, filters []string) (map[string]strin
 		return value, bosherr.WrapError(err, "Create file on blobstore")
 	}
 
-	value = map[string]string{"blobstore_id": blobID, "sha1": multidigestSha.String()}
+	value = FetchLogsResponse{BlobstoreID: blobID, SHA1Digest: multidigestSha.String(), Manifest: manifest}
 	return value, nil
 }
 
@@ -1,7 +1,9 @@
 package action_test
 
 import (
+	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,6 +14,9 @@ import (
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("FetchLogsAction", func() {
@@ -28,7 +33,7 @@ var _ = Describe("FetchLogsAction", func() {
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
 		dirProvider = boshdirs.NewProvider("/fake/dir")
 		copier = fakecmd.NewFakeCopier()
-		fetchLogsAction = action.NewFetchLogs(compressor, copier, blobstore, dirProvider)
+		fetchLogsAction = action.NewFetchLogs(compressor, copier, blobstore, dirProvider, fakesys.NewFakeFileSystem())
 	})
 
 	AssertActionIsAsynchronous(fetchLogsAction)
@@ -121,5 +126,117 @@ var _ = Describe("FetchLogsAction", func() {
 			afterCleanUpTarballPath = compressor.CleanUpTarballPath
 			Expect(afterCleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
 		})
+
+		Context("with since, until and max_bytes options", func() {
+			var (
+				baseDir  string
+				bundle   map[string]string
+				now      time.Time
+				hourAgo  time.Time
+				dayAgo   time.Time
+				writeLog func(string, string, time.Time)
+			)
+
+			BeforeEach(func() {
+				var err error
+				baseDir, err = os.MkdirTemp("", "fetch-logs")
+				Expect(err).ToNot(HaveOccurred())
+
+				dirProvider = boshdirs.NewProvider(baseDir)
+				fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+				fetchLogsAction = action.NewFetchLogs(compressor, copier, blobstore, dirProvider, fs)
+
+				now = time.Now().Truncate(time.Second)
+				hourAgo = now.Add(-time.Hour)
+				dayAgo = now.Add(-24 * time.Hour)
+
+				writeLog = func(relativePath, contents string, mtime time.Time) {
+					path := filepath.Join(dirProvider.LogsDir(), relativePath)
+					Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+					Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+					Expect(os.Chtimes(path, mtime, mtime)).To(Succeed())
+				}
+
+				writeLog("job/current.log", "0123456789", now)
+				writeLog("job/older.log", "abcdefghij", hourAgo)
+				writeLog("job/rotated.log.1", "rotated", dayAgo)
+
+				bundle = map[string]string{}
+				blobstore.WriteStub = func(signedURL, fileName string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+					err := filepath.Walk(compressor.CompressFilesInDirDir, func(path string, info os.FileInfo, err error) error {
+						if err != nil || info.IsDir() {
+							return err
+						}
+						contents, err := os.ReadFile(path)
+						bundle[path[len(compressor.CompressFilesInDirDir)+1:]] = string(contents)
+						return err
+					})
+					return "my-blob-id", boshcrypto.MultipleDigest{}, err
+				}
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(baseDir)).To(Succeed())
+				Expect(os.RemoveAll(compressor.CompressFilesInDirDir)).To(Succeed())
+			})
+
+			It("skips files modified outside of the window", func() {
+				since := hourAgo.Add(-time.Minute)
+
+				logs, err := fetchLogsAction.Run("job", nil, action.FetchLogsOptions{Since: &since})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copier.FilteredCopyToTempDir).To(BeEmpty())
+				Expect(bundle).To(Equal(map[string]string{
+					"job/current.log": "0123456789",
+					"job/older.log":   "abcdefghij",
+				}))
+
+				Expect(logs.BlobstoreID).To(Equal("my-blob-id"))
+				Expect(logs.Manifest.Included).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/current.log", Size: 10, ModTime: now, BytesIncluded: 10},
+					{Path: "job/older.log", Size: 10, ModTime: hourAgo, BytesIncluded: 10},
+				}))
+				Expect(logs.Manifest.Skipped).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/rotated.log.1", Size: 7, ModTime: dayAgo, Reason: "modified before since"},
+				}))
+				Expect(logs.Manifest.Truncated).To(BeEmpty())
+			})
+
+			It("skips files modified after until", func() {
+				until := hourAgo
+
+				logs, err := fetchLogsAction.Run("job", []string{"job"}, action.FetchLogsOptions{Until: &until})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(bundle).To(Equal(map[string]string{
+					"job/older.log":     "abcdefghij",
+					"job/rotated.log.1": "rotated",
+				}))
+				Expect(logs.Manifest.Skipped).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/current.log", Size: 10, ModTime: now, Reason: "modified after until"},
+				}))
+			})
+
+			It("keeps the newest logs and truncates files to their tail to stay within max_bytes", func() {
+				logs, err := fetchLogsAction.Run("job", nil, action.FetchLogsOptions{MaxBytes: 14})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(bundle).To(Equal(map[string]string{
+					"job/current.log": "0123456789",
+					"job/older.log":   "ghij",
+				}))
+
+				Expect(logs.Manifest.Included).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/current.log", Size: 10, ModTime: now, BytesIncluded: 10},
+				}))
+				Expect(logs.Manifest.Truncated).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/older.log", Size: 10, ModTime: hourAgo, BytesIncluded: 4, Reason: "max bundle size reached"},
+				}))
+				Expect(logs.Manifest.Skipped).To(Equal([]action.LogsManifestEntry{
+					{Path: "job/rotated.log.1", Size: 7, ModTime: dayAgo, Reason: "max bundle size reached"},
+				}))
+			})
+		})
 	})
 })
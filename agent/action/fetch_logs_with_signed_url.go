@@ -7,6 +7,7 @@ import (
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type FetchLogsWithSignedURLRequest struct {
@@ -14,10 +15,13 @@ type FetchLogsWithSignedURLRequest struct {
 	LogType          string            `json:"log_type"`
 	Filters          []string          `json:"filters"`
 	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+
+	FetchLogsOptions
 }
 
 type FetchLogsWithSignedURLResponse struct {
-	SHA1Digest string `json:"sha1"`
+	SHA1Digest string        `json:"sha1"`
+	Manifest   *LogsManifest `json:"manifest,omitempty"`
 }
 
 type FetchLogsWithSignedURLAction struct {
@@ -25,17 +29,20 @@ type FetchLogsWithSignedURLAction struct {
 	copier        boshcmd.Copier
 	settingsDir   boshdirs.Provider
 	blobDelegator blobdelegator.BlobstoreDelegator
+	bundler       logsBundler
 }
 
 func NewFetchLogsWithSignedURLAction(
 	compressor boshcmd.Compressor,
 	copier boshcmd.Copier,
 	settingsDir boshdirs.Provider,
-	blobDelegator blobdelegator.BlobstoreDelegator) (action FetchLogsWithSignedURLAction) {
+	blobDelegator blobdelegator.BlobstoreDelegator,
+	fs boshsys.FileSystem) (action FetchLogsWithSignedURLAction) {
 	action.compressor = compressor
 	action.copier = copier
 	action.settingsDir = settingsDir
 	action.blobDelegator = blobDelegator
+	action.bundler = logsBundler{fs: fs}
 	return
 }
 
@@ -70,7 +77,17 @@ func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest)
 		return FetchLogsWithSignedURLResponse{}, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
+	var tmpDir string
+	var manifest *LogsManifest
+	var err error
+
+	if request.FetchLogsOptions.isSet() {
+		var bundleManifest LogsManifest
+		tmpDir, bundleManifest, err = a.bundler.CopyToTemp(logsDir, filters, request.FetchLogsOptions)
+		manifest = &bundleManifest
+	} else {
+		tmpDir, err = a.copier.FilteredCopyToTemp(logsDir, filters)
+	}
 	if err != nil {
 		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Copying filtered files to temp directory")
 	}
@@ -93,6 +110,7 @@ func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest)
 
 	return FetchLogsWithSignedURLResponse{
 		SHA1Digest: digest.String(),
+		Manifest:   manifest,
 	}, nil
 }
 
@@ -1,7 +1,10 @@
 package action_test
 
 import (
+	"encoding/json"
+	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,6 +15,9 @@ import (
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("FetchLogsWithSignedURLAction", func() {
@@ -29,7 +35,7 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 		copier = fakecmd.NewFakeCopier()
 		blobDelegator = &fakeblobdelegator.FakeBlobstoreDelegator{}
 
-		fetchLogsWithSignedURLAction = action.NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobDelegator)
+		fetchLogsWithSignedURLAction = action.NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobDelegator, fakesys.NewFakeFileSystem())
 	})
 
 	AssertActionIsAsynchronous(fetchLogsWithSignedURLAction)
@@ -117,5 +123,55 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 			afterCleanUpTarballPath = compressor.CleanUpTarballPath
 			Expect(afterCleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
 		})
+
+		Context("with since, until and max_bytes options", func() {
+			var baseDir string
+
+			BeforeEach(func() {
+				var err error
+				baseDir, err = os.MkdirTemp("", "fetch-logs-with-signed-url")
+				Expect(err).ToNot(HaveOccurred())
+
+				dirProvider = boshdirs.NewProvider(baseDir)
+				fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+				fetchLogsWithSignedURLAction = action.NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobDelegator, fs)
+
+				path := filepath.Join(dirProvider.AgentLogsDir(), "current")
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte("0123456789"), 0644)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(baseDir)).To(Succeed())
+				Expect(os.RemoveAll(compressor.CompressFilesInDirDir)).To(Succeed())
+			})
+
+			It("bundles the tail of the logs and returns a manifest", func() {
+				var request action.FetchLogsWithSignedURLRequest
+				err := json.Unmarshal([]byte(`{"signed_url":"foobar","log_type":"agent","since":"2000-01-01T00:00:00Z","max_bytes":4}`), &request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*request.Since).To(Equal(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+				var bundled []byte
+				blobDelegator.WriteStub = func(signedURL, fileName string, headers map[string]string) (string, boshcrypto.MultipleDigest, error) {
+					var readErr error
+					bundled, readErr = os.ReadFile(filepath.Join(compressor.CompressFilesInDirDir, "current"))
+					return "", boshcrypto.MultipleDigest{}, readErr
+				}
+
+				logs, err := fetchLogsWithSignedURLAction.Run(request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copier.FilteredCopyToTempDir).To(BeEmpty())
+				Expect(string(bundled)).To(Equal("6789"))
+
+				Expect(logs.Manifest.Included).To(BeEmpty())
+				Expect(logs.Manifest.Skipped).To(BeEmpty())
+				Expect(logs.Manifest.Truncated).To(HaveLen(1))
+				Expect(logs.Manifest.Truncated[0].Path).To(Equal("current"))
+				Expect(logs.Manifest.Truncated[0].Size).To(Equal(int64(10)))
+				Expect(logs.Manifest.Truncated[0].BytesIncluded).To(Equal(int64(4)))
+			})
+		})
 	})
 })
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	PreSnapshotScriptName = "pre-snapshot"
+
+	DefaultFreezeTimeout = 2 * time.Minute
+	MaxFreezeTimeout     = 30 * time.Minute
+)
+
+type FreezeDiskOptions struct {
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+type FreezeDiskAction struct {
+	settingsService   boshsettings.Service
+	platform          boshplatform.Platform
+	dirProvider       boshdirs.Provider
+	specService       boshas.V1Service
+	jobScriptProvider boshscript.JobScriptProvider
+	watchdog          FreezeWatchdog
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFreezeDisk(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	dirProvider boshdirs.Provider,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	watchdog FreezeWatchdog,
+	logger boshlog.Logger,
+) FreezeDiskAction {
+	return FreezeDiskAction{
+		settingsService:   settingsService,
+		platform:          platform,
+		dirProvider:       dirProvider,
+		specService:       specService,
+		jobScriptProvider: jobScriptProvider,
+		watchdog:          watchdog,
+
+		logTag: "Freeze Disk Action",
+		logger: logger,
+	}
+}
+
+func (a FreezeDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a FreezeDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a FreezeDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a FreezeDiskAction) Run(diskCid string, options ...FreezeDiskOptions) (map[string]string, error) {
+	timeout := DefaultFreezeTimeout
+	if len(options) > 0 && options[0].TimeoutSeconds > 0 {
+		timeout = time.Duration(options[0].TimeoutSeconds) * time.Second
+	}
+
+	if timeout > MaxFreezeTimeout {
+		return nil, bosherr.Errorf("Freeze timeout must not exceed %s", MaxFreezeTimeout)
+	}
+
+	mountPoint, err := persistentDiskMountPoint(a.settingsService, a.platform, a.dirProvider, diskCid)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isMigrating, err := a.platform.IsMountPoint(a.dirProvider.StoreMigrationDir())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking persistent disk migration mount point")
+	}
+
+	if isMigrating {
+		return nil, bosherr.Errorf("Persistent disk %s is being migrated", diskCid)
+	}
+
+	err = a.runPreSnapshotScripts()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Running pre-snapshot scripts")
+	}
+
+	a.logger.Info(a.logTag, "Freezing persistent disk %s for at most %s", diskCid, timeout)
+
+	err = a.watchdog.Freeze(mountPoint, timeout)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Freezing persistent disk")
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a FreezeDiskAction) runPreSnapshotScripts() error {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting current spec")
+	}
+
+	scripts := make([]boshscript.Script, 0, len(currentSpec.Jobs()))
+	for _, job := range currentSpec.Jobs() {
+		scripts = append(scripts, a.jobScriptProvider.NewScript(job.BundleName(), PreSnapshotScriptName, nil))
+	}
+
+	return a.jobScriptProvider.NewParallelScript(PreSnapshotScriptName, scripts).Run()
+}
+
+func (a FreezeDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a FreezeDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+// persistentDiskMountPoint returns where the given persistent disk is mounted
+func persistentDiskMountPoint(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	dirProvider boshdirs.Provider,
+	diskCid string,
+) (string, error) {
+	diskSettings, err := settingsService.GetPersistentDiskSettings(diskCid)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting persistent disk settings")
+	}
+
+	isMounted, err := platform.IsPersistentDiskMounted(diskSettings)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+
+	if !isMounted {
+		return "", bosherr.Errorf("Persistent disk %s is not mounted", diskCid)
+	}
+
+	return dirProvider.StoreDir(), nil
+}
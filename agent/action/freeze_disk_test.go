@@ -0,0 +1,172 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	"github.com/cloudfoundry/bosh-agent/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("FreezeDiskAction", func() {
+	var (
+		settingsService   *fakesettings.FakeSettingsService
+		platform          *platformfakes.FakePlatform
+		specService       *fakeas.FakeV1Service
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		parallelScript    *scriptfakes.FakeCancellableScript
+		fakeClock         *fakeclock.FakeClock
+		freezeDiskAction  action.FreezeDiskAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"fake-disk-cid": {ID: "fake-disk-cid", Path: "/dev/sdf"},
+			},
+		}
+		platform = &platformfakes.FakePlatform{}
+		platform.IsPersistentDiskMountedReturns(true, nil)
+
+		specService = fakeas.NewFakeV1Service()
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		parallelScript = &scriptfakes.FakeCancellableScript{}
+		jobScriptProvider.NewParallelScriptReturns(parallelScript)
+		jobScriptProvider.NewScriptStub = func(jobName, scriptName string, _ map[string]string) boshscript.Script {
+			script := &scriptfakes.FakeScript{}
+			script.TagReturns(jobName + "/" + scriptName)
+			return script
+		}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		watchdog := action.NewFreezeWatchdog(platform, fakesys.NewFakeFileSystem(), "/fake-freeze-deadlines.json", fakeClock, logger)
+		dirProvider := boshdirs.NewProvider("/fake-base-dir")
+
+		freezeDiskAction = action.NewFreezeDisk(settingsService, platform, dirProvider, specService, jobScriptProvider, watchdog, logger)
+	})
+
+	AssertActionIsAsynchronous(freezeDiskAction)
+	AssertActionIsNotPersistent(freezeDiskAction)
+	AssertActionIsLoggable(freezeDiskAction)
+
+	AssertActionIsNotResumable(freezeDiskAction)
+	AssertActionIsNotCancelable(freezeDiskAction)
+
+	Describe("Run", func() {
+		It("runs the pre-snapshot scripts of all jobs before freezing the persistent disk", func() {
+			specService.Spec = boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "foo"}, {Name: "bar"}},
+				},
+				RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+			}
+
+			parallelScript.RunStub = func() error {
+				Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+				return nil
+			}
+
+			value, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), value, "{}")
+
+			scriptName, scripts := jobScriptProvider.NewParallelScriptArgsForCall(0)
+			Expect(scriptName).To(Equal("pre-snapshot"))
+			Expect(scripts).To(HaveLen(2))
+			Expect(scripts[0].Tag()).To(Equal("foo/pre-snapshot"))
+			Expect(scripts[1].Tag()).To(Equal("bar/pre-snapshot"))
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+			Expect(platform.FreezeFilesystemArgsForCall(0)).To(boshassert.MatchPath("/fake-base-dir/store"))
+		})
+
+		It("thaws the persistent disk after the default timeout", func() {
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeClock.WaitForWatcherAndIncrement(action.DefaultFreezeTimeout)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+		})
+
+		It("thaws the persistent disk after the requested timeout", func() {
+			_, err := freezeDiskAction.Run("fake-disk-cid", action.FreezeDiskOptions{TimeoutSeconds: 10})
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+		})
+
+		It("returns an error if the requested timeout is too long", func() {
+			_, err := freezeDiskAction.Run("fake-disk-cid", action.FreezeDiskOptions{TimeoutSeconds: 3600})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must not exceed"))
+
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if the persistent disk is not mounted", func() {
+			platform.IsPersistentDiskMountedReturns(false, nil)
+
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Persistent disk fake-disk-cid is not mounted"))
+
+			Expect(parallelScript.RunCallCount()).To(Equal(0))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if getting the persistent disk settings fails", func() {
+			settingsService.GetPersistentDiskSettingsError = errors.New("fake-settings-err")
+
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-settings-err"))
+		})
+
+		It("returns an error if the persistent disk is being migrated", func() {
+			platform.IsMountPointReturns("", true, nil)
+
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is being migrated"))
+
+			path := platform.IsMountPointArgsForCall(0)
+			Expect(path).To(boshassert.MatchPath("/fake-base-dir/store_migration_target"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("does not freeze the persistent disk if a pre-snapshot script fails", func() {
+			parallelScript.RunReturns(errors.New("fake-script-err"))
+
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-script-err"))
+
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if freezing fails", func() {
+			platform.FreezeFilesystemReturns(errors.New("fake-freeze-err"))
+
+			_, err := freezeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+		})
+	})
+})
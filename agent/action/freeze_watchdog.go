@@ -0,0 +1,239 @@
+package action
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type filesystemFreezer interface {
+	FreezeFilesystem(mountPoint string) error
+	ThawFilesystem(mountPoint string) error
+}
+
+type frozenFilesystem struct {
+	deadline time.Time
+	thawedCh chan struct{}
+}
+
+// FreezeWatchdog thaws filesystems that were not thawed within a timeout
+// so that a director that went away cannot leave them frozen.
+// It is shared by the freeze_disk and thaw_disk actions.
+//
+// Deadlines are saved to statePath so that freezes outlive agent restarts.
+type FreezeWatchdog struct {
+	freezer     filesystemFreezer
+	fs          boshsys.FileSystem
+	statePath   string
+	timeService clock.Clock
+
+	lock   *sync.Mutex
+	frozen map[string]frozenFilesystem
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFreezeWatchdog(
+	freezer filesystemFreezer,
+	fs boshsys.FileSystem,
+	statePath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) FreezeWatchdog {
+	return FreezeWatchdog{
+		freezer:     freezer,
+		fs:          fs,
+		statePath:   statePath,
+		timeService: timeService,
+
+		lock:   &sync.Mutex{},
+		frozen: map[string]frozenFilesystem{},
+
+		logTag: "Freeze Watchdog",
+		logger: logger,
+	}
+}
+
+func (w FreezeWatchdog) Freeze(mountPoint string, timeout time.Duration) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, found := w.frozen[mountPoint]; found {
+		return bosherr.Errorf("Filesystem mounted on %s is already frozen", mountPoint)
+	}
+
+	deadline := w.timeService.Now().Add(timeout)
+
+	// The deadline is saved first so that a restarted agent
+	// cannot miss a frozen filesystem
+	w.frozen[mountPoint] = frozenFilesystem{deadline: deadline, thawedCh: make(chan struct{})}
+
+	err := w.saveDeadlines()
+	if err != nil {
+		delete(w.frozen, mountPoint)
+		return err
+	}
+
+	err = w.freezer.FreezeFilesystem(mountPoint)
+	if err != nil {
+		delete(w.frozen, mountPoint)
+		w.saveDeadlinesOrWarn()
+		return err
+	}
+
+	go w.thawAt(mountPoint, w.frozen[mountPoint])
+
+	return nil
+}
+
+// Thaw also thaws filesystems the watchdog does not know about,
+// e.g. ones frozen by hand
+func (w FreezeWatchdog) Thaw(mountPoint string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if frozen, found := w.frozen[mountPoint]; found {
+		close(frozen.thawedCh)
+		delete(w.frozen, mountPoint)
+		w.saveDeadlinesOrWarn()
+	}
+
+	return w.freezer.ThawFilesystem(mountPoint)
+}
+
+// Restore takes over the freezes saved before the agent restarted. It thaws
+// filesystems whose deadline passed and watches the others until their
+// deadline. If the saved deadlines cannot be read it thaws fallbackMountPoint,
+// where freezes are made.
+func (w FreezeWatchdog) Restore(fallbackMountPoint string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.fs.FileExists(w.statePath) {
+		return nil
+	}
+
+	deadlines, err := w.readDeadlines()
+	if err != nil {
+		w.logger.Warn(w.logTag, "Ignoring unreadable freeze deadlines %s: %s", w.statePath, err.Error())
+		deadlines = map[string]time.Time{fallbackMountPoint: {}}
+	}
+
+	now := w.timeService.Now()
+
+	for mountPoint, deadline := range deadlines {
+		if deadline.After(now) {
+			w.logger.Info(w.logTag, "Watching filesystem mounted on %s that stays frozen until %s", mountPoint, deadline)
+
+			w.frozen[mountPoint] = frozenFilesystem{deadline: deadline, thawedCh: make(chan struct{})}
+			go w.thawAt(mountPoint, w.frozen[mountPoint])
+
+			continue
+		}
+
+		w.logger.Warn(w.logTag, "Thawing filesystem mounted on %s because it was not thawed by %s", mountPoint, deadline)
+
+		// Filesystems are no longer frozen after a reboot
+		err = w.freezer.ThawFilesystem(mountPoint)
+		if err != nil {
+			w.logger.Warn(w.logTag, "Thawing filesystem mounted on %s: %s", mountPoint, err.Error())
+		}
+	}
+
+	return w.saveDeadlines()
+}
+
+func (w FreezeWatchdog) thawAt(mountPoint string, frozen frozenFilesystem) {
+	defer w.logger.HandlePanic("Freeze Watchdog")
+
+	timer := w.timeService.NewTimer(frozen.deadline.Sub(w.timeService.Now()))
+	defer timer.Stop()
+
+	select {
+	case <-frozen.thawedCh:
+		return
+	case <-timer.C():
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// Thaw may have won the race for the lock
+	if w.frozen[mountPoint].thawedCh != frozen.thawedCh {
+		return
+	}
+
+	delete(w.frozen, mountPoint)
+	w.saveDeadlinesOrWarn()
+
+	w.logger.Warn(w.logTag, "Thawing filesystem mounted on %s because it was not thawed by %s", mountPoint, frozen.deadline)
+
+	err := w.freezer.ThawFilesystem(mountPoint)
+	if err != nil {
+		w.logger.Error(w.logTag, "Thawing filesystem mounted on %s: %s", mountPoint, err.Error())
+	}
+}
+
+func (w FreezeWatchdog) readDeadlines() (map[string]time.Time, error) {
+	contents, err := w.fs.ReadFile(w.statePath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading freeze deadlines")
+	}
+
+	var deadlines map[string]time.Time
+
+	err = json.Unmarshal(contents, &deadlines)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling freeze deadlines")
+	}
+
+	return deadlines, nil
+}
+
+func (w FreezeWatchdog) saveDeadlines() error {
+	if len(w.frozen) == 0 {
+		err := w.fs.RemoveAll(w.statePath)
+		if err != nil {
+			return bosherr.WrapError(err, "Removing freeze deadlines")
+		}
+
+		return nil
+	}
+
+	deadlines := map[string]time.Time{}
+	for mountPoint, frozen := range w.frozen {
+		deadlines[mountPoint] = frozen.deadline
+	}
+
+	contents, err := json.Marshal(deadlines)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling freeze deadlines")
+	}
+
+	err = w.fs.WriteFile(w.statePath+".tmp", contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing freeze deadlines")
+	}
+
+	err = w.fs.Rename(w.statePath+".tmp", w.statePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Renaming freeze deadlines")
+	}
+
+	return nil
+}
+
+// saveDeadlinesOrWarn is used after thawing, when a stale deadline
+// only causes an extra thaw on restart
+func (w FreezeWatchdog) saveDeadlinesOrWarn() {
+	err := w.saveDeadlines()
+	if err != nil {
+		w.logger.Warn(w.logTag, "Saving freeze deadlines: %s", err.Error())
+	}
+}
@@ -0,0 +1,223 @@
+package action_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("FreezeWatchdog", func() {
+	var (
+		platform  *platformfakes.FakePlatform
+		fs        *fakesys.FakeFileSystem
+		fakeClock *fakeclock.FakeClock
+		watchdog  action.FreezeWatchdog
+	)
+
+	const statePath = "/fake-freeze-deadlines.json"
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		fs = fakesys.NewFakeFileSystem()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		watchdog = action.NewFreezeWatchdog(platform, fs, statePath, fakeClock, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	savedDeadlines := func() map[string]time.Time {
+		contents, err := fs.ReadFile(statePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var deadlines map[string]time.Time
+		Expect(json.Unmarshal(contents, &deadlines)).To(Succeed())
+
+		return deadlines
+	}
+
+	Describe("Freeze", func() {
+		It("freezes the filesystem", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+			Expect(platform.FreezeFilesystemArgsForCall(0)).To(Equal("/fake-mount"))
+		})
+
+		It("saves the deadline until the filesystem is thawed", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(savedDeadlines()).To(HaveKey("/fake-mount"))
+			Expect(savedDeadlines()["/fake-mount"].Equal(fakeClock.Now().Add(time.Minute))).To(BeTrue())
+
+			err = watchdog.Thaw("/fake-mount")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("does not freeze the filesystem if the deadline cannot be saved", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("thaws the filesystem when it was not thawed within the timeout", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeClock.WaitForWatcherAndIncrement(59 * time.Second)
+			Consistently(platform.ThawFilesystemCallCount).Should(Equal(0))
+
+			fakeClock.Increment(time.Second)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-mount"))
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("allows freezing again after the filesystem was thawed by the timeout", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+
+			err = watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if the filesystem is already frozen", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already frozen"))
+			Expect(platform.FreezeFilesystemCallCount()).To(Equal(1))
+		})
+
+		It("returns an error and does not watch the filesystem if freezing fails", func() {
+			platform.FreezeFilesystemReturns(errors.New("fake-freeze-err"))
+
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+
+			Consistently(fakeClock.WatcherCount).Should(Equal(0))
+		})
+	})
+
+	Describe("Thaw", func() {
+		It("thaws the filesystem and stops watching it", func() {
+			err := watchdog.Freeze("/fake-mount", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			err = watchdog.Thaw("/fake-mount")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+
+			fakeClock.Increment(time.Minute)
+			Consistently(platform.ThawFilesystemCallCount).Should(Equal(1))
+		})
+
+		It("thaws filesystems it did not freeze", func() {
+			err := watchdog.Thaw("/fake-mount")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-mount"))
+		})
+
+		It("returns an error if thawing fails", func() {
+			platform.ThawFilesystemReturns(errors.New("fake-thaw-err"))
+
+			err := watchdog.Thaw("/fake-mount")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+		})
+	})
+
+	Describe("Restore", func() {
+		writeDeadlines := func(deadlines map[string]time.Time) {
+			contents, err := json.Marshal(deadlines)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.WriteFile(statePath, contents)).To(Succeed())
+		}
+
+		It("does nothing without saved deadlines", func() {
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("thaws filesystems whose deadline passed", func() {
+			writeDeadlines(map[string]time.Time{"/fake-mount": fakeClock.Now().Add(-time.Second)})
+
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-mount"))
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("thaws other filesystems when their deadline passes", func() {
+			writeDeadlines(map[string]time.Time{"/fake-mount": fakeClock.Now().Add(time.Minute)})
+
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+			Expect(savedDeadlines()).To(HaveKey("/fake-mount"))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(platform.ThawFilesystemCallCount).Should(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-mount"))
+		})
+
+		It("stops watching filesystems that are thawed before their deadline", func() {
+			writeDeadlines(map[string]time.Time{"/fake-mount": fakeClock.Now().Add(time.Minute)})
+
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			err = watchdog.Thaw("/fake-mount")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("thaws the fallback mount point if the deadlines cannot be read", func() {
+			Expect(fs.WriteFileString(statePath, "{not-json")).To(Succeed())
+
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-store"))
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("does not fail when filesystems are no longer frozen", func() {
+			writeDeadlines(map[string]time.Time{"/fake-mount": fakeClock.Now().Add(-time.Second)})
+			platform.ThawFilesystemReturns(errors.New("fake-thaw-err"))
+
+			err := watchdog.Restore("/fake-store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+	})
+})
@@ -5,6 +5,7 @@ import (
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -50,6 +51,9 @@ type GetStateV1ApplySpec struct {
 	Vitals    *boshvitals.Vitals     `json:"vitals,omitempty"`
 	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 	VM        boshsettings.VM        `json:"vm"`
+
+	// JobResources is the resource usage of each job's cgroup
+	JobResources map[string]boshcgroup.Stats `json:"job_resources,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -60,6 +64,7 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 
 	var vitals boshvitals.Vitals
 	var vitalsReference *boshvitals.Vitals
+	var jobResources map[string]boshcgroup.Stats
 
 	if len(filters) > 0 && filters[0] == "full" {
 		vitals, err = a.vitalsService.Get()
@@ -67,6 +72,11 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
 		}
 		vitalsReference = &vitals
+
+		jobResources, err = a.jobSupervisor.JobResources()
+		if err != nil {
+			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting job resources")
+		}
 	}
 
 	processes, err := a.jobSupervisor.Processes()
@@ -83,6 +93,7 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		processes,
 		settings.VM,
+		jobResources,
 	}
 
 	if value.NetworkSpecs == nil {
@@ -11,6 +11,7 @@ import (
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -105,6 +106,10 @@ var _ = Describe("GetState", func() {
 					vitalsService.GetReturns(expectedVitals, nil)
 					expectedVM := map[string]interface{}{"name": "vm-abc-def"}
 
+					jobSupervisor.JobResourcesStatus = map[string]boshcgroup.Stats{
+						"fake-job": {CPUUsageNanos: 100, MemoryBytes: 2048, Pids: 3, OOMKills: 1},
+					}
+
 					expectedProcesses := []boshjobsuper.Process{
 						boshjobsuper.Process{
 							Name:  "fake-process-name-1",
@@ -124,9 +129,18 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONString(GinkgoT(), state.Deployment, `"fake-deployment"`)
 					Expect(*state.Vitals).To(Equal(expectedVitals))
 					Expect(state.Processes).To(Equal(expectedProcesses))
+					Expect(state.JobResources).To(Equal(jobSupervisor.JobResourcesStatus))
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
 
+				It("returns error when job resources cannot be retrieved", func() {
+					jobSupervisor.JobResourcesErr = errors.New("fake-job-resources-error")
+
+					_, err := getStateAction.Run("full")
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-job-resources-error"))
+				})
+
 				Describe("non-populated field formatting", func() {
 					It("returns network as empty hash if not set", func() {
 						specService.Spec = boshas.V1ApplySpec{NetworkSpecs: nil}
@@ -36,8 +36,11 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 
 	if task.State == boshtask.StateRunning {
 		return boshtask.StateValue{
-			AgentTaskID: task.ID,
-			State:       task.State,
+			AgentTaskID:      task.ID,
+			State:            task.State,
+			QueuePosition:    task.QueuePosition,
+			QueueWaitSeconds: task.QueueWait.Seconds(),
+			Progress:         task.Progress(),
 		}, nil
 	}
 
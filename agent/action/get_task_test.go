@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -44,6 +45,37 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	It("returns queue position and wait time of a queued task", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:            "fake-task-id",
+			State:         boshtask.StateRunning,
+			QueuePosition: 2,
+			QueueWait:     1500 * time.Millisecond,
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","queue_position":2,"queue_wait_seconds":1.5}`)
+	})
+
+	It("returns the progress reported by the action of a running task", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:    "fake-task-id",
+			State: boshtask.StateRunning,
+			ProgressFunc: func() interface{} {
+				return map[string]int{"files_copied": 3}
+			},
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","progress":{"files_copied":3}}`)
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
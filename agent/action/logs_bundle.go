@@ -0,0 +1,208 @@
+package action
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// FetchLogsOptions narrows down which log files end up in a logs bundle.
+// Zero values leave the corresponding limit unset.
+type FetchLogsOptions struct {
+	Since    *time.Time `json:"since,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+	MaxBytes int64      `json:"max_bytes,omitempty"`
+}
+
+func (o FetchLogsOptions) isSet() bool {
+	return o.Since != nil || o.Until != nil || o.MaxBytes > 0
+}
+
+type LogsManifestEntry struct {
+	Path          string    `json:"path"`
+	Size          int64     `json:"size"`
+	ModTime       time.Time `json:"mtime"`
+	BytesIncluded int64     `json:"bytes_included"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+type LogsManifest struct {
+	Included  []LogsManifestEntry `json:"included"`
+	Skipped   []LogsManifestEntry `json:"skipped"`
+	Truncated []LogsManifestEntry `json:"truncated"`
+}
+
+// logsBundler copies log files into a temporary directory honouring
+// FetchLogsOptions. Files are considered newest first so that the size
+// limit keeps the most recent logs; files that do not fit completely
+// are cut down to their tail.
+type logsBundler struct {
+	fs boshsys.FileSystem
+}
+
+func (b logsBundler) CopyToTemp(logsDir string, filters []string, options FetchLogsOptions) (string, LogsManifest, error) {
+	manifest := LogsManifest{
+		Included:  []LogsManifestEntry{},
+		Skipped:   []LogsManifestEntry{},
+		Truncated: []LogsManifestEntry{},
+	}
+
+	entries, err := b.matchingFiles(logsDir, filters)
+	if err != nil {
+		return "", manifest, err
+	}
+
+	tmpDir, err := b.fs.TempDir("bosh-agent-fetch-logs")
+	if err != nil {
+		return "", manifest, bosherr.WrapError(err, "Creating temporary directory")
+	}
+
+	remaining := options.MaxBytes
+
+	for _, entry := range entries {
+		switch {
+		case options.Since != nil && entry.ModTime.Before(*options.Since):
+			entry.Reason = "modified before since"
+			manifest.Skipped = append(manifest.Skipped, entry)
+			continue
+		case options.Until != nil && entry.ModTime.After(*options.Until):
+			entry.Reason = "modified after until"
+			manifest.Skipped = append(manifest.Skipped, entry)
+			continue
+		}
+
+		length := entry.Size
+		if options.MaxBytes > 0 {
+			if remaining <= 0 {
+				entry.Reason = "max bundle size reached"
+				manifest.Skipped = append(manifest.Skipped, entry)
+				continue
+			}
+
+			if length > remaining {
+				length = remaining
+			}
+			remaining -= length
+		}
+
+		err = b.copyTail(filepath.Join(logsDir, entry.Path), filepath.Join(tmpDir, entry.Path), entry.Size-length, length)
+		if err != nil {
+			_ = b.fs.RemoveAll(tmpDir)
+			return "", manifest, bosherr.WrapErrorf(err, "Copying '%s'", entry.Path)
+		}
+
+		entry.BytesIncluded = length
+
+		if length < entry.Size {
+			entry.Reason = "max bundle size reached"
+			manifest.Truncated = append(manifest.Truncated, entry)
+		} else {
+			manifest.Included = append(manifest.Included, entry)
+		}
+	}
+
+	err = b.fs.Chmod(tmpDir, os.FileMode(0755))
+	if err != nil {
+		_ = b.fs.RemoveAll(tmpDir)
+		return "", manifest, bosherr.WrapError(err, "Fixing permissions on temp dir")
+	}
+
+	return tmpDir, manifest, nil
+}
+
+// matchingFiles returns regular files matching filters, newest first.
+// Like FilteredCopyToTemp, filters naming a directory match everything below it.
+func (b logsBundler) matchingFiles(logsDir string, filters []string) ([]LogsManifestEntry, error) {
+	seen := map[string]bool{}
+	entries := []LogsManifestEntry{}
+
+	for _, filter := range filters {
+		pattern := filepath.Join(logsDir, filter)
+
+		info, err := b.fs.Stat(pattern)
+		if err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "**", "*")
+		}
+
+		matches, err := b.fs.RecursiveGlob(pattern)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Finding files matching filters")
+		}
+
+		for _, match := range matches {
+			relativePath, err := filepath.Rel(logsDir, match)
+			if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, "../") || seen[relativePath] {
+				continue
+			}
+
+			info, err := b.fs.Stat(match)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Getting file info for '%s'", match)
+			}
+
+			if !info.Mode().IsRegular() {
+				continue
+			}
+
+			seen[relativePath] = true
+			entries = append(entries, LogsManifestEntry{
+				Path:    relativePath,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].ModTime.Equal(entries[j].ModTime) {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].ModTime.After(entries[j].ModTime)
+	})
+
+	return entries, nil
+}
+
+func (b logsBundler) copyTail(src, dst string, offset, length int64) error {
+	err := b.fs.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Making destination directory")
+	}
+
+	srcFile, err := b.fs.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening source file")
+	}
+
+	defer func() {
+		_ = srcFile.Close()
+	}()
+
+	_, err = srcFile.Seek(offset, io.SeekStart)
+	if err != nil {
+		return bosherr.WrapError(err, "Seeking source file")
+	}
+
+	dstFile, err := b.fs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating destination file")
+	}
+
+	defer func() {
+		_ = dstFile.Close()
+	}()
+
+	// A log rotated since it was inspected simply ends up shorter
+	_, err = io.CopyN(dstFile, srcFile, length)
+	if err != nil && err != io.EOF {
+		return bosherr.WrapError(err, "Copying file contents")
+	}
+
+	return nil
+}
@@ -1,9 +1,10 @@
 package action
 
 import (
-	"errors"
+	"sync"
 
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -11,6 +12,14 @@ import (
 type MigrateDiskAction struct {
 	platform    boshplatform.Platform
 	dirProvider boshdirs.Provider
+
+	cancelCh chan struct{}
+	progress *migrateDiskProgress
+}
+
+type migrateDiskProgress struct {
+	lock  sync.Mutex
+	value boshdisk.MigrationProgress
 }
 
 func NewMigrateDisk(
@@ -19,6 +28,8 @@ func NewMigrateDisk(
 ) (action MigrateDiskAction) {
 	action.platform = platform
 	action.dirProvider = dirProvider
+	action.cancelCh = make(chan struct{}, 1)
+	action.progress = &migrateDiskProgress{}
 	return
 }
 
@@ -26,8 +37,10 @@ func (a MigrateDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
 	return true
 }
 
+// IsPersistent is true because copying a large disk can outlive the agent;
+// the platform continues an interrupted copy where it stopped
 func (a MigrateDiskAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a MigrateDiskAction) IsLoggable() bool {
@@ -35,7 +48,21 @@ func (a MigrateDiskAction) IsLoggable() bool {
 }
 
 func (a MigrateDiskAction) Run() (value interface{}, err error) {
-	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir())
+	// A cancellation arriving after the migration finished must not
+	// cancel the next one
+	defer func() {
+		select {
+		case <-a.cancelCh:
+		default:
+		}
+	}()
+
+	err = a.platform.MigratePersistentDisk(
+		a.dirProvider.StoreDir(),
+		a.dirProvider.StoreMigrationDir(),
+		a.cancelCh,
+		a.progress.set,
+	)
 	if err != nil {
 		err = bosherr.WrapError(err, "Migrating persistent disk")
 		return
@@ -46,9 +73,29 @@ func (a MigrateDiskAction) Run() (value interface{}, err error) {
 }
 
 func (a MigrateDiskAction) Resume() (interface{}, error) {
-	return nil, errors.New("not supported")
+	return a.Run()
 }
 
 func (a MigrateDiskAction) Cancel() error {
-	return errors.New("not supported")
+	select {
+	case a.cancelCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (a MigrateDiskAction) Progress() interface{} {
+	return a.progress.get()
+}
+
+func (p *migrateDiskProgress) set(value boshdisk.MigrationProgress) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.value = value
+}
+
+func (p *migrateDiskProgress) get() boshdisk.MigrationProgress {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.value
 }
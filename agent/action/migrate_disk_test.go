@@ -1,10 +1,13 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
@@ -23,20 +26,78 @@ var _ = Describe("Testing with Ginkgo", func() {
 	})
 
 	AssertActionIsAsynchronous(migrateDiskAction)
-	AssertActionIsNotPersistent(migrateDiskAction)
+	AssertActionIsPersistent(migrateDiskAction)
 	AssertActionIsLoggable(migrateDiskAction)
 
-	AssertActionIsNotResumable(migrateDiskAction)
-	AssertActionIsNotCancelable(migrateDiskAction)
-
 	It("migrate disk migrateDiskAction run", func() {
 		value, err := migrateDiskAction.Run()
 		Expect(err).ToNot(HaveOccurred())
 		boshassert.MatchesJSONString(GinkgoT(), value, "{}")
 
 		Expect(platform.MigratePersistentDiskCallCount()).To(Equal(1))
-		fromPath, toPath := platform.MigratePersistentDiskArgsForCall(0)
+		fromPath, toPath, _, _ := platform.MigratePersistentDiskArgsForCall(0)
 		Expect(fromPath).To(boshassert.MatchPath("/foo/store"))
 		Expect(toPath).To(boshassert.MatchPath("/foo/store_migration_target"))
 	})
+
+	It("returns an error if migrating fails", func() {
+		platform.MigratePersistentDiskReturns(errors.New("fake-migrate-err"))
+
+		_, err := migrateDiskAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-migrate-err"))
+	})
+
+	It("migrates the disk again when resumed", func() {
+		_, err := migrateDiskAction.Resume()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(platform.MigratePersistentDiskCallCount()).To(Equal(1))
+	})
+
+	It("reports the progress of the running migration", func() {
+		Expect(migrateDiskAction.Progress()).To(Equal(boshdisk.MigrationProgress{}))
+
+		platform.MigratePersistentDiskStub = func(_, _ string, _ <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) error {
+			progressFunc(boshdisk.MigrationProgress{BytesCopied: 4096, FilesCopied: 2})
+			Expect(migrateDiskAction.Progress()).To(Equal(boshdisk.MigrationProgress{BytesCopied: 4096, FilesCopied: 2}))
+			return nil
+		}
+
+		_, err := migrateDiskAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Cancel", func() {
+		It("signals the running migration to stop", func() {
+			platform.MigratePersistentDiskStub = func(_, _ string, cancelCh <-chan struct{}, _ boshdisk.MigrationProgressFunc) error {
+				Expect(migrateDiskAction.Cancel()).To(Succeed())
+				Eventually(cancelCh).Should(Receive())
+				return boshdisk.ErrMigrationCancelled
+			}
+
+			_, err := migrateDiskAction.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("migration cancelled"))
+		})
+
+		It("does not cancel a later migration once the migration finished", func() {
+			Expect(migrateDiskAction.Cancel()).To(Succeed())
+
+			platform.MigratePersistentDiskStub = func(_, _ string, _ <-chan struct{}, _ boshdisk.MigrationProgressFunc) error {
+				return nil
+			}
+
+			_, err := migrateDiskAction.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			platform.MigratePersistentDiskStub = func(_, _ string, cancelCh <-chan struct{}, _ boshdisk.MigrationProgressFunc) error {
+				Consistently(cancelCh).ShouldNot(Receive())
+				return nil
+			}
+
+			_, err = migrateDiskAction.Run()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 })
@@ -9,11 +9,13 @@ import (
 )
 
 type PrepareAction struct {
-	applier boshappl.Applier
+	applier     boshappl.Applier
+	specService boshas.V1Service
 }
 
-func NewPrepare(applier boshappl.Applier) (action PrepareAction) {
+func NewPrepare(applier boshappl.Applier, specService boshas.V1Service) (action PrepareAction) {
 	action.applier = applier
+	action.specService = specService
 	return action
 }
 
@@ -29,7 +31,11 @@ func (a PrepareAction) IsLoggable() bool {
 	return true
 }
 
-func (a PrepareAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
+func (a PrepareAction) Run(desiredSpec boshas.V1ApplySpec, options ...ApplyOptions) (interface{}, error) {
+	if len(options) > 0 && options[0].DryRun {
+		return diffWithCurrentSpec(a.specService, desiredSpec)
+	}
+
 	err := a.applier.Prepare(desiredSpec)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Preparing apply spec")
@@ -8,18 +8,21 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
 )
 
 var _ = Describe("PrepareAction", func() {
 	var (
 		applier       *fakeappl.FakeApplier
+		specService   *fakeas.FakeV1Service
 		prepareAction action.PrepareAction
 	)
 
 	BeforeEach(func() {
 		applier = fakeappl.NewFakeApplier()
-		prepareAction = action.NewPrepare(applier)
+		specService = fakeas.NewFakeV1Service()
+		prepareAction = action.NewPrepare(applier, specService)
 	})
 
 	AssertActionIsAsynchronous(prepareAction)
@@ -56,5 +59,17 @@ var _ = Describe("PrepareAction", func() {
 				Expect(err.Error()).To(ContainSubstring("fake-prepare-error"))
 			})
 		})
+
+		Context("when running as a dry run", func() {
+			It("returns the diff with the current spec without preparing the vm", func() {
+				currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+				specService.Spec = currentApplySpec
+
+				value, err := prepareAction.Run(desiredApplySpec, action.ApplyOptions{DryRun: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(boshas.Diff(currentApplySpec, desiredApplySpec)))
+				Expect(applier.Prepared).To(BeFalse())
+			})
+		})
 	})
 })
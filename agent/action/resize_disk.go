@@ -0,0 +1,88 @@
+package action
+
+import (
+	"errors"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type ResizeDiskResult struct {
+	OldSizeInBytes uint64 `json:"old_size_in_bytes"`
+	NewSizeInBytes uint64 `json:"new_size_in_bytes"`
+}
+
+type ResizeDiskAction struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewResizeDisk(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) ResizeDiskAction {
+	return ResizeDiskAction{
+		settingsService: settingsService,
+		platform:        platform,
+
+		logTag: "Resize Disk Action",
+		logger: logger,
+	}
+}
+
+func (a ResizeDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a ResizeDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a ResizeDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a ResizeDiskAction) Run(diskCid string) (ResizeDiskResult, error) {
+	err := a.settingsService.LoadSettings()
+	if err != nil {
+		return ResizeDiskResult{}, bosherr.WrapError(err, "Refreshing the settings")
+	}
+
+	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskCid)
+	if err != nil {
+		return ResizeDiskResult{}, bosherr.WrapError(err, "Getting persistent disk settings")
+	}
+
+	isMounted, err := a.platform.IsPersistentDiskMounted(diskSettings)
+	if err != nil {
+		return ResizeDiskResult{}, bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+
+	// Disks that are not mounted are grown by mount_disk
+	if !isMounted {
+		return ResizeDiskResult{}, bosherr.Errorf("Persistent disk %s is not mounted", diskCid)
+	}
+
+	oldSize, newSize, err := a.platform.ResizePersistentDisk(diskSettings)
+	if err != nil {
+		return ResizeDiskResult{}, bosherr.WrapError(err, "Resizing persistent disk")
+	}
+
+	a.logger.Info(a.logTag, "Resized persistent disk %s from %d to %d bytes", diskCid, oldSize, newSize)
+
+	return ResizeDiskResult{OldSizeInBytes: oldSize, NewSizeInBytes: newSize}, nil
+}
+
+func (a ResizeDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ResizeDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
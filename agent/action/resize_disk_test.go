@@ -0,0 +1,90 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("ResizeDiskAction", func() {
+	var (
+		settingsService  *fakesettings.FakeSettingsService
+		platform         *platformfakes.FakePlatform
+		resizeDiskAction action.ResizeDiskAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"fake-disk-cid": {ID: "fake-disk-cid", Path: "/dev/sdf"},
+			},
+		}
+		platform = &platformfakes.FakePlatform{}
+		platform.IsPersistentDiskMountedReturns(true, nil)
+
+		resizeDiskAction = action.NewResizeDisk(settingsService, platform, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AssertActionIsAsynchronous(resizeDiskAction)
+	AssertActionIsNotPersistent(resizeDiskAction)
+	AssertActionIsLoggable(resizeDiskAction)
+
+	AssertActionIsNotResumable(resizeDiskAction)
+	AssertActionIsNotCancelable(resizeDiskAction)
+
+	Describe("Run", func() {
+		It("resizes the mounted persistent disk and returns its old and new size", func() {
+			platform.ResizePersistentDiskReturns(1024, 4096, nil)
+
+			result, err := resizeDiskAction.Run("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), result, `{"old_size_in_bytes":1024,"new_size_in_bytes":4096}`)
+
+			Expect(settingsService.SettingsWereLoaded).To(BeTrue())
+			Expect(platform.ResizePersistentDiskCallCount()).To(Equal(1))
+			Expect(platform.ResizePersistentDiskArgsForCall(0)).To(Equal(boshsettings.DiskSettings{ID: "fake-disk-cid", Path: "/dev/sdf"}))
+		})
+
+		It("returns an error if the persistent disk is not mounted", func() {
+			platform.IsPersistentDiskMountedReturns(false, nil)
+
+			_, err := resizeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Persistent disk fake-disk-cid is not mounted"))
+
+			Expect(platform.ResizePersistentDiskCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if checking whether the persistent disk is mounted fails", func() {
+			platform.IsPersistentDiskMountedReturns(false, errors.New("fake-mounted-err"))
+
+			_, err := resizeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-mounted-err"))
+		})
+
+		It("returns an error if loading the settings fails", func() {
+			settingsService.LoadSettingsError = errors.New("fake-load-err")
+
+			_, err := resizeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-load-err"))
+		})
+
+		It("returns an error if resizing fails", func() {
+			platform.ResizePersistentDiskReturns(0, 0, errors.New("fake-resize-err"))
+
+			_, err := resizeDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-resize-err"))
+		})
+	})
+})
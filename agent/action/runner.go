@@ -155,10 +155,10 @@ func (r concreteRunner) getMethodArgType(methodType reflect.Type, index int) (ar
 }
 
 func (r concreteRunner) extractReturns(values []reflect.Value) (value interface{}, err error) {
+	// The error is passed on as is so that details it carries reach the director
 	errValue := values[1]
 	if !errValue.IsNil() {
-		errorValues := errValue.MethodByName("Error").Call([]reflect.Value{})
-		err = bosherr.Error(errorValues[0].String())
+		err = errValue.Interface().(error)
 	}
 
 	value = values[0].Interface()
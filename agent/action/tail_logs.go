@@ -0,0 +1,62 @@
+package action
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type TailLogsOptions struct {
+	Offsets  map[string]int64 `json:"offsets"`
+	Lines    int              `json:"lines"`
+	MaxBytes int64            `json:"max_bytes"`
+}
+
+type TailLogsAction struct {
+	tailer logtail.Tailer
+}
+
+func NewTailLogs(tailer logtail.Tailer) (action TailLogsAction) {
+	action.tailer = tailer
+	return
+}
+
+func (a TailLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a TailLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a TailLogsAction) IsLoggable() bool {
+	return false
+}
+
+// Run returns the log data written since the given offsets. Callers pass the
+// returned offsets back in to follow logs incrementally.
+func (a TailLogsAction) Run(logType string, filters []string, options ...TailLogsOptions) (logtail.Chunk, error) {
+	request := logtail.Request{LogType: logType, Filters: filters}
+
+	if len(options) > 0 {
+		request.Offsets = options[0].Offsets
+		request.Lines = options[0].Lines
+		request.MaxBytes = options[0].MaxBytes
+	}
+
+	chunk, err := a.tailer.Tail(request)
+	if err != nil {
+		return chunk, bosherr.WrapError(err, "Tailing logs")
+	}
+
+	return chunk, nil
+}
+
+func (a TailLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a TailLogsAction) Cancel() error {
+	return errors.New("not supported")
+}
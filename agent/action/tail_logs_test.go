@@ -0,0 +1,71 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
+	fakelogtail "github.com/cloudfoundry/bosh-agent/agent/logtail/fakes"
+)
+
+var _ = Describe("TailLogsAction", func() {
+	var (
+		tailer         *fakelogtail.FakeTailer
+		tailLogsAction action.TailLogsAction
+	)
+
+	BeforeEach(func() {
+		tailer = &fakelogtail.FakeTailer{}
+		tailLogsAction = action.NewTailLogs(tailer)
+	})
+
+	AssertActionIsAsynchronous(tailLogsAction)
+	AssertActionIsNotPersistent(tailLogsAction)
+	AssertActionIsNotLoggable(tailLogsAction)
+
+	AssertActionIsNotResumable(tailLogsAction)
+	AssertActionIsNotCancelable(tailLogsAction)
+
+	Describe("Run", func() {
+		It("returns the chunk read by the tailer", func() {
+			tailer.TailChunk = logtail.Chunk{
+				Files:   []logtail.FileChunk{{Path: "job/job.log", Offset: 10, NextOffset: 14, Data: "new\n"}},
+				Offsets: map[string]int64{"job/job.log": 14},
+			}
+
+			chunk, err := tailLogsAction.Run("job", []string{"job/*.log"}, action.TailLogsOptions{
+				Offsets:  map[string]int64{"job/job.log": 10},
+				Lines:    5,
+				MaxBytes: 1024,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chunk).To(Equal(tailer.TailChunk))
+
+			Expect(tailer.TailRequests).To(Equal([]logtail.Request{{
+				LogType:  "job",
+				Filters:  []string{"job/*.log"},
+				Offsets:  map[string]int64{"job/job.log": 10},
+				Lines:    5,
+				MaxBytes: 1024,
+			}}))
+		})
+
+		It("tails without offsets when no options are given", func() {
+			_, err := tailLogsAction.Run("agent", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tailer.TailRequests).To(Equal([]logtail.Request{{LogType: "agent"}}))
+		})
+
+		It("returns an error when tailing fails", func() {
+			tailer.TailErr = errors.New("fake-tail-err")
+
+			_, err := tailLogsAction.Run("job", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-tail-err"))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"errors"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type ThawDiskAction struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+	dirProvider     boshdirs.Provider
+	watchdog        FreezeWatchdog
+}
+
+func NewThawDisk(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	dirProvider boshdirs.Provider,
+	watchdog FreezeWatchdog,
+) (action ThawDiskAction) {
+	action.settingsService = settingsService
+	action.platform = platform
+	action.dirProvider = dirProvider
+	action.watchdog = watchdog
+	return
+}
+
+// IsAsynchronous is false so that thawing is never queued behind other
+// disk tasks while the disk is frozen
+func (a ThawDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ThawDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a ThawDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a ThawDiskAction) Run(diskCid string) (map[string]string, error) {
+	mountPoint, err := persistentDiskMountPoint(a.settingsService, a.platform, a.dirProvider, diskCid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.watchdog.Thaw(mountPoint)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Thawing persistent disk")
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a ThawDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ThawDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
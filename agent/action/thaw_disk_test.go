@@ -0,0 +1,94 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("ThawDiskAction", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		platform        *platformfakes.FakePlatform
+		fakeClock       *fakeclock.FakeClock
+		watchdog        action.FreezeWatchdog
+		thawDiskAction  action.ThawDiskAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"fake-disk-cid": {ID: "fake-disk-cid", Path: "/dev/sdf"},
+			},
+		}
+		platform = &platformfakes.FakePlatform{}
+		platform.IsPersistentDiskMountedReturns(true, nil)
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		watchdog = action.NewFreezeWatchdog(platform, fakesys.NewFakeFileSystem(), "/fake-freeze-deadlines.json", fakeClock, boshlog.NewLogger(boshlog.LevelNone))
+		dirProvider := boshdirs.NewProvider("/fake-base-dir")
+
+		thawDiskAction = action.NewThawDisk(settingsService, platform, dirProvider, watchdog)
+	})
+
+	AssertActionIsNotAsynchronous(thawDiskAction)
+	AssertActionIsNotPersistent(thawDiskAction)
+	AssertActionIsLoggable(thawDiskAction)
+
+	AssertActionIsNotResumable(thawDiskAction)
+	AssertActionIsNotCancelable(thawDiskAction)
+
+	Describe("Run", func() {
+		It("thaws the persistent disk", func() {
+			value, err := thawDiskAction.Run("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), value, "{}")
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+			Expect(platform.ThawFilesystemArgsForCall(0)).To(boshassert.MatchPath("/fake-base-dir/store"))
+		})
+
+		It("stops the watchdog from thawing the persistent disk again", func() {
+			err := watchdog.Freeze("/fake-base-dir/store", time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			_, err = thawDiskAction.Run("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+
+			fakeClock.Increment(time.Minute)
+			Consistently(platform.ThawFilesystemCallCount).Should(Equal(1))
+		})
+
+		It("returns an error if the persistent disk is not mounted", func() {
+			platform.IsPersistentDiskMountedReturns(false, nil)
+
+			_, err := thawDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Persistent disk fake-disk-cid is not mounted"))
+
+			Expect(platform.ThawFilesystemCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if thawing fails", func() {
+			platform.ThawFilesystemReturns(errors.New("fake-thaw-err"))
+
+			_, err := thawDiskAction.Run("fake-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+		})
+	})
+})
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+)
+
+// actionConcurrencyClasses decides which asynchronous actions may run next to each other.
+// Read-only actions run in parallel and compilations are limited on their own.
+// Actions mutating jobs or disks share the serial class, since e.g. stopping
+// jobs must not overlap with unmounting the disk they write to.
+// Actions not listed here fall into boshtask.ConcurrencyClassSerial.
+var actionConcurrencyClasses = map[string]boshtask.ConcurrencyClass{
+	"fetch_logs":                 boshtask.ConcurrencyClassParallel,
+	"fetch_logs_with_signed_url": boshtask.ConcurrencyClassParallel,
+	"tail_logs":                  boshtask.ConcurrencyClassParallel,
+
+	"compile_package":                 boshtask.ConcurrencyClassCompile,
+	"compile_package_with_signed_url": boshtask.ConcurrencyClassCompile,
+
+	"prepare":     boshtask.ConcurrencyClassSerial,
+	"apply":       boshtask.ConcurrencyClassSerial,
+	"stop":        boshtask.ConcurrencyClassSerial,
+	"drain":       boshtask.ConcurrencyClassSerial,
+	"run_errand":  boshtask.ConcurrencyClassSerial,
+	"run_script":  boshtask.ConcurrencyClassSerial,
+	"upload_blob": boshtask.ConcurrencyClassSerial,
+
+	"mount_disk":             boshtask.ConcurrencyClassSerial,
+	"unmount_disk":           boshtask.ConcurrencyClassSerial,
+	"migrate_disk":           boshtask.ConcurrencyClassSerial,
+	"resize_disk":            boshtask.ConcurrencyClassSerial,
+	"add_persistent_disk":    boshtask.ConcurrencyClassSerial,
+	"remove_persistent_disk": boshtask.ConcurrencyClassSerial,
+	"freeze_disk":            boshtask.ConcurrencyClassSerial,
+}
+
+func concurrencyClassForAction(method string) boshtask.ConcurrencyClass {
+	class, found := actionConcurrencyClasses[method]
+	if !found {
+		return boshtask.ConcurrencyClassSerial
+	}
+
+	return class
+}
@@ -1,9 +1,12 @@
 package agent
 
 import (
+	"time"
+
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -21,6 +24,7 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	recorder      boshmetrics.Recorder
 }
 
 func NewActionDispatcher(
@@ -29,6 +33,7 @@ func NewActionDispatcher(
 	taskManager boshtask.Manager,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	recorder boshmetrics.Recorder,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
@@ -36,6 +41,7 @@ func NewActionDispatcher(
 		taskManager:   taskManager,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		recorder:      recorder,
 	}
 }
 
@@ -65,10 +71,14 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 
 		task := dispatcher.taskService.CreateTaskWithID(
 			taskID,
-			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
+			dispatcher.timedTaskFunc(taskInfo.Method, func() (interface{}, error) {
+				return dispatcher.actionRunner.Resume(action, payload)
+			}),
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeInfo,
 		)
+		task.ConcurrencyClass = concurrencyClassForAction(taskInfo.Method)
+		task.ProgressFunc = progressFuncForAction(action)
 
 		dispatcher.taskService.StartTask(task)
 	}
@@ -82,6 +92,7 @@ func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) bos
 	}
 
 	dispatcher.logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
+	dispatcher.recorder.ActionDispatched(req.Method)
 	if action.IsLoggable() {
 		dispatcher.logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
 	}
@@ -102,9 +113,9 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	var task boshtask.Task
 	var err error
 
-	runTask := func() (interface{}, error) {
+	runTask := dispatcher.timedTaskFunc(req.Method, func() (interface{}, error) {
 		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
-	}
+	})
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
 
@@ -141,6 +152,9 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		}
 	}
 
+	task.ConcurrencyClass = concurrencyClassForAction(req.Method)
+	task.ProgressFunc = progressFuncForAction(action)
+
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.StateValue{
@@ -173,3 +187,27 @@ func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 	}
 }
+
+func (dispatcher concreteActionDispatcher) timedTaskFunc(method string, taskFunc boshtask.Func) boshtask.Func {
+	return func() (interface{}, error) {
+		startedAt := time.Now()
+
+		value, err := taskFunc()
+
+		state := boshtask.StateDone
+		if err != nil {
+			state = boshtask.StateFailed
+		}
+
+		dispatcher.recorder.TaskFinished(method, string(state), time.Since(startedAt))
+
+		return value, err
+	}
+}
+
+func progressFuncForAction(action boshaction.Action) boshtask.ProgressFunc {
+	if reporter, ok := action.(boshaction.ProgressReporter); ok {
+		return reporter.Progress
+	}
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -14,10 +16,37 @@ import (
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakes "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
 
+type detailedTaskError struct{}
+
+func (e detailedTaskError) Error() string { return "fake-limit-exceeded" }
+
+func (e detailedTaskError) ErrorDetails() interface{} {
+	return map[string]string{"limit": "memory"}
+}
+
+type failingDetailedAction struct {
+	fakeaction.TestAction
+}
+
+func (a *failingDetailedAction) Run() (interface{}, error) {
+	return nil, bosherr.WrapError(detailedTaskError{}, "Compiling package")
+}
+
+type actionsFactory map[string]action.Action
+
+func (f actionsFactory) Create(method string) (action.Action, error) {
+	return f[method], nil
+}
+
 func init() { //nolint:funlen,gochecknoinits
 	Describe("actionDispatcher", func() {
 		var (
@@ -26,6 +55,7 @@ func init() { //nolint:funlen,gochecknoinits
 			taskManager   *faketask.FakeManager
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
+			recorder      boshmetrics.Recorder
 			dispatcher    agent.ActionDispatcher
 		)
 
@@ -35,7 +65,8 @@ func init() { //nolint:funlen,gochecknoinits
 			taskManager = faketask.NewFakeManager()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			recorder = boshmetrics.NewRecorder()
+			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, recorder)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -210,6 +241,13 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 			}
 
+			It("lets the task report the progress of the action", func() {
+				action.ProgressValue = "fake-progress"
+				dispatcher.Dispatch(req)
+
+				Expect(taskService.StartedTasks["fake-generated-task-id"].Progress()).To(Equal("fake-progress"))
+			})
+
 			Context("when action is not persistent", func() {
 				BeforeEach(func() {
 					action.Persistent = false
@@ -358,6 +396,67 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 		})
 
+		Describe("metrics", func() {
+			It("counts dispatched actions", func() {
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+
+				Expect(recorder.Snapshot().ActionDispatches).To(Equal(map[string]uint64{"fake-action": 2}))
+			})
+
+			It("records the duration and outcome of asynchronous tasks", func() {
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{Asynchronous: true})
+				actionRunner.RunErr = errors.New("fake-run-error")
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+
+				_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+				Expect(err).To(HaveOccurred())
+
+				taskDurations := recorder.Snapshot().TaskDurations
+				Expect(taskDurations).To(HaveLen(1))
+				Expect(taskDurations[0].Method).To(Equal("fake-action"))
+				Expect(taskDurations[0].State).To(Equal("failed"))
+				Expect(taskDurations[0].Count).To(Equal(uint64(1)))
+			})
+		})
+
+		Describe("concurrency classes", func() {
+			It("runs read-only asynchronous actions in the parallel class", func() {
+				actionFactory.RegisterAction("fetch_logs", &fakeaction.TestAction{Asynchronous: true})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fetch_logs", []byte("fake-payload"), 0))
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyClass).To(Equal(boshtask.ConcurrencyClassParallel))
+			})
+
+			It("serializes disk mutating asynchronous actions with job mutating ones", func() {
+				actionFactory.RegisterAction("mount_disk", &fakeaction.TestAction{Asynchronous: true})
+				actionFactory.RegisterAction("stop", &fakeaction.TestAction{Asynchronous: true})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "mount_disk", []byte("fake-payload"), 0))
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyClass).To(Equal(boshtask.ConcurrencyClassSerial))
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "stop", []byte("fake-payload"), 0))
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyClass).To(Equal(boshtask.ConcurrencyClassSerial))
+			})
+
+			It("does not run upload_blob in the parallel class since it writes to the blobstore", func() {
+				actionFactory.RegisterAction("upload_blob", &fakeaction.TestAction{Asynchronous: true})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "upload_blob", []byte("fake-payload"), 0))
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyClass).To(Equal(boshtask.ConcurrencyClassSerial))
+			})
+
+			It("runs unclassified asynchronous actions in the serial class", func() {
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{Asynchronous: true})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyClass).To(Equal(boshtask.ConcurrencyClassSerial))
+			})
+		})
+
 		Describe("ResumePreviouslyDispatchedTasks", func() {
 			var firstAction, secondAction *fakeaction.TestAction
 
@@ -511,5 +610,44 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(err.Error()).To(ContainSubstring("fake-cancel-err-2"))
 			})
 		})
+
+		Context("when an asynchronous action fails with error details", func() {
+			BeforeEach(func() {
+				history := boshtask.NewHistory(
+					boshlog.NewLogger(boshlog.LevelNone),
+					fakesys.NewFakeFileSystem(),
+					"/fake-dir/task_history.json",
+					fakeclock.NewFakeClock(time.Now()),
+					boshtask.DefaultHistoryMaxAge,
+					boshtask.DefaultHistoryMaxEntries,
+				)
+				uuidGen := &fakeuuid.FakeGenerator{GeneratedUUID: "fake-task-id"}
+				realTaskService := boshtask.NewAsyncTaskService(uuidGen, history, boshtask.DefaultConcurrencyLimits(), fakeclock.NewFakeClock(time.Now()), boshlog.NewLogger(boshlog.LevelNone))
+
+				actions := actionsFactory{
+					"compile_package": &failingDetailedAction{fakeaction.TestAction{Asynchronous: true}},
+					"get_task":        action.NewGetTask(realTaskService),
+				}
+
+				dispatcher = agent.NewActionDispatcher(logger, realTaskService, taskManager, actions, action.NewRunner(), recorder)
+			})
+
+			It("reports the details through get_task once the task finished", func() {
+				resp := dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "compile_package", []byte(`{"arguments":[]}`), 0))
+				boshassert.MatchesJSONString(GinkgoT(), resp, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`)
+
+				Eventually(func() string {
+					resp := dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "get_task", []byte(`{"arguments":["fake-task-id"]}`), 0))
+					respJSON, err := json.Marshal(resp)
+					Expect(err).ToNot(HaveOccurred())
+					return string(respJSON)
+				}).Should(MatchJSON(`{
+					"exception": {
+						"message": "Action Failed get_task: Task fake-task-id result: Compiling package: fake-limit-exceeded",
+						"details": {"limit": "memory"}
+					}
+				}`))
+			})
+		})
 	})
 }
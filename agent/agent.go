@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"errors"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -9,6 +10,7 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -46,6 +48,9 @@ type Agent struct {
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
 	startManager      StartManager
+	recorder          boshmetrics.Recorder
+	alertDispatcher   boshalert.Dispatcher
+	alertRateLimiter  *boshalert.RateLimiter
 }
 
 func New(
@@ -60,6 +65,8 @@ func New(
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
 	startManager StartManager,
+	recorder boshmetrics.Recorder,
+	alertDispatcher boshalert.Dispatcher,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -73,6 +80,9 @@ func New(
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
 		startManager:      startManager,
+		recorder:          recorder,
+		alertDispatcher:   alertDispatcher,
+		alertRateLimiter:  boshalert.NewRateLimiter(timeService),
 	}
 }
 
@@ -99,9 +109,22 @@ func (a Agent) Run() error {
 		}
 	}()
 
+	go a.monitorDiskHealth(errCh)
+
 	return <-errCh
 }
 
+// monitorDiskHealth raises alerts for failing disks; the agent keeps
+// running without them if disk health cannot be watched
+func (a Agent) monitorDiskHealth(errCh chan error) {
+	diskHealthEventSource := NewDiskHealthEventSource(a.platform.GetVitalsService(), a.heartbeatInterval, a.timeService, a.logger)
+
+	err := diskHealthEventSource.Run(a.handleJobFailure(errCh))
+	if err != nil {
+		a.logger.Error(agentLogTag, "Monitoring disk health: %s", err.Error())
+	}
+}
+
 func (a Agent) subscribeActionDispatcher(errCh chan error) {
 	defer a.logger.HandlePanic("Agent Message Bus Handler")
 
@@ -144,9 +167,17 @@ func (a Agent) sendAndRecordHeartbeat(errCh chan error, retry bool) {
 	heartbeatRetryable := boshretry.NewRetryable(func() (bool, error) {
 		a.logger.Info(agentLogTag, "Attempting to send Heartbeat")
 		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, heartbeat)
+		if errors.Is(err, boshhandler.ErrSendNotSupported) {
+			return false, nil
+		}
 		if err != nil {
+			a.recorder.HeartbeatSendFailed()
 			return true, bosherr.WrapError(err, "Sending Heartbeat")
 		}
+
+		// The message bus is reachable again, deliver alerts missed meanwhile
+		a.alertDispatcher.Flush()
+
 		return false, nil
 	})
 
@@ -191,6 +222,23 @@ func (a Agent) getHeartbeat(status string) (Heartbeat, error) {
 		NodeID:     spec.NodeID,
 	}
 
+	// Process details are not required for a heartbeat
+	processes, err := a.jobSupervisor.Processes()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Getting processes for heartbeat: %s", err.Error())
+	}
+
+	for _, process := range processes {
+		if process.CrashLooping {
+			hb.CrashLoopingProcesses = append(hb.CrashLoopingProcesses, process.Name)
+		}
+	}
+
+	hb.JobResources, err = a.jobSupervisor.JobResources()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Getting job resources for heartbeat: %s", err.Error())
+	}
+
 	return hb, nil
 }
 
@@ -207,12 +255,18 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 			a.logger.Error(agentLogTag, "Unknown monit event name `%s', using default severity %d", monitAlert.Event, severity)
 		}
 
+		key, window := alertAdapter.RateLimit()
+		if !a.alertRateLimiter.Allow(key, window) {
+			a.logger.Debug(agentLogTag, "Dropping monit event '%s' for '%s' repeated within %s", monitAlert.Event, monitAlert.Service, window)
+			return nil
+		}
+
 		alert, err := alertAdapter.Alert()
 		if err != nil {
 			errCh <- bosherr.WrapError(err, "Adapting monit alert")
 		}
 
-		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		err = a.alertDispatcher.Dispatch(alert)
 		if err != nil {
 			errCh <- bosherr.WrapError(err, "Sending monit alert")
 		}
@@ -15,8 +15,11 @@ import (
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
@@ -39,6 +42,7 @@ func init() { //nolint:funlen,gochecknoinits
 			timeService      *fakeclock.FakeClock
 			vitalService     *vitalsfakes.FakeService
 			startManager     *agentfakes.FakeStartManager
+			recorder         boshmetrics.Recorder
 
 			boshAgent agent.Agent
 		)
@@ -60,6 +64,7 @@ func init() { //nolint:funlen,gochecknoinits
 			vitalService = &vitalsfakes.FakeService{}
 			startManager = &agentfakes.FakeStartManager{}
 			startManager.CanStartReturns(true)
+			recorder = boshmetrics.NewRecorder()
 
 			platform.GetVitalsServiceReturns(vitalService)
 
@@ -75,6 +80,8 @@ func init() { //nolint:funlen,gochecknoinits
 				uuidGenerator,
 				timeService,
 				startManager,
+				recorder,
+				boshalert.NewDispatcher(handler, nil, nil, logger),
 			)
 		})
 
@@ -161,6 +168,8 @@ func init() { //nolint:funlen,gochecknoinits
 						uuidGenerator,
 						timeService,
 						startManager,
+						recorder,
+						boshalert.NewDispatcher(handler, nil, nil, logger),
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -179,6 +188,38 @@ func init() { //nolint:funlen,gochecknoinits
 					}))
 
 					Expect(jobSupervisor.GetHealthRecorded()).To(Equal(1))
+					Expect(recorder.Snapshot().HeartbeatSendFailures).To(Equal(uint64(1)))
+				})
+
+				It("includes crash-looping processes and job resources in heartbeats", func() {
+					jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+						{Name: "fake-web"},
+						{Name: "fake-worker", CrashLooping: true},
+					}
+
+					jobSupervisor.JobResourcesStatus = map[string]boshcgroup.Stats{
+						"fake-job": {MemoryBytes: 1024, OOMKills: 1},
+					}
+
+					// Immediately exit after sending initial heartbeat
+					handler.SendErr = errors.New("stop")
+
+					err := boshAgent.Run()
+					Expect(err).To(HaveOccurred())
+
+					expectedCrashLoopingHb := expectedHb
+					expectedCrashLoopingHb.CrashLoopingProcesses = []string{"fake-worker"}
+					expectedCrashLoopingHb.JobResources = map[string]boshcgroup.Stats{
+						"fake-job": {MemoryBytes: 1024, OOMKills: 1},
+					}
+
+					Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{
+						{
+							Target:  boshhandler.HealthMonitor,
+							Topic:   boshhandler.Heartbeat,
+							Message: expectedCrashLoopingHb,
+						},
+					}))
 				})
 
 				It("sends periodic heartbeats, with retry", func() {
@@ -0,0 +1,121 @@
+package alert
+
+import (
+	"errors"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	dispatcherLogTag = "alertDispatcher"
+
+	// SinkQueueSize bounds the alerts waiting for slow sinks; further
+	// alerts are not sent to sinks until the queue drains
+	SinkQueueSize = 100
+)
+
+type Dispatcher interface {
+	// Dispatch sends alert to all local sinks and over the message bus,
+	// spooling it if the message bus cannot be reached
+	Dispatch(alert Alert) error
+
+	// Flush replays spooled alerts over the message bus
+	Flush()
+}
+
+type dispatcher struct {
+	mbusHandler boshhandler.Handler
+	sinks       []Sink
+	sinkQueue   chan Alert
+	spool       *Spool
+	logger      boshlog.Logger
+}
+
+// NewDispatcher creates a Dispatcher; spool may be nil to disable spooling.
+// Sinks are sent to in the background so that a slow sink cannot delay
+// sending over the message bus.
+func NewDispatcher(mbusHandler boshhandler.Handler, sinks []Sink, spool *Spool, logger boshlog.Logger) Dispatcher {
+	d := dispatcher{
+		mbusHandler: mbusHandler,
+		sinks:       sinks,
+		spool:       spool,
+		logger:      logger,
+	}
+
+	if len(sinks) > 0 {
+		d.sinkQueue = make(chan Alert, SinkQueueSize)
+		go d.sendToSinks()
+	}
+
+	return d
+}
+
+func (d dispatcher) Dispatch(alert Alert) error {
+	if d.sinkQueue != nil {
+		select {
+		case d.sinkQueue <- alert:
+		default:
+			d.logger.Warn(dispatcherLogTag, "Not sending alert '%s' to alert sinks: %d alerts are already waiting", alert.ID, SinkQueueSize)
+		}
+	}
+
+	err := d.flush()
+	if err == nil {
+		err = d.send(alert)
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, boshhandler.ErrSendNotSupported):
+		if len(d.sinks) == 0 {
+			d.logger.Warn(dispatcherLogTag, "Dropping alert '%s': the message bus cannot send alerts and no alert sinks are configured", alert.ID)
+		}
+		return nil
+	case d.spool != nil:
+		d.logger.Info(dispatcherLogTag, "Spooling alert '%s' until the message bus is reachable: %s", alert.ID, err.Error())
+
+		spoolErr := d.spool.Add(alert)
+		if spoolErr == nil {
+			return nil
+		}
+
+		d.logger.Error(dispatcherLogTag, "Spooling alert '%s': %s", alert.ID, spoolErr.Error())
+	}
+
+	return bosherr.WrapError(err, "Sending alert")
+}
+
+func (d dispatcher) sendToSinks() {
+	defer d.logger.HandlePanic("Alert Dispatcher Send To Sinks")
+
+	for alert := range d.sinkQueue {
+		for _, sink := range d.sinks {
+			err := sink.Send(alert)
+			if err != nil {
+				d.logger.Error(dispatcherLogTag, "Sending alert '%s' to %T: %s", alert.ID, sink, err.Error())
+			}
+		}
+	}
+}
+
+func (d dispatcher) Flush() {
+	err := d.flush()
+	if err != nil && !errors.Is(err, boshhandler.ErrSendNotSupported) {
+		d.logger.Info(dispatcherLogTag, "Replaying spooled alerts: %s", err.Error())
+	}
+}
+
+func (d dispatcher) flush() error {
+	if d.spool == nil {
+		return nil
+	}
+
+	return d.spool.Replay(d.send)
+}
+
+func (d dispatcher) send(alert Alert) error {
+	return d.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+}
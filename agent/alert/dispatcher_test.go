@@ -0,0 +1,184 @@
+package alert_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type fakeSink struct {
+	mutex   sync.Mutex
+	alerts  []Alert
+	err     error
+	blockCh chan struct{}
+}
+
+func (s *fakeSink) Send(alert Alert) error {
+	if s.blockCh != nil {
+		<-s.blockCh
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.alerts = append(s.alerts, alert)
+	return s.err
+}
+
+func (s *fakeSink) Alerts() []Alert {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Alert{}, s.alerts...)
+}
+
+var _ = Describe("Dispatcher", func() {
+	var (
+		handler     *fakembus.FakeHandler
+		sink        *fakeSink
+		spoolDir    string
+		timeService *fakeclock.FakeClock
+		spool       *Spool
+		dispatcher  Dispatcher
+	)
+
+	alertMessage := func(alert Alert) fakembus.SendInput {
+		return fakembus.SendInput{Target: boshhandler.HealthMonitor, Topic: boshhandler.Alert, Message: alert}
+	}
+
+	BeforeEach(func() {
+		var err error
+		spoolDir, err = os.MkdirTemp("", "alert-spool")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		handler = fakembus.NewFakeHandler()
+		sink = &fakeSink{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		spool = NewSpool(boshsys.NewOsFileSystem(logger), spoolDir, 2, timeService, logger)
+		dispatcher = NewDispatcher(handler, []Sink{sink}, spool, logger)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(spoolDir)).To(Succeed())
+	})
+
+	It("sends alerts to the sinks and over the message bus", func() {
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id"})).To(Succeed())
+
+		Eventually(sink.Alerts).Should(Equal([]Alert{{ID: "fake-id"}}))
+		Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{alertMessage(Alert{ID: "fake-id"})}))
+	})
+
+	It("still sends over the message bus when a sink fails", func() {
+		sink.err = errors.New("fake-sink-err")
+
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id"})).To(Succeed())
+		Expect(handler.SendInputs()).To(HaveLen(1))
+	})
+
+	Context("when a sink is slow", func() {
+		BeforeEach(func() {
+			sink = &fakeSink{blockCh: make(chan struct{})}
+			dispatcher = NewDispatcher(handler, []Sink{sink}, nil, boshlog.NewLogger(boshlog.LevelNone))
+		})
+
+		It("sends over the message bus without waiting for the sink", func() {
+			Expect(dispatcher.Dispatch(Alert{ID: "fake-id"})).To(Succeed())
+			Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{alertMessage(Alert{ID: "fake-id"})}))
+
+			close(sink.blockCh)
+			Eventually(sink.Alerts).Should(Equal([]Alert{{ID: "fake-id"}}))
+		})
+
+		It("does not queue more alerts for the sinks than the queue holds", func() {
+			for i := 0; i < SinkQueueSize+10; i++ {
+				Expect(dispatcher.Dispatch(Alert{ID: "fake-id"})).To(Succeed())
+			}
+			Expect(handler.SendInputs()).To(HaveLen(SinkQueueSize + 10))
+
+			close(sink.blockCh)
+
+			// One more alert may have been taken off the queue by the blocked sink
+			sinkAlerts := func() int { return len(sink.Alerts()) }
+			Eventually(sinkAlerts).Should(BeNumerically(">=", SinkQueueSize))
+			Consistently(sinkAlerts).Should(BeNumerically("<=", SinkQueueSize+1))
+		})
+	})
+
+	It("does not fail when the message bus cannot send alerts", func() {
+		handler.SendErr = boshhandler.ErrSendNotSupported
+
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id"})).To(Succeed())
+		Eventually(sink.Alerts).Should(HaveLen(1))
+	})
+
+	It("spools alerts while the message bus is unreachable and replays them in order", func() {
+		handler.SendErr = errors.New("fake-send-err")
+
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id-1"})).To(Succeed())
+		timeService.Increment(time.Second)
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id-2"})).To(Succeed())
+
+		handler.SendErr = nil
+		dispatcher.Flush()
+
+		Expect(handler.SendInputs()[2:]).To(Equal([]fakembus.SendInput{
+			alertMessage(Alert{ID: "fake-id-1"}),
+			alertMessage(Alert{ID: "fake-id-2"}),
+		}))
+
+		dispatcher.Flush()
+		Expect(handler.SendInputs()).To(HaveLen(4))
+	})
+
+	It("replays spooled alerts before sending a new one", func() {
+		handler.SendErr = errors.New("fake-send-err")
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id-1"})).To(Succeed())
+
+		handler.SendErr = nil
+		Expect(dispatcher.Dispatch(Alert{ID: "fake-id-2"})).To(Succeed())
+
+		Expect(handler.SendInputs()[1:]).To(Equal([]fakembus.SendInput{
+			alertMessage(Alert{ID: "fake-id-1"}),
+			alertMessage(Alert{ID: "fake-id-2"}),
+		}))
+	})
+
+	It("drops the oldest spooled alerts when the spool is full", func() {
+		handler.SendErr = errors.New("fake-send-err")
+
+		for _, id := range []string{"fake-id-1", "fake-id-2", "fake-id-3"} {
+			Expect(dispatcher.Dispatch(Alert{ID: id})).To(Succeed())
+			timeService.Increment(time.Second)
+		}
+
+		handler.SendErr = nil
+		dispatcher.Flush()
+
+		Expect(handler.SendInputs()[3:]).To(Equal([]fakembus.SendInput{
+			alertMessage(Alert{ID: "fake-id-2"}),
+			alertMessage(Alert{ID: "fake-id-3"}),
+		}))
+	})
+
+	It("returns the error without a spool", func() {
+		dispatcher = NewDispatcher(handler, nil, nil, boshlog.NewLogger(boshlog.LevelNone))
+		handler.SendErr = errors.New("fake-send-err")
+
+		err := dispatcher.Dispatch(Alert{ID: "fake-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-send-err"))
+	})
+})
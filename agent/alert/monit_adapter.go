@@ -14,6 +14,10 @@ type MonitAdapter interface {
 	IsIgnorable() bool
 	Alert() (Alert, error)
 	Severity() (severity SeverityLevel, found bool)
+
+	// RateLimit returns the key identifying duplicates of the alert and
+	// the window in which they should be dropped
+	RateLimit() (key string, window time.Duration)
 }
 
 type monitAdapter struct {
@@ -153,11 +157,47 @@ func (m *monitAdapter) Severity() (severity SeverityLevel, found bool) {
 		"uid succeeded":                SeverityIgnored,
 		"uid changed":                  SeverityWarning,
 		"uid not changed":              SeverityIgnored,
+		"process started":              SeverityIgnored,
+		"process stopped":              SeverityIgnored,
+		"process restarted":            SeverityWarning,
+		"process crashed":              SeverityAlert,
+		"process crash looping":        SeverityCritical,
+		"oom killed":                   SeverityAlert,
+		"pids limit reached":           SeverityWarning,
+		"disk health failed":           SeverityCritical,
+		"disk media errors":            SeverityError,
+		"disk io errors":               SeverityError,
 	}
 
 	severity, found = eventToSeverity[strings.ToLower(m.monitAlert.Event)]
 	if !found {
 		severity = SeverityDefault
 	}
+
+	if rule, ruleFound := m.rule(); ruleFound {
+		if rule.Suppress {
+			return SeverityIgnored, true
+		}
+
+		if ruleSeverity, valid := ParseSeverity(rule.Severity); valid {
+			return ruleSeverity, true
+		}
+	}
+
 	return severity, found
 }
+
+func (m *monitAdapter) RateLimit() (string, time.Duration) {
+	key := strings.ToLower(strings.Join([]string{m.monitAlert.Service, m.monitAlert.Event, m.monitAlert.Action}, "\x00"))
+
+	rule, found := m.rule()
+	if !found {
+		return key, 0
+	}
+
+	return key, time.Duration(rule.RateLimitWindowInSeconds) * time.Second
+}
+
+func (m *monitAdapter) rule() (boshsettings.AlertRule, bool) {
+	return findRule(m.settingsService.GetSettings().Env.Bosh.Alerts.Rules, m.monitAlert)
+}
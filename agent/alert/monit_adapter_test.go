@@ -137,4 +137,76 @@ var _ = Describe("monitAdapter", func() {
 			Expect(builtAlert.Title).To(Equal("nats (10.0.0.1, 192.168.0.1) - does not exist - restart"))
 		})
 	})
+	Describe("alert rules", func() {
+		setRules := func(rules ...boshsettings.AlertRule) {
+			settingsService.Settings.Env.Bosh.Alerts.Rules = rules
+		}
+
+		It("overrides the severity of matching alerts", func() {
+			setRules(boshsettings.AlertRule{Service: "NATS", Event: "does not *", Severity: "warning"})
+
+			severity, found := NewMonitAdapter(buildMonitAlert(), settingsService, timeService).Severity()
+			Expect(found).To(BeTrue())
+			Expect(severity).To(Equal(SeverityWarning))
+		})
+
+		It("can report events that are ignored by default", func() {
+			monitAlert := buildMonitAlert()
+			monitAlert.Event = "pid changed"
+			setRules(boshsettings.AlertRule{Event: "pid changed", Severity: "critical"})
+
+			monitAdapter := NewMonitAdapter(monitAlert, settingsService, timeService)
+			Expect(monitAdapter.IsIgnorable()).To(BeFalse())
+
+			builtAlert, err := monitAdapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builtAlert.Severity).To(Equal(SeverityCritical))
+		})
+
+		It("suppresses matching alerts", func() {
+			setRules(boshsettings.AlertRule{Action: "restart", Suppress: true})
+
+			Expect(NewMonitAdapter(buildMonitAlert(), settingsService, timeService).IsIgnorable()).To(BeTrue())
+		})
+
+		It("applies the first matching rule only", func() {
+			setRules(
+				boshsettings.AlertRule{Service: "other"},
+				boshsettings.AlertRule{Service: "nats", Severity: "error"},
+				boshsettings.AlertRule{Service: "nats", Suppress: true},
+			)
+
+			monitAdapter := NewMonitAdapter(buildMonitAlert(), settingsService, timeService)
+			Expect(monitAdapter.IsIgnorable()).To(BeFalse())
+
+			severity, _ := monitAdapter.Severity()
+			Expect(severity).To(Equal(SeverityError))
+		})
+
+		It("keeps the default severity when a rule names an unknown severity", func() {
+			setRules(boshsettings.AlertRule{Service: "nats", Severity: "fake-severity"})
+
+			severity, _ := NewMonitAdapter(buildMonitAlert(), settingsService, timeService).Severity()
+			Expect(severity).To(Equal(SeverityAlert))
+		})
+
+		It("returns the rate limit window of the matching rule", func() {
+			setRules(boshsettings.AlertRule{Service: "nats", RateLimitWindowInSeconds: 60})
+
+			key, window := NewMonitAdapter(buildMonitAlert(), settingsService, timeService).RateLimit()
+			Expect(window).To(Equal(time.Minute))
+
+			otherAlert := buildMonitAlert()
+			otherAlert.Action = "alert"
+			otherKey, _ := NewMonitAdapter(otherAlert, settingsService, timeService).RateLimit()
+			Expect(otherKey).ToNot(Equal(key))
+		})
+
+		It("does not rate limit alerts without a matching rule", func() {
+			setRules(boshsettings.AlertRule{Service: "other", RateLimitWindowInSeconds: 60})
+
+			_, window := NewMonitAdapter(buildMonitAlert(), settingsService, timeService).RateLimit()
+			Expect(window).To(BeZero())
+		})
+	})
 })
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+// RateLimiter drops alerts that repeat within their rate limit window
+type RateLimiter struct {
+	timeService clock.Clock
+
+	until map[string]time.Time
+	lock  sync.Mutex
+}
+
+func NewRateLimiter(timeService clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		timeService: timeService,
+		until:       map[string]time.Time{},
+	}
+}
+
+// Allow returns false if an alert with the same key was allowed less than
+// window ago. A zero window allows every alert.
+func (l *RateLimiter) Allow(key string, window time.Duration) bool {
+	if window <= 0 {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.timeService.Now()
+
+	for existingKey, until := range l.until {
+		if !now.Before(until) {
+			delete(l.until, existingKey)
+		}
+	}
+
+	if _, found := l.until[key]; found {
+		return false
+	}
+
+	l.until[key] = now.Add(window)
+
+	return true
+}
@@ -0,0 +1,39 @@
+package alert_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		limiter     *RateLimiter
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		limiter = NewRateLimiter(timeService)
+	})
+
+	It("drops duplicates within the window", func() {
+		Expect(limiter.Allow("fake-key", time.Minute)).To(BeTrue())
+
+		timeService.Increment(59 * time.Second)
+		Expect(limiter.Allow("fake-key", time.Minute)).To(BeFalse())
+		Expect(limiter.Allow("other-key", time.Minute)).To(BeTrue())
+
+		timeService.Increment(time.Second)
+		Expect(limiter.Allow("fake-key", time.Minute)).To(BeTrue())
+	})
+
+	It("allows every alert without a window", func() {
+		Expect(limiter.Allow("fake-key", 0)).To(BeTrue())
+		Expect(limiter.Allow("fake-key", 0)).To(BeTrue())
+	})
+})
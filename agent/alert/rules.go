@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"path"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var severityNames = map[string]SeverityLevel{
+	"alert":    SeverityAlert,
+	"critical": SeverityCritical,
+	"error":    SeverityError,
+	"warning":  SeverityWarning,
+	"ignored":  SeverityIgnored,
+}
+
+// ParseSeverity converts severity names used in alert rules
+func ParseSeverity(name string) (SeverityLevel, bool) {
+	severity, found := severityNames[strings.ToLower(name)]
+	return severity, found
+}
+
+func findRule(rules []boshsettings.AlertRule, monitAlert MonitAlert) (boshsettings.AlertRule, bool) {
+	for _, rule := range rules {
+		if matchesPattern(rule.Service, monitAlert.Service) &&
+			matchesPattern(rule.Event, monitAlert.Event) &&
+			matchesPattern(rule.Action, monitAlert.Action) {
+			return rule, true
+		}
+	}
+
+	return boshsettings.AlertRule{}, false
+}
+
+func matchesPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
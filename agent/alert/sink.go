@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	defaultWebhookMaxAttempts = 3
+	webhookRetryDelay         = 2 * time.Second
+	webhookTimeout            = 10 * time.Second
+)
+
+// Sink delivers alerts to a destination other than the message bus
+type Sink interface {
+	Send(alert Alert) error
+}
+
+// NewSinks builds the local sinks enabled in settings
+func NewSinks(settings boshsettings.AlertSinks, timeService clock.Clock, logger boshlog.Logger) ([]Sink, error) {
+	sinks := []Sink{}
+
+	if settings.Syslog.Address != "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "-"
+		}
+
+		sinks = append(sinks, NewSyslogSink(settings.Syslog.Transport, settings.Syslog.Address, hostname, timeService))
+	}
+
+	if settings.Webhook.URL != "" {
+		transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+		if settings.Webhook.CA != "" {
+			certPool := x509.NewCertPool()
+			if !certPool.AppendCertsFromPEM([]byte(settings.Webhook.CA)) {
+				return nil, bosherr.Error("Parsing alert webhook CA certificate")
+			}
+
+			transport.TLSClientConfig = &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12}
+		}
+
+		maxAttempts := settings.Webhook.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = defaultWebhookMaxAttempts
+		}
+
+		sinks = append(sinks, NewWebhookSink(
+			settings.Webhook.URL,
+			settings.Webhook.Headers,
+			&http.Client{Transport: transport, Timeout: webhookTimeout},
+			maxAttempts,
+			webhookRetryDelay,
+			logger,
+		))
+	}
+
+	return sinks, nil
+}
@@ -0,0 +1,196 @@
+package alert_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("syslogSink", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		alert       Alert
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		alert = Alert{
+			ID:        "fake-\"id\"",
+			Severity:  SeverityError,
+			Title:     "nats - does not exist - restart",
+			Summary:   "process is not running",
+			CreatedAt: 1306076861,
+		}
+	})
+
+	It("sends RFC 5424 messages over udp", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		sink := NewSyslogSink("", conn.LocalAddr().String(), "fake-host", timeService)
+		Expect(sink.Send(alert)).To(Succeed())
+
+		buffer := make([]byte, 1024)
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+		n, _, err := conn.ReadFrom(buffer)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(buffer[:n])).To(Equal(
+			`<27>1 2011-05-22T15:07:41Z fake-host bosh-agent - alert [alert@47450 id="fake-\"id\"" severity="3"] nats - does not exist - restart: process is not running`,
+		))
+	})
+
+	It("frames messages with their length over tcp", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+
+		received := make(chan string, 1)
+		go func() {
+			defer GinkgoRecover()
+
+			conn, err := listener.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			received <- line
+		}()
+
+		sink := NewSyslogSink("tcp", listener.Addr().String(), "fake-host", timeService)
+		Expect(sink.Send(alert)).To(Succeed())
+
+		Eventually(received).Should(Receive(MatchRegexp(`^\d+ <27>1 .* process is not running$`)))
+	})
+
+	It("returns an error when the receiver cannot be reached", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		address := listener.Addr().String()
+		Expect(listener.Close()).To(Succeed())
+
+		err = NewSyslogSink("tcp", address, "fake-host", timeService).Send(alert)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Connecting to syslog"))
+	})
+})
+
+var _ = Describe("webhookSink", func() {
+	var (
+		server    *httptest.Server
+		responses []int
+		requests  []*http.Request
+		bodies    []Alert
+		lock      sync.Mutex
+		sink      Sink
+	)
+
+	BeforeEach(func() {
+		responses = nil
+		requests = nil
+		bodies = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			var alert Alert
+			Expect(json.NewDecoder(r.Body).Decode(&alert)).To(Succeed())
+
+			requests = append(requests, r)
+			bodies = append(bodies, alert)
+
+			status := http.StatusOK
+			if len(responses) > 0 {
+				status = responses[0]
+				responses = responses[1:]
+			}
+			w.WriteHeader(status)
+		}))
+
+		sink = NewWebhookSink(
+			server.URL,
+			map[string]string{"Authorization": "Bearer fake-token"},
+			server.Client(),
+			3,
+			time.Millisecond,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts alerts as JSON", func() {
+		Expect(sink.Send(Alert{ID: "fake-id", Severity: SeverityAlert})).To(Succeed())
+
+		Expect(bodies).To(Equal([]Alert{{ID: "fake-id", Severity: SeverityAlert}}))
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer fake-token"))
+	})
+
+	It("retries server errors", func() {
+		responses = []int{http.StatusBadGateway, http.StatusServiceUnavailable}
+
+		Expect(sink.Send(Alert{ID: "fake-id"})).To(Succeed())
+		Expect(requests).To(HaveLen(3))
+	})
+
+	It("gives up after the maximum number of attempts", func() {
+		responses = []int{500, 500, 500, 500}
+
+		err := sink.Send(Alert{ID: "fake-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Webhook responded with status 500"))
+		Expect(requests).To(HaveLen(3))
+	})
+
+	It("does not retry client errors", func() {
+		responses = []int{http.StatusUnauthorized}
+
+		err := sink.Send(Alert{ID: "fake-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("NewSinks", func() {
+	logger := boshlog.NewLogger(boshlog.LevelNone)
+
+	It("builds the configured sinks", func() {
+		sinks, err := NewSinks(boshsettings.AlertSinks{
+			Syslog:  boshsettings.SyslogAlertSink{Address: "127.0.0.1:514"},
+			Webhook: boshsettings.WebhookAlertSink{URL: "https://example.com/alerts"},
+		}, fakeclock.NewFakeClock(time.Now()), logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sinks).To(HaveLen(2))
+	})
+
+	It("builds no sinks by default", func() {
+		sinks, err := NewSinks(boshsettings.AlertSinks{}, fakeclock.NewFakeClock(time.Now()), logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sinks).To(BeEmpty())
+	})
+
+	It("returns an error for an invalid webhook CA", func() {
+		_, err := NewSinks(boshsettings.AlertSinks{
+			Webhook: boshsettings.WebhookAlertSink{URL: "https://example.com/alerts", CA: "fake-ca"},
+		}, fakeclock.NewFakeClock(time.Now()), logger)
+		Expect(err).To(MatchError("Parsing alert webhook CA certificate"))
+	})
+})
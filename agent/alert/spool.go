@@ -0,0 +1,134 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultSpoolMaxAlerts = 1000
+
+	spoolLogTag = "alertSpool"
+)
+
+// Spool keeps alerts on disk, one file per alert named after the time it
+// was spooled so that alerts are replayed in order
+type Spool struct {
+	fs          boshsys.FileSystem
+	dir         string
+	maxAlerts   int
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock sync.Mutex
+}
+
+func NewSpool(fs boshsys.FileSystem, dir string, maxAlerts int, timeService clock.Clock, logger boshlog.Logger) *Spool {
+	if maxAlerts <= 0 {
+		maxAlerts = DefaultSpoolMaxAlerts
+	}
+
+	return &Spool{
+		fs:          fs,
+		dir:         dir,
+		maxAlerts:   maxAlerts,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+// Add stores alert, dropping the oldest alerts beyond the maximum
+func (s *Spool) Add(alert Alert) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.fs.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating alert spool dir")
+	}
+
+	contents, err := json.Marshal(alert)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling alert")
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d.json", s.timeService.Now().UnixNano()))
+
+	err = s.fs.WriteFile(path, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing spooled alert")
+	}
+
+	paths, err := s.paths()
+	if err != nil {
+		return err
+	}
+
+	for len(paths) > s.maxAlerts {
+		s.logger.Warn(spoolLogTag, "Dropping spooled alert %s: spool is full", paths[0])
+		_ = s.fs.RemoveAll(paths[0])
+		paths = paths[1:]
+	}
+
+	return nil
+}
+
+// Replay sends spooled alerts oldest first, removing each one once sent.
+// It stops at the first alert that cannot be sent.
+func (s *Spool) Replay(send func(Alert) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	paths, err := s.paths()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		contents, err := s.fs.ReadFile(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading spooled alert %s", path)
+		}
+
+		var alert Alert
+
+		err = json.Unmarshal(contents, &alert)
+		if err != nil {
+			s.logger.Error(spoolLogTag, "Dropping unreadable spooled alert %s: %s", path, err.Error())
+			_ = s.fs.RemoveAll(path)
+			continue
+		}
+
+		err = send(alert)
+		if err != nil {
+			return err
+		}
+
+		err = s.fs.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing spooled alert %s", path)
+		}
+	}
+
+	return nil
+}
+
+func (s *Spool) paths() ([]string, error) {
+	paths, err := s.fs.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing spooled alerts")
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
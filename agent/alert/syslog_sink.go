@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	syslogFacilityDaemon = 3
+	syslogAppName        = "bosh-agent"
+	syslogMsgID          = "alert"
+
+	// syslogSDID uses the Cloud Foundry Foundation private enterprise number
+	syslogSDID = "alert@47450"
+
+	syslogDialTimeout = 5 * time.Second
+)
+
+type syslogSink struct {
+	transport   string
+	address     string
+	hostname    string
+	timeService clock.Clock
+}
+
+// NewSyslogSink sends alerts as RFC 5424 messages over udp or tcp.
+// TCP messages are framed using octet counting as per RFC 6587.
+func NewSyslogSink(transport, address, hostname string, timeService clock.Clock) Sink {
+	if transport == "" {
+		transport = "udp"
+	}
+
+	return syslogSink{
+		transport:   transport,
+		address:     address,
+		hostname:    hostname,
+		timeService: timeService,
+	}
+}
+
+func (s syslogSink) Send(alert Alert) error {
+	message := s.format(alert)
+
+	if s.transport == "tcp" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	conn, err := net.DialTimeout(s.transport, s.address, syslogDialTimeout)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to syslog %s", s.address)
+	}
+
+	defer conn.Close()
+
+	err = conn.SetWriteDeadline(s.timeService.Now().Add(syslogDialTimeout))
+	if err != nil {
+		return bosherr.WrapError(err, "Setting syslog write deadline")
+	}
+
+	_, err = conn.Write([]byte(message))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to syslog %s", s.address)
+	}
+
+	return nil
+}
+
+func (s syslogSink) format(alert Alert) string {
+	severity := int(alert.Severity)
+	if severity < int(SeverityAlert) || severity > int(SeverityWarning) {
+		severity = int(SeverityDefault)
+	}
+
+	timestamp := time.Unix(alert.CreatedAt, 0).UTC().Format(time.RFC3339)
+
+	structuredData := fmt.Sprintf(`[%s id="%s" severity="%d"]`, syslogSDID, escapeSDParam(alert.ID), alert.Severity)
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s %s %s: %s",
+		syslogFacilityDaemon*8+severity,
+		timestamp,
+		s.hostname,
+		syslogAppName,
+		syslogMsgID,
+		structuredData,
+		alert.Title,
+		alert.Summary,
+	)
+}
+
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
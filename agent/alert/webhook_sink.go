@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+)
+
+type webhookSink struct {
+	url         string
+	headers     map[string]string
+	httpClient  *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	logger      boshlog.Logger
+}
+
+// NewWebhookSink posts alerts as JSON. Connection errors and server
+// errors are retried, client errors are not.
+func NewWebhookSink(
+	url string,
+	headers map[string]string,
+	httpClient *http.Client,
+	maxAttempts int,
+	retryDelay time.Duration,
+	logger boshlog.Logger,
+) Sink {
+	return webhookSink{
+		url:         url,
+		headers:     headers,
+		httpClient:  httpClient,
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		logger:      logger,
+	}
+}
+
+func (s webhookSink) Send(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling alert")
+	}
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		return s.post(body)
+	})
+
+	err = boshretry.NewAttemptRetryStrategy(s.maxAttempts, s.retryDelay, retryable, s.logger).Try()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Posting alert to webhook")
+	}
+
+	return nil
+}
+
+func (s webhookSink) post(body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body)) //nolint:noctx
+	if err != nil {
+		return false, bosherr.WrapError(err, "Building request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return true, err
+	}
+
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	switch {
+	case response.StatusCode >= 500:
+		return true, bosherr.Errorf("Webhook responded with status %d", response.StatusCode)
+	case response.StatusCode >= 300:
+		return false, bosherr.Errorf("Webhook responded with status %d", response.StatusCode)
+	}
+
+	return false, nil
+}
@@ -2,16 +2,28 @@ package applyspec
 
 import (
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 )
 
 type JobTemplateSpec struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Resources optionally limits the resources of the job's processes.
+	// Pointer so that specs without limits are not changed when they are
+	// returned by get_state.
+	Resources *boshcgroup.Resources `json:"resources,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
-	return models.Job{
+	job := models.Job{
 		Name:    s.Name,
 		Version: s.Version,
 	}
+
+	if s.Resources != nil {
+		job.Resources = *s.Resources
+	}
+
+	return job
 }
@@ -0,0 +1,166 @@
+package applyspec
+
+import (
+	"sort"
+)
+
+// SpecDiff describes what applying a desired spec on top of
+// the current spec would change without changing anything
+type SpecDiff struct {
+	Packages          BundlesDiff           `json:"packages"`
+	Jobs              BundlesDiff           `json:"jobs"`
+	RenderedTemplates RenderedTemplatesDiff `json:"rendered_templates"`
+	PersistentDisk    PersistentDiskDiff    `json:"persistent_disk"`
+	MonitJobs         MonitJobsDiff         `json:"monit_jobs"`
+}
+
+type BundlesDiff struct {
+	Added   []BundleChange `json:"added"`
+	Removed []BundleChange `json:"removed"`
+	Changed []BundleChange `json:"changed"`
+}
+
+type BundleChange struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+type RenderedTemplatesDiff struct {
+	Changed bool   `json:"changed"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+type PersistentDiskDiff struct {
+	Changed bool `json:"changed"`
+	From    int  `json:"from"`
+	To      int  `json:"to"`
+}
+
+// MonitJobsDiff lists jobs whose monit configuration would be added,
+// removed or replaced, which restarts their processes
+type MonitJobsDiff struct {
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+	Reconfigured []string `json:"reconfigured"`
+}
+
+func (d SpecDiff) HasChanges() bool {
+	return d.Packages.hasChanges() ||
+		d.Jobs.hasChanges() ||
+		d.RenderedTemplates.Changed ||
+		d.PersistentDisk.Changed ||
+		len(d.MonitJobs.Added)+len(d.MonitJobs.Removed)+len(d.MonitJobs.Reconfigured) > 0
+}
+
+func (d BundlesDiff) hasChanges() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed) > 0
+}
+
+func Diff(current, desired V1ApplySpec) SpecDiff {
+	currentPackages := map[string]string{}
+	for _, pkg := range current.PackageSpecs {
+		currentPackages[pkg.Name] = pkg.Version + "-" + pkg.Sha1.String()
+	}
+
+	desiredPackages := map[string]string{}
+	for _, pkg := range desired.PackageSpecs {
+		desiredPackages[pkg.Name] = pkg.Version + "-" + pkg.Sha1.String()
+	}
+
+	currentJobs := map[string]string{}
+	for _, job := range current.JobSpec.JobTemplateSpecs {
+		currentJobs[job.Name] = job.Version
+	}
+
+	desiredJobs := map[string]string{}
+	for _, job := range desired.JobSpec.JobTemplateSpecs {
+		desiredJobs[job.Name] = job.Version
+	}
+
+	diff := SpecDiff{
+		Packages:          diffBundles(currentPackages, desiredPackages),
+		Jobs:              diffBundles(currentJobs, desiredJobs),
+		RenderedTemplates: diffRenderedTemplates(current.RenderedTemplatesArchiveSpec, desired.RenderedTemplatesArchiveSpec),
+		PersistentDisk: PersistentDiskDiff{
+			Changed: current.PersistentDisk != desired.PersistentDisk,
+			From:    current.PersistentDisk,
+			To:      desired.PersistentDisk,
+		},
+		MonitJobs: MonitJobsDiff{
+			Added:        []string{},
+			Removed:      []string{},
+			Reconfigured: []string{},
+		},
+	}
+
+	for _, change := range diff.Jobs.Added {
+		diff.MonitJobs.Added = append(diff.MonitJobs.Added, change.Name)
+	}
+
+	for _, change := range diff.Jobs.Removed {
+		diff.MonitJobs.Removed = append(diff.MonitJobs.Removed, change.Name)
+	}
+
+	// Monit files are part of the rendered templates archive, so any job
+	// that stays may get a new monit configuration when the archive changes
+	for name, version := range desiredJobs {
+		currentVersion, found := currentJobs[name]
+		if found && (currentVersion != version || diff.RenderedTemplates.Changed) {
+			diff.MonitJobs.Reconfigured = append(diff.MonitJobs.Reconfigured, name)
+		}
+	}
+
+	sort.Strings(diff.MonitJobs.Reconfigured)
+
+	return diff
+}
+
+func diffBundles(current, desired map[string]string) BundlesDiff {
+	diff := BundlesDiff{
+		Added:   []BundleChange{},
+		Removed: []BundleChange{},
+		Changed: []BundleChange{},
+	}
+
+	for name, version := range desired {
+		currentVersion, found := current[name]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, BundleChange{Name: name, To: version})
+		case currentVersion != version:
+			diff.Changed = append(diff.Changed, BundleChange{Name: name, From: currentVersion, To: version})
+		}
+	}
+
+	for name, version := range current {
+		if _, found := desired[name]; !found {
+			diff.Removed = append(diff.Removed, BundleChange{Name: name, From: version})
+		}
+	}
+
+	for _, changes := range [][]BundleChange{diff.Added, diff.Removed, diff.Changed} {
+		changes := changes
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	}
+
+	return diff
+}
+
+func diffRenderedTemplates(current, desired *RenderedTemplatesArchiveSpec) RenderedTemplatesDiff {
+	digest := func(spec *RenderedTemplatesArchiveSpec) string {
+		if spec == nil || spec.Sha1 == nil {
+			return ""
+		}
+		return spec.Sha1.String()
+	}
+
+	from, to := digest(current), digest(desired)
+
+	return RenderedTemplatesDiff{
+		Changed: from != to,
+		From:    from,
+		To:      to,
+	}
+}
@@ -0,0 +1,117 @@
+package applyspec_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+)
+
+var _ = Describe("Diff", func() {
+	digest := func(value string) crypto.MultipleDigest {
+		return crypto.MustNewMultipleDigest(crypto.NewDigest(crypto.DigestAlgorithmSHA1, value))
+	}
+
+	var (
+		current V1ApplySpec
+		desired V1ApplySpec
+	)
+
+	BeforeEach(func() {
+		currentArchiveDigest := digest("fake-current-archive-sha1")
+
+		current = V1ApplySpec{
+			JobSpec: JobSpec{
+				JobTemplateSpecs: []JobTemplateSpec{
+					{Name: "fake-kept-job", Version: "1"},
+					{Name: "fake-updated-job", Version: "1"},
+					{Name: "fake-removed-job", Version: "1"},
+				},
+			},
+			PackageSpecs: map[string]PackageSpec{
+				"fake-kept-pkg":    {Name: "fake-kept-pkg", Version: "1", Sha1: digest("kept")},
+				"fake-updated-pkg": {Name: "fake-updated-pkg", Version: "1", Sha1: digest("old")},
+				"fake-removed-pkg": {Name: "fake-removed-pkg", Version: "1", Sha1: digest("removed")},
+			},
+			RenderedTemplatesArchiveSpec: &RenderedTemplatesArchiveSpec{Sha1: &currentArchiveDigest},
+			PersistentDisk:               1024,
+		}
+
+		desired = V1ApplySpec{
+			JobSpec: JobSpec{
+				JobTemplateSpecs: []JobTemplateSpec{
+					{Name: "fake-kept-job", Version: "1"},
+					{Name: "fake-updated-job", Version: "2"},
+					{Name: "fake-added-job", Version: "1"},
+				},
+			},
+			PackageSpecs: map[string]PackageSpec{
+				"fake-kept-pkg":    {Name: "fake-kept-pkg", Version: "1", Sha1: digest("kept")},
+				"fake-updated-pkg": {Name: "fake-updated-pkg", Version: "2", Sha1: digest("new")},
+				"fake-added-pkg":   {Name: "fake-added-pkg", Version: "1", Sha1: digest("added")},
+			},
+			RenderedTemplatesArchiveSpec: &RenderedTemplatesArchiveSpec{Sha1: &currentArchiveDigest},
+			PersistentDisk:               1024,
+		}
+	})
+
+	It("lists added, removed and changed packages", func() {
+		diff := Diff(current, desired)
+
+		Expect(diff.Packages.Added).To(Equal([]BundleChange{{Name: "fake-added-pkg", To: "1-added"}}))
+		Expect(diff.Packages.Removed).To(Equal([]BundleChange{{Name: "fake-removed-pkg", From: "1-removed"}}))
+		Expect(diff.Packages.Changed).To(Equal([]BundleChange{{Name: "fake-updated-pkg", From: "1-old", To: "2-new"}}))
+	})
+
+	It("lists added, removed and changed jobs and the monit jobs they affect", func() {
+		diff := Diff(current, desired)
+
+		Expect(diff.Jobs.Added).To(Equal([]BundleChange{{Name: "fake-added-job", To: "1"}}))
+		Expect(diff.Jobs.Removed).To(Equal([]BundleChange{{Name: "fake-removed-job", From: "1"}}))
+		Expect(diff.Jobs.Changed).To(Equal([]BundleChange{{Name: "fake-updated-job", From: "1", To: "2"}}))
+
+		Expect(diff.MonitJobs).To(Equal(MonitJobsDiff{
+			Added:        []string{"fake-added-job"},
+			Removed:      []string{"fake-removed-job"},
+			Reconfigured: []string{"fake-updated-job"},
+		}))
+	})
+
+	It("reconfigures all kept jobs when the rendered templates change", func() {
+		desiredArchiveDigest := digest("fake-desired-archive-sha1")
+		desired.RenderedTemplatesArchiveSpec = &RenderedTemplatesArchiveSpec{Sha1: &desiredArchiveDigest}
+
+		diff := Diff(current, desired)
+
+		Expect(diff.RenderedTemplates).To(Equal(RenderedTemplatesDiff{
+			Changed: true,
+			From:    "fake-current-archive-sha1",
+			To:      "fake-desired-archive-sha1",
+		}))
+		Expect(diff.MonitJobs.Reconfigured).To(Equal([]string{"fake-kept-job", "fake-updated-job"}))
+	})
+
+	It("reports persistent disk size changes", func() {
+		desired.PersistentDisk = 2048
+
+		diff := Diff(current, desired)
+
+		Expect(diff.PersistentDisk).To(Equal(PersistentDiskDiff{Changed: true, From: 1024, To: 2048}))
+	})
+
+	It("reports no changes for identical specs", func() {
+		diff := Diff(current, current)
+
+		Expect(diff.HasChanges()).To(BeFalse())
+		Expect(Diff(current, desired).HasChanges()).To(BeTrue())
+	})
+
+	It("serializes empty lists as arrays", func() {
+		diffJSON, err := json.Marshal(Diff(V1ApplySpec{}, V1ApplySpec{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(diffJSON)).To(ContainSubstring(`"packages":{"added":[],"removed":[],"changed":[]}`))
+	})
+})
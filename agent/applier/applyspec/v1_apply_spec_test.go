@@ -8,6 +8,7 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -28,7 +29,7 @@ var _ = Describe("V1ApplySpec", func() {
 					"blobstore_id": "router-blob-id-1",
 					"templates": [
 						{"name": "template 1", "version": "0.1"},
-						{"name": "template 2", "version": "0.2"}
+						{"name": "template 2", "version": "0.2", "resources": {"cpu_weight": 200, "memory_bytes": 1073741824, "pids_max": 512}}
 					]
 				},
 				"packages": {
@@ -88,7 +89,7 @@ var _ = Describe("V1ApplySpec", func() {
 					Version:  "1.0",
 					JobTemplateSpecs: []JobTemplateSpec{
 						{Name: "template 1", Version: "0.1"},
-						{Name: "template 2", Version: "0.2"},
+						{Name: "template 2", Version: "0.2", Resources: &boshcgroup.Resources{CPUWeight: 200, MemoryBytes: 1073741824, PidsMax: 512}},
 					},
 				},
 				PackageSpecs: map[string]PackageSpec{
@@ -191,8 +192,9 @@ var _ = Describe("V1ApplySpec", func() {
 							Version: "fake-job1-version",
 						},
 						{
-							Name:    "fake-job2-name",
-							Version: "fake-job2-version",
+							Name:      "fake-job2-name",
+							Version:   "fake-job2-version",
+							Resources: &boshcgroup.Resources{MemoryBytes: 1024},
 						},
 					},
 				},
@@ -262,7 +264,8 @@ var _ = Describe("V1ApplySpec", func() {
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job2-name",
 					},
-					Packages: actualJobs[1].Packages, // tested above
+					Packages:  actualJobs[1].Packages, // tested above
+					Resources: boshcgroup.Resources{MemoryBytes: 1024},
 				},
 			}))
 		})
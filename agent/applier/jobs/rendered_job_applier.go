@@ -170,7 +170,7 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 
 	monitFilePath := path.Join(jobDir, "monit")
 	if s.fs.FileExists(monitFilePath) {
-		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath)
+		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath, job.Resources)
 		if err != nil {
 			err = bosherr.WrapError(err, "Adding monit configuration")
 			return
@@ -187,7 +187,7 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		label := strings.Replace(path.Base(monitFilePath), ".monit", "", 1)
 		subJobName := fmt.Sprintf("%s_%s", job.Name, label)
 
-		err = s.jobSupervisor.AddJob(subJobName, jobIndex, monitFilePath)
+		err = s.jobSupervisor.AddJob(subJobName, jobIndex, monitFilePath, job.Resources)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Adding additional monit configuration %s", label)
 			return
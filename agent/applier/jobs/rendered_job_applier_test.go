@@ -9,6 +9,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
@@ -401,6 +402,24 @@ var _ = Describe("renderedJobApplier", func() {
 			}))
 		})
 
+		It("adds job with its resource limits to the job supervisor", func() {
+			job, bundle := buildJob(jobsBc)
+			job.Resources = boshcgroup.Resources{MemoryBytes: 1024, PidsMax: 64}
+
+			err := fs.WriteFileString("/path/to/job/monit", "some conf")
+			Expect(err).NotTo(HaveOccurred())
+			fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+			bundle.GetDirPath = "/path/to/job"
+
+			err = applier.Configure(job, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobSupervisor.AddJobArgs).To(HaveLen(2))
+			Expect(jobSupervisor.AddJobArgs[0].Resources).To(Equal(boshcgroup.Resources{MemoryBytes: 1024, PidsMax: 64}))
+			Expect(jobSupervisor.AddJobArgs[1].Resources).To(Equal(boshcgroup.Resources{MemoryBytes: 1024, PidsMax: 64}))
+		})
+
 		It("does not require monit script", func() {
 			job, _ := buildJob(jobsBc)
 
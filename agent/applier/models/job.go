@@ -3,6 +3,7 @@ package models
 import (
 	"os"
 
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -14,6 +15,9 @@ type Job struct {
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
+
+	// Resources limits the job's processes; zero values leave limits unset
+	Resources boshcgroup.Resources
 }
 
 func (s Job) BundleName() string {
@@ -6,8 +6,11 @@ import (
 	"path"
 	"path/filepath"
 
+	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -25,6 +28,8 @@ type bootstrap struct {
 	dirProvider     boshdir.Provider
 	settingsService boshsettings.Service
 	specService     applyspec.V1Service
+	cgroupManager   boshcgroup.Manager
+	freezeWatchdog  boshaction.FreezeWatchdog
 	logger          boshlog.Logger
 	logTag          string
 }
@@ -34,6 +39,8 @@ func NewBootstrap(
 	dirProvider boshdir.Provider,
 	settingsService boshsettings.Service,
 	specService applyspec.V1Service,
+	cgroupManager boshcgroup.Manager,
+	freezeWatchdog boshaction.FreezeWatchdog,
 	logger boshlog.Logger,
 ) Bootstrap {
 	return bootstrap{
@@ -42,6 +49,8 @@ func NewBootstrap(
 		dirProvider:     dirProvider,
 		settingsService: settingsService,
 		specService:     specService,
+		cgroupManager:   cgroupManager,
+		freezeWatchdog:  freezeWatchdog,
 		logger:          logger,
 		logTag:          "bootstrap",
 	}
@@ -170,6 +179,11 @@ func (boot bootstrap) Run() (err error) { //nolint:gocyclo
 		return bosherr.WrapError(err, "Comparing persistent disks")
 	}
 
+	// Freezes made before the agent restarted must not outlive their timeout
+	if err = boot.freezeWatchdog.Restore(boot.dirProvider.StoreDir()); err != nil {
+		return bosherr.WrapError(err, "Restoring frozen filesystems")
+	}
+
 	v1Spec, err := boot.specService.Get()
 	if err != nil {
 		return bosherr.WrapError(err, "Cannot get v1spec from SpecService")
@@ -182,6 +196,11 @@ func (boot bootstrap) Run() (err error) { //nolint:gocyclo
 		}
 	}
 
+	// Cgroups do not survive a reboot and jobs need to be limited as soon as monit starts them
+	if err = boshjobsuper.RestoreJobCgroups(boot.fs, boot.dirProvider.MonitJobsDir(), boot.cgroupManager); err != nil {
+		return bosherr.WrapError(err, "Restoring job cgroups")
+	}
+
 	if err = boot.platform.SetupMonitUser(); err != nil {
 		return bosherr.WrapError(err, "Setting up monit user")
 	}
@@ -9,10 +9,13 @@ import (
 	"path/filepath"
 	"time"
 
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent"
+	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakedevicepathresolver "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver/fakes"
@@ -33,6 +36,8 @@ import (
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshcdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup/fakes"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
@@ -57,6 +62,9 @@ var _ = Describe("bootstrap", func() {
 
 			settingsService *fakesettings.FakeSettingsService
 			specService     *fakes.FakeV1Service
+			cgroupManager   *fakecgroup.FakeManager
+			freezeWatchdog  boshaction.FreezeWatchdog
+			fakeClock       *fakeclock.FakeClock
 
 			ephemeralDiskPath string
 			logger            *fakelogger.FakeLogger
@@ -69,6 +77,7 @@ var _ = Describe("bootstrap", func() {
 				PersistentDiskSettings: make(map[string]boshsettings.DiskSettings),
 			}
 			specService = fakes.NewFakeV1Service()
+			cgroupManager = fakecgroup.NewFakeManager()
 
 			ephemeralDiskPath = "/dev/sda"
 
@@ -86,10 +95,13 @@ var _ = Describe("bootstrap", func() {
 				},
 			}
 			logger = &fakelogger.FakeLogger{}
+
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			freezeWatchdog = boshaction.NewFreezeWatchdog(platform, fileSystem, "/fake-freeze-deadlines.json", fakeClock, logger)
 		})
 
 		bootstrap := func() error {
-			return agent.NewBootstrap(platform, dirProvider, settingsService, specService, logger).Run()
+			return agent.NewBootstrap(platform, dirProvider, settingsService, specService, cgroupManager, freezeWatchdog, logger).Run()
 		}
 
 		It("sets up runtime configuration", func() {
@@ -545,6 +557,57 @@ var _ = Describe("bootstrap", func() {
 			Expect(platform.StartMonitCallCount()).To(Equal(1))
 		})
 
+		Context("when jobs were added to monit with cgroup limits", func() {
+			BeforeEach(func() {
+				fileSystem.SetGlob("/var/vcap/monit/job/*.cgroup.json", []string{"/var/vcap/monit/job/0000_router.cgroup.json"})
+				err := fileSystem.WriteFileString("/var/vcap/monit/job/0000_router.cgroup.json", `{"memory_bytes":1073741824}`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("restores the job cgroups before monit starts the jobs", func() {
+				platform.StartMonitStub = func() error {
+					Expect(cgroupManager.Resources).To(HaveKeyWithValue("bosh-jobs/router", boshcgroup.Resources{MemoryBytes: 1073741824}))
+					return nil
+				}
+
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(platform.StartMonitCallCount()).To(Equal(1))
+			})
+
+			It("does not start monit when the limits cannot be enforced", func() {
+				cgroupManager.CreateErr = errors.New("fake-create-err")
+
+				err := bootstrap()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Restoring cgroup of job router"))
+				Expect(platform.StartMonitCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when filesystems were frozen before the agent restarted", func() {
+			BeforeEach(func() {
+				deadlines := fmt.Sprintf(`{"/fake-expired":%q,"/fake-frozen":%q}`,
+					fakeClock.Now().Add(-time.Second).Format(time.RFC3339Nano),
+					fakeClock.Now().Add(time.Minute).Format(time.RFC3339Nano),
+				)
+				err := fileSystem.WriteFileString("/fake-freeze-deadlines.json", deadlines)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("thaws the filesystems whose deadline passed and the others once theirs passes", func() {
+				err := bootstrap()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(platform.ThawFilesystemCallCount()).To(Equal(1))
+				Expect(platform.ThawFilesystemArgsForCall(0)).To(Equal("/fake-expired"))
+
+				fakeClock.WaitForWatcherAndIncrement(time.Minute)
+				Eventually(platform.ThawFilesystemCallCount).Should(Equal(2))
+				Expect(platform.ThawFilesystemArgsForCall(1)).To(Equal("/fake-frozen"))
+			})
+		})
+
 		Context("when RemoveDevTools is requested", func() {
 			BeforeEach(func() {
 				settingsService.Settings.Env.Bosh.RemoveDevTools = true
@@ -1098,9 +1161,9 @@ var _ = Describe("bootstrap", func() {
 				compressor := boshcmd.NewTarballCompressor(runner, fs)
 				copier := boshcmd.NewGenericCpCopier(fs, logger)
 
-				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
+				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{}, fakesys.NewFakeFileSystem(), logger)
 
-				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter)
+				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter, boshdisk.NewDummyHealthChecker())
 
 				ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -1122,6 +1185,7 @@ var _ = Describe("bootstrap", func() {
 				monitRetryStrategy := boshretry.NewAttemptRetryStrategy(10, 1*time.Second, monitRetryable, logger)
 
 				devicePathResolver := fakedevicepathresolver.NewFakeDevicePathResolver()
+				cgroupManager := boshcgroup.NewManager(fs, boshcgroup.DefaultMountPoint)
 
 				fakeUUIDGenerator := boshuuid.NewGenerator()
 				routesSearcher := boshnet.NewRoutesSearcher(logger, runner, nil)
@@ -1143,6 +1207,7 @@ var _ = Describe("bootstrap", func() {
 					ubuntuCertManager,
 					monitRetryStrategy,
 					devicePathResolver,
+					cgroupManager,
 					state,
 					linuxOptions,
 					logger,
@@ -1174,6 +1239,8 @@ var _ = Describe("bootstrap", func() {
 					dirProvider,
 					settingsService,
 					specService,
+					fakecgroup.NewFakeManager(),
+					boshaction.NewFreezeWatchdog(platform, platform.GetFs(), "/fake-freeze-deadlines.json", clock.NewClock(), logger),
 					logger,
 				)
 			})
@@ -0,0 +1,75 @@
+package compiler
+
+import (
+	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// cachingBlobstore keeps a copy of every blob it downloads in a PackageCache
+// keyed by the blob's digest. Callers get their own copy of cached blobs so
+// that cleaning up after them leaves the cache intact.
+type cachingBlobstore struct {
+	blobstore_delegator.BlobstoreDelegator
+
+	cache PackageCache
+	fs    boshsys.FileSystem
+}
+
+func NewCachingBlobstore(
+	blobstore blobstore_delegator.BlobstoreDelegator,
+	cache PackageCache,
+	fs boshsys.FileSystem,
+) blobstore_delegator.BlobstoreDelegator {
+	return cachingBlobstore{
+		BlobstoreDelegator: blobstore,
+		cache:              cache,
+		fs:                 fs,
+	}
+}
+
+func (b cachingBlobstore) Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (string, error) {
+	if digest == nil || digest.String() == "" {
+		return b.BlobstoreDelegator.Get(digest, signedURL, blobID, headers)
+	}
+
+	key := "blob:" + digest.String()
+
+	cachedPath, found, err := b.cache.Get(key)
+	if err == nil && found {
+		return b.copyToTemp(cachedPath)
+	}
+
+	fileName, err := b.BlobstoreDelegator.Get(digest, signedURL, blobID, headers)
+	if err != nil {
+		return "", err
+	}
+
+	// Caching is best effort, the blob simply gets downloaded again next time
+	_ = b.cache.Put(key, fileName)
+
+	return fileName, nil
+}
+
+func (b cachingBlobstore) copyToTemp(cachedPath string) (string, error) {
+	file, err := b.fs.TempFile("bosh-agent-cached-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for cached blob")
+	}
+
+	fileName := file.Name()
+
+	err = file.Close()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Closing temporary file for cached blob")
+	}
+
+	err = b.fs.CopyFile(cachedPath, fileName)
+	if err != nil {
+		_ = b.fs.RemoveAll(fileName)
+		return "", bosherr.WrapError(err, "Copying cached blob")
+	}
+
+	return fileName, nil
+}
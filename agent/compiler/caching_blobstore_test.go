@@ -0,0 +1,88 @@
+package compiler_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	fakecompiler "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
+	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("cachingBlobstore", func() {
+	var (
+		delegate  *fakeblobdelegator.FakeBlobstoreDelegator
+		cache     *fakecompiler.FakePackageCache
+		fs        *fakesys.FakeFileSystem
+		blobstore blobstore_delegator.BlobstoreDelegator
+		digest    boshcrypto.MultipleDigest
+	)
+
+	BeforeEach(func() {
+		delegate = &fakeblobdelegator.FakeBlobstoreDelegator{}
+		cache = fakecompiler.NewFakePackageCache()
+		fs = fakesys.NewFakeFileSystem()
+		blobstore = NewCachingBlobstore(delegate, cache, fs)
+		digest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+	})
+
+	It("downloads blobs that are not cached and caches them", func() {
+		delegate.GetReturns("/tmp/downloaded-blob", nil)
+
+		fileName, err := blobstore.Get(digest, "fake-signed-url", "fake-blob-id", map[string]string{"key": "value"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal("/tmp/downloaded-blob"))
+
+		Expect(delegate.GetCallCount()).To(Equal(1))
+		_, signedURL, blobID, headers := delegate.GetArgsForCall(0)
+		Expect(signedURL).To(Equal("fake-signed-url"))
+		Expect(blobID).To(Equal("fake-blob-id"))
+		Expect(headers).To(Equal(map[string]string{"key": "value"}))
+
+		Expect(cache.PutKeys).To(Equal([]string{"blob:fake-sha1"}))
+		Expect(cache.PutPaths).To(Equal([]string{"/tmp/downloaded-blob"}))
+	})
+
+	It("returns a copy of cached blobs without downloading them", func() {
+		Expect(fs.WriteFileString("/fake-cache/blob", "cached-contents")).To(Succeed())
+		cache.Entries["blob:fake-sha1"] = "/fake-cache/blob"
+
+		fileName, err := blobstore.Get(digest, "", "fake-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).ToNot(Equal("/fake-cache/blob"))
+		Expect(fs.ReadFileString(fileName)).To(Equal("cached-contents"))
+
+		Expect(delegate.GetCallCount()).To(Equal(0))
+	})
+
+	It("downloads blobs when the cache cannot be read", func() {
+		cache.GetErr = errors.New("fake-get-err")
+		delegate.GetReturns("/tmp/downloaded-blob", nil)
+
+		fileName, err := blobstore.Get(digest, "", "fake-blob-id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileName).To(Equal("/tmp/downloaded-blob"))
+	})
+
+	It("returns download errors", func() {
+		delegate.GetReturns("", errors.New("fake-download-err"))
+
+		_, err := blobstore.Get(digest, "", "fake-blob-id", nil)
+		Expect(err).To(MatchError("fake-download-err"))
+		Expect(cache.PutKeys).To(BeEmpty())
+	})
+
+	It("passes writes through to the blobstore", func() {
+		delegate.WriteReturns("fake-blob-id", digest, nil)
+
+		blobID, _, err := blobstore.Write("fake-signed-url", "/tmp/compiled", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-blob-id"))
+		Expect(delegate.WriteCallCount()).To(Equal(1))
+	})
+})
@@ -4,8 +4,20 @@
 package compiler
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+)
+
+const (
+	compileLogTag = "concreteCompiler"
+
+	// Killed processes leave the cgroup asynchronously
+	cgroupDrainInterval = 100 * time.Millisecond
+	cgroupDrainAttempts = 100
 )
 
 func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
@@ -20,9 +32,82 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 		},
 		WorkingDir: compilePath,
 	}
+
+	if c.sandboxOptions.Enabled {
+		return c.runSandboxedPackagingCommand(command, pkg)
+	}
+
 	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
 	if err != nil {
 		return bosherr.WrapError(err, "Running packaging script")
 	}
 	return nil
 }
+
+func (c concreteCompiler) runSandboxedPackagingCommand(command boshsys.Command, pkg Package) (err error) {
+	cg, err := c.cgroupManager.Create(compileCgroupPath(pkg), c.sandboxOptions.resources())
+	if err != nil {
+		return bosherr.WrapError(err, "Creating compilation cgroup")
+	}
+
+	defer c.removeCgroup(cg)
+
+	startedAt := c.timeProvider.Now()
+
+	_, err = c.runner.RunCommand("compilation", PackagingScriptName, c.sandboxOptions.sandboxCommand(command, cg.ProcsFiles()))
+	if err == nil {
+		return nil
+	}
+
+	elapsed := c.timeProvider.Since(startedAt)
+
+	stats, statsErr := cg.Stats()
+	if statsErr != nil {
+		return bosherr.WrapError(err, "Running packaging script")
+	}
+
+	limit, max, exceeded := c.sandboxOptions.exceededLimit(stats, elapsed)
+	if !exceeded {
+		return bosherr.WrapError(err, "Running packaging script")
+	}
+
+	return CompileLimitExceededError{
+		Package: pkg.Name,
+		Limit:   limit,
+		Max:     max,
+		Stats:   stats,
+		Cause:   err,
+	}
+}
+
+// removeCgroup kills left over background processes which would keep the
+// cgroup busy. A cgroup that cannot be removed does not fail the compilation.
+func (c concreteCompiler) removeCgroup(cg boshcgroup.Cgroup) {
+	err := cg.Kill()
+	if err == nil {
+		err = c.waitForCgroupToDrain(cg)
+	}
+	if err == nil {
+		err = cg.Delete()
+	}
+	if err != nil {
+		c.logger.Warn(compileLogTag, "Removing compilation cgroup %s: %s", cg.Path(), err.Error())
+	}
+}
+
+func (c concreteCompiler) waitForCgroupToDrain(cg boshcgroup.Cgroup) error {
+	for attempt := 0; attempt < cgroupDrainAttempts; attempt++ {
+		pids, err := cg.Pids()
+		if err != nil {
+			return bosherr.WrapError(err, "Listing processes of compilation cgroup")
+		}
+
+		if len(pids) == 0 {
+			return nil
+		}
+
+		c.timeProvider.Sleep(cgroupDrainInterval)
+	}
+
+	return bosherr.Errorf("Processes did not exit after %s", cgroupDrainInterval*cgroupDrainAttempts)
+}
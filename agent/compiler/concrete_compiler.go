@@ -1,9 +1,12 @@
 package compiler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path"
+	"sort"
 
 	"code.cloudfoundry.org/clock"
 
@@ -12,9 +15,11 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshcmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
@@ -32,7 +37,11 @@ type concreteCompiler struct {
 	compileDirProvider CompileDirProvider
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
+	packageCache       PackageCache
+	cgroupManager      boshcgroup.Manager
+	sandboxOptions     SandboxOptions
 	timeProvider       clock.Clock
+	logger             boshlog.Logger
 }
 
 func NewConcreteCompiler(
@@ -43,7 +52,11 @@ func NewConcreteCompiler(
 	compileDirProvider CompileDirProvider,
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
+	packageCache PackageCache,
+	cgroupManager boshcgroup.Manager,
+	sandboxOptions SandboxOptions,
 	timeProvider clock.Clock,
+	logger boshlog.Logger,
 ) Compiler {
 	return concreteCompiler{
 		compressor:         compressor,
@@ -53,11 +66,27 @@ func NewConcreteCompiler(
 		compileDirProvider: compileDirProvider,
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
+		packageCache:       packageCache,
+		cgroupManager:      cgroupManager,
+		sandboxOptions:     sandboxOptions,
 		timeProvider:       timeProvider,
+		logger:             logger,
 	}
 }
 
 func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+	cacheKey := compiledPackageCacheKey(pkg, deps)
+
+	cachedPath, found, err := c.packageCache.Get(cacheKey)
+	if err == nil && found {
+		blobID, digest, err := c.blobstore.Write(pkg.UploadSignedURL, cachedPath, pkg.BlobstoreHeaders)
+		if err != nil {
+			return "", nil, bosherr.WrapError(err, "Uploading cached compiled package")
+		}
+
+		return blobID, digest, nil
+	}
+
 	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Removing packages")
@@ -121,6 +150,9 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		_ = c.compressor.CleanUp(tmpPackageTar)
 	}()
 
+	// Caching is best effort, a miss only costs another compilation
+	_ = c.packageCache.Put(cacheKey, tmpPackageTar)
+
 	uploadedBlobID, digest, err := c.blobstore.Write(pkg.UploadSignedURL, tmpPackageTar, pkg.BlobstoreHeaders)
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Uploading compiled package")
@@ -144,6 +176,25 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 	return uploadedBlobID, digest, nil
 }
 
+// compiledPackageCacheKey identifies a compilation result by the package
+// fingerprint and source digest together with the digests of all
+// dependencies it was compiled against
+func compiledPackageCacheKey(pkg Package, deps []boshmodels.Package) string {
+	depKeys := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		depKeys = append(depKeys, fmt.Sprintf("%s/%s/%s", dep.Name, dep.Version, dep.Source.Sha1.String()))
+	}
+	sort.Strings(depKeys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s/%s/%s\n", pkg.Name, pkg.Version, pkg.Sha1.String())
+	for _, depKey := range depKeys {
+		fmt.Fprintf(hash, "%s\n", depKey)
+	}
+
+	return "compiled:" + hex.EncodeToString(hash.Sum(nil))
+}
+
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
 	if pkg.BlobstoreID == "" && pkg.PackageGetSignedURL == "" {
 		return bosherr.Error(fmt.Sprintf("No blobstore reference for package '%s'", pkg.Name))
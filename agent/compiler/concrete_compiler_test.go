@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,9 +16,14 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
+	fakecompiler "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -74,17 +80,13 @@ func init() { //nolint:funlen,gochecknoinits
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			packageCache   *fakecompiler.FakePackageCache
+			cgroupManager  *fakecgroup.FakeManager
+			fakeClock      *fakebc.FakeClock
 		)
 
-		BeforeEach(func() {
-			compressor = fakecmd.NewFakeCompressor()
-			blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
-			fs = fakesys.NewFakeFileSystem()
-			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
-			packageApplier = fakepackages.NewFakeApplier()
-			packagesBc = fakebc.NewFakeBundleCollection()
-
-			compiler = NewConcreteCompiler(
+		buildCompiler := func(sandboxOptions SandboxOptions) Compiler {
+			return NewConcreteCompiler(
 				compressor,
 				blobstore,
 				fs,
@@ -92,8 +94,26 @@ func init() { //nolint:funlen,gochecknoinits
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
-				new(fakebc.FakeClock),
+				packageCache,
+				cgroupManager,
+				sandboxOptions,
+				fakeClock,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
+		}
+
+		BeforeEach(func() {
+			compressor = fakecmd.NewFakeCompressor()
+			blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
+			fs = fakesys.NewFakeFileSystem()
+			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
+			packageApplier = fakepackages.NewFakeApplier()
+			packagesBc = fakebc.NewFakeBundleCollection()
+			packageCache = fakecompiler.NewFakePackageCache()
+			cgroupManager = fakecgroup.NewFakeManager()
+			fakeClock = new(fakebc.FakeClock)
+
+			compiler = buildCompiler(SandboxOptions{})
 
 			err := fs.MkdirAll("/real-compile-dir", os.ModePerm)
 			Expect(err).NotTo(HaveOccurred())
@@ -162,6 +182,89 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(fingerprint).To(Equal(pkg.Sha1))
 			})
 
+			It("stores the compiled package in the package cache", func() {
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageCache.PutKeys).To(HaveLen(1))
+				Expect(packageCache.PutKeys[0]).To(HavePrefix("compiled:"))
+				Expect(packageCache.PutPaths).To(Equal([]string{"/tmp/compressed-compiled-package"}))
+			})
+
+			It("does not fail compilation if caching the compiled package fails", func() {
+				packageCache.PutErr = errors.New("fake-put-err")
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("uses different cache keys for different dependency digests", func() {
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				pkgDeps[0].Source.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "other_sha1"))
+
+				_, _, err = compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageCache.PutKeys).To(HaveLen(2))
+				Expect(packageCache.PutKeys[0]).ToNot(Equal(packageCache.PutKeys[1]))
+			})
+
+			It("uses the same cache key regardless of the order of dependencies", func() {
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, err = compiler.Compile(pkg, []boshmodels.Package{pkgDeps[1], pkgDeps[0]})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageCache.PutKeys).To(HaveLen(2))
+				Expect(packageCache.PutKeys[0]).To(Equal(packageCache.PutKeys[1]))
+			})
+
+			Context("when the compiled package is already cached", func() {
+				BeforeEach(func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+					packageCache.Entries[packageCache.PutKeys[0]] = "/fake-cache/compiled-package"
+
+					packageApplier.ActionsCalled = nil
+					bundle.ActionsCalled = nil
+					runner.RunCommands = nil
+
+					blobstore.WriteReturns("fake-cached-blob-id", boshcrypto.MustNewMultipleDigest(
+						boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "cached-sha1"),
+					), nil)
+				})
+
+				It("uploads the cached package without compiling it again", func() {
+					blobID, digest, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobID).To(Equal("fake-cached-blob-id"))
+					Expect(digest.String()).To(Equal("cached-sha1"))
+
+					Expect(blobstore.WriteCallCount()).To(Equal(2))
+					signedURL, path, headers := blobstore.WriteArgsForCall(1)
+					Expect(signedURL).To(Equal(pkg.UploadSignedURL))
+					Expect(path).To(Equal("/fake-cache/compiled-package"))
+					Expect(headers).To(Equal(pkg.BlobstoreHeaders))
+
+					Expect(blobstore.GetCallCount()).To(Equal(1))
+					Expect(packageApplier.ActionsCalled).To(BeEmpty())
+					Expect(bundle.ActionsCalled).To(BeEmpty())
+					Expect(runner.RunCommands).To(BeEmpty())
+				})
+
+				It("returns an error if uploading the cached package fails", func() {
+					blobstore.WriteReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-write-err"))
+
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Uploading cached compiled package"))
+				})
+			})
+
 			It("cleans up all packages before and after applying dependent packages", func() {
 				_, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
@@ -319,6 +422,171 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
+
+				Context("when the sandbox is enabled", func() {
+					var startedAt time.Time
+
+					BeforeEach(func() {
+						if runtime.GOOS == "windows" {
+							Skip("Packaging scripts are not sandboxed on windows")
+						}
+
+						startedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+						fakeClock.NowReturns(startedAt)
+						fakeClock.SinceReturns(time.Minute)
+
+						compiler = buildCompiler(SandboxOptions{
+							Enabled:          true,
+							CPULimit:         2,
+							MemoryLimitInMB:  512,
+							PidsLimit:        100,
+							TimeoutInSeconds: 3600,
+							User:             "vcap",
+						})
+					})
+
+					It("runs the packaging script as the user in a cgroup with the configured limits", func() {
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(cgroupManager.Resources).To(Equal(map[string]boshcgroup.Resources{
+							"bosh-compile/pkg_name": {CPUs: 2, MemoryBytes: 512 * 1024 * 1024, PidsMax: 100},
+						}))
+
+						Expect(runner.RunCommands).To(HaveLen(1))
+						Expect(runner.RunCommands[0]).To(Equal(boshsys.Command{
+							Name: "sh",
+							Args: []string{
+								"-c",
+								`set -e
+for procs in '/sys/fs/cgroup/bosh-compile/pkg_name/cgroup.procs'; do echo $$ > "$procs"; done
+chown -R -H 'vcap': "$BOSH_COMPILE_TARGET" "$BOSH_INSTALL_TARGET"
+exec timeout --kill-after=10 3600 setpriv --reuid='vcap' --regid='vcap' --init-groups "$@"`,
+								"compile-sandbox",
+								"bash",
+								"-x",
+								PackagingScriptName,
+							},
+							Env: map[string]string{
+								"BOSH_COMPILE_TARGET":  "/fake-compile-dir/pkg_name",
+								"BOSH_INSTALL_TARGET":  "/fake-dir/packages/pkg_name",
+								"BOSH_PACKAGE_NAME":    "pkg_name",
+								"BOSH_PACKAGE_VERSION": "pkg_version",
+							},
+							WorkingDir: "/fake-compile-dir/pkg_name",
+						}))
+					})
+
+					It("kills left over processes and removes the cgroup", func() {
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						cg := cgroupManager.Cgroups["bosh-compile/pkg_name"]
+						Expect(cg.Killed).To(BeTrue())
+						Expect(cg.Deleted).To(BeTrue())
+					})
+
+					It("waits for killed processes to exit before removing the cgroup", func() {
+						cg := fakecgroup.NewFakeCgroup("bosh-compile/pkg_name")
+						cg.PidsValues = [][]int{{123}, {123}, {}}
+						cgroupManager.Cgroups["bosh-compile/pkg_name"] = cg
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fakeClock.SleepCallCount()).To(Equal(2))
+						Expect(cg.Deleted).To(BeTrue())
+					})
+
+					It("does not remove the cgroup when processes do not exit", func() {
+						cg := fakecgroup.NewFakeCgroup("bosh-compile/pkg_name")
+						cg.PidsValues = [][]int{{123}}
+						cgroupManager.Cgroups["bosh-compile/pkg_name"] = cg
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(cg.Deleted).To(BeFalse())
+					})
+
+					It("succeeds when the cgroup cannot be removed", func() {
+						cg := fakecgroup.NewFakeCgroup("bosh-compile/pkg_name")
+						cg.DeleteErr = errors.New("device or resource busy")
+						cgroupManager.Cgroups["bosh-compile/pkg_name"] = cg
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					It("returns an error when the cgroup cannot be created", func() {
+						cgroupManager.CreateErr = errors.New("fake-create-err")
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-create-err"))
+						Expect(runner.RunCommands).To(BeEmpty())
+					})
+
+					Context("when the packaging script fails", func() {
+						BeforeEach(func() {
+							runner.RunCommandErr = errors.New("fake-packaging-error")
+							cgroupManager.Cgroups["bosh-compile/pkg_name"] = fakecgroup.NewFakeCgroup("bosh-compile/pkg_name")
+						})
+
+						limitErr := func(err error) CompileLimitExceededError {
+							cause := err
+							for {
+								if typedErr, ok := cause.(CompileLimitExceededError); ok {
+									return typedErr
+								}
+								complexErr, ok := cause.(bosherr.ComplexError)
+								Expect(ok).To(BeTrue(), "expected a CompileLimitExceededError in %#v", err)
+								cause = complexErr.Cause
+							}
+						}
+
+						It("reports the memory limit when processes were OOM killed", func() {
+							cgroupManager.Cgroups["bosh-compile/pkg_name"].StatsValue = boshcgroup.Stats{OOMKills: 1, MemoryBytes: 42}
+
+							_, _, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Compiling package 'pkg_name' exceeded the memory limit of 536870912 bytes: fake-packaging-error"))
+
+							Expect(limitErr(err).ErrorDetails()).To(Equal(CompileLimitExceededDetails{
+								Type:    "compile_limit_exceeded",
+								Package: "pkg_name",
+								Limit:   CompileLimitMemory,
+								Max:     536870912,
+								Stats:   boshcgroup.Stats{OOMKills: 1, MemoryBytes: 42},
+							}))
+						})
+
+						It("reports the pids limit when forks were refused", func() {
+							cgroupManager.Cgroups["bosh-compile/pkg_name"].StatsValue = boshcgroup.Stats{PidsLimitHits: 3}
+
+							_, _, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).To(HaveOccurred())
+							Expect(limitErr(err).Limit).To(Equal(CompileLimitPids))
+							Expect(limitErr(err).Max).To(Equal(int64(100)))
+						})
+
+						It("reports the time limit when the script ran until the timeout", func() {
+							fakeClock.SinceReturns(time.Hour)
+
+							_, _, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).To(HaveOccurred())
+							Expect(limitErr(err).Limit).To(Equal(CompileLimitTime))
+							Expect(limitErr(err).Max).To(Equal(int64(3600)))
+							Expect(fakeClock.SinceArgsForCall(0)).To(Equal(startedAt))
+						})
+
+						It("returns the plain error when no limit was hit", func() {
+							_, _, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
+							Expect(err.Error()).ToNot(ContainSubstring("exceeded"))
+						})
+					})
+				})
 			})
 
 			It("does not run packaging script when script does not exist", func() {
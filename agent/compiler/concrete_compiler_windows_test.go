@@ -13,9 +13,11 @@ import (
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	fakecompiler "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
@@ -29,6 +31,7 @@ func init() { //nolint:gochecknoinits
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			packageCache   *fakecompiler.FakePackageCache
 			fakeClock      *fakebc.FakeClock
 		)
 
@@ -39,6 +42,7 @@ func init() { //nolint:gochecknoinits
 			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			packageApplier = fakepackages.NewFakeApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			packageCache = fakecompiler.NewFakePackageCache()
 			fakeClock = new(fakebc.FakeClock)
 
 			compiler = NewConcreteCompiler(
@@ -49,7 +53,11 @@ func init() { //nolint:gochecknoinits
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
+				packageCache,
+				nil,
+				SandboxOptions{},
 				fakeClock,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			err := fs.MkdirAll("/fake-compile-dir", os.ModePerm)
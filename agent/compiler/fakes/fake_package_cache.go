@@ -0,0 +1,29 @@
+package fakes
+
+type FakePackageCache struct {
+	Entries map[string]string
+	GetErr  error
+
+	PutKeys  []string
+	PutPaths []string
+	PutErr   error
+}
+
+func NewFakePackageCache() *FakePackageCache {
+	return &FakePackageCache{Entries: map[string]string{}}
+}
+
+func (c *FakePackageCache) Get(key string) (string, bool, error) {
+	if c.GetErr != nil {
+		return "", false, c.GetErr
+	}
+
+	path, found := c.Entries[key]
+	return path, found, nil
+}
+
+func (c *FakePackageCache) Put(key, path string) error {
+	c.PutKeys = append(c.PutKeys, key)
+	c.PutPaths = append(c.PutPaths, path)
+	return c.PutErr
+}
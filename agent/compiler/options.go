@@ -0,0 +1,26 @@
+package compiler
+
+type Options struct {
+	// PackageCacheMaxSize limits the local cache of downloaded and compiled
+	// package blobs in bytes. The cache is disabled unless it is set, since
+	// it shares the data disk with compilation.
+	PackageCacheMaxSize int64
+
+	Sandbox SandboxOptions
+}
+
+// SandboxOptions configure the cgroup packaging scripts run in.
+// Zero values leave the corresponding limit unset.
+type SandboxOptions struct {
+	Enabled bool
+
+	// CPULimit caps CPU bandwidth at the given number of CPUs, e.g. 2.5
+	CPULimit float64
+
+	MemoryLimitInMB  int64
+	PidsLimit        int64
+	TimeoutInSeconds int64
+
+	// User runs packaging scripts as the given unprivileged user instead of root
+	User string
+}
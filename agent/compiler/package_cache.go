@@ -0,0 +1,163 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const packageCacheTmpSuffix = ".tmp"
+
+// PackageCache keeps package blobs on local disk so that compilation VMs
+// do not have to download or build the same package twice
+type PackageCache interface {
+	// Get returns the path of the blob stored under key. The file belongs
+	// to the cache and must not be modified or removed by the caller.
+	Get(key string) (path string, found bool, err error)
+
+	// Put stores a copy of the file at path under key and evicts the
+	// least recently used entries once the cache grows beyond its maximum size
+	Put(key, path string) error
+}
+
+type fileSystemPackageCache struct {
+	fs      boshsys.FileSystem
+	dir     string
+	maxSize int64
+	lock    sync.Mutex
+}
+
+// NewFileSystemPackageCache returns a cache storing blobs in dir. A maxSize
+// of zero or less disables caching.
+func NewFileSystemPackageCache(fs boshsys.FileSystem, dir string, maxSize int64) PackageCache {
+	return &fileSystemPackageCache{
+		fs:      fs,
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+func (c *fileSystemPackageCache) Get(key string) (string, bool, error) {
+	if c.maxSize <= 0 {
+		return "", false, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	path := c.entryPath(key)
+
+	if !c.fs.FileExists(path) {
+		return "", false, nil
+	}
+
+	// Eviction goes by modification time, so hits mark entries as recently used.
+	// Failing to do so only makes the entry evicted sooner.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return path, true, nil
+}
+
+func (c *fileSystemPackageCache) Put(key, path string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	info, err := c.fs.Stat(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting size of %s", path)
+	}
+
+	if info.Size() > c.maxSize {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err = c.fs.MkdirAll(c.dir, os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating package cache directory")
+	}
+
+	entryPath := c.entryPath(key)
+	tmpPath := entryPath + packageCacheTmpSuffix
+
+	err = c.fs.CopyFile(path, tmpPath)
+	if err != nil {
+		_ = c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapError(err, "Copying blob into package cache")
+	}
+
+	err = c.fs.Rename(tmpPath, entryPath)
+	if err != nil {
+		_ = c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapError(err, "Moving blob into package cache")
+	}
+
+	return c.evict()
+}
+
+type packageCacheEntry struct {
+	path string
+	info os.FileInfo
+}
+
+func (c *fileSystemPackageCache) evict() error {
+	matches, err := c.fs.Glob(filepath.Join(c.dir, "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing package cache entries")
+	}
+
+	var totalSize int64
+	entries := []packageCacheEntry{}
+
+	for _, match := range matches {
+		if strings.HasSuffix(match, packageCacheTmpSuffix) {
+			continue
+		}
+
+		info, err := c.fs.Stat(match)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting size of package cache entry %s", match)
+		}
+
+		totalSize += info.Size()
+		entries = append(entries, packageCacheEntry{path: match, info: info})
+	}
+
+	// Least recently stored or read first
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].info.ModTime().Before(entries[j].info.ModTime())
+	})
+
+	for _, entry := range entries {
+		if totalSize <= c.maxSize {
+			break
+		}
+
+		err = c.fs.RemoveAll(entry.path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Evicting package cache entry %s", entry.path)
+		}
+
+		totalSize -= entry.info.Size()
+	}
+
+	return nil
+}
+
+// entryPath hashes key so that arbitrary keys map to valid file names
+func (c *fileSystemPackageCache) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,123 @@
+package compiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("fileSystemPackageCache", func() {
+	var (
+		tmpDir   string
+		cacheDir string
+		fs       boshsys.FileSystem
+		cache    PackageCache
+	)
+
+	writeBlob := func(name, contents string) string {
+		path := filepath.Join(tmpDir, name)
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "package-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		cacheDir = filepath.Join(tmpDir, "cache")
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		cache = NewFileSystemPackageCache(fs, cacheDir, 10)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("returns copies of stored blobs", func() {
+		Expect(cache.Put("key", writeBlob("blob", "12345"))).To(Succeed())
+		Expect(os.Remove(filepath.Join(tmpDir, "blob"))).To(Succeed())
+
+		path, found, err := cache.Get("key")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(filepath.Dir(path)).To(Equal(cacheDir))
+		Expect(os.ReadFile(path)).To(Equal([]byte("12345")))
+	})
+
+	It("does not find unknown keys", func() {
+		_, found, err := cache.Get("unknown")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("evicts the least recently used blobs once the maximum size is exceeded", func() {
+		Expect(cache.Put("old", writeBlob("old", "1234"))).To(Succeed())
+		Expect(cache.Put("middle", writeBlob("middle", "1234"))).To(Succeed())
+
+		oldPath, _, _ := cache.Get("old")
+		middlePath, _, _ := cache.Get("middle")
+		Expect(os.Chtimes(oldPath, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))).To(Succeed())
+		Expect(os.Chtimes(middlePath, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))).To(Succeed())
+
+		Expect(cache.Put("new", writeBlob("new", "1234"))).To(Succeed())
+
+		_, found, _ := cache.Get("old")
+		Expect(found).To(BeFalse())
+
+		_, found, _ = cache.Get("middle")
+		Expect(found).To(BeTrue())
+
+		_, found, _ = cache.Get("new")
+		Expect(found).To(BeTrue())
+	})
+
+	It("keeps old blobs that were read recently", func() {
+		Expect(cache.Put("old", writeBlob("old", "1234"))).To(Succeed())
+		Expect(cache.Put("middle", writeBlob("middle", "1234"))).To(Succeed())
+
+		oldPath, _, _ := cache.Get("old")
+		middlePath, _, _ := cache.Get("middle")
+		Expect(os.Chtimes(oldPath, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))).To(Succeed())
+		Expect(os.Chtimes(middlePath, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))).To(Succeed())
+
+		_, found, err := cache.Get("old")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		Expect(cache.Put("new", writeBlob("new", "1234"))).To(Succeed())
+
+		_, found, _ = cache.Get("middle")
+		Expect(found).To(BeFalse())
+
+		_, found, _ = cache.Get("old")
+		Expect(found).To(BeTrue())
+
+		_, found, _ = cache.Get("new")
+		Expect(found).To(BeTrue())
+	})
+
+	It("does not store blobs larger than the whole cache", func() {
+		Expect(cache.Put("big", writeBlob("big", "12345678901"))).To(Succeed())
+
+		_, found, _ := cache.Get("big")
+		Expect(found).To(BeFalse())
+	})
+
+	It("never stores blobs when disabled", func() {
+		cache = NewFileSystemPackageCache(fs, cacheDir, 0)
+
+		Expect(cache.Put("key", writeBlob("blob", "1"))).To(Succeed())
+
+		_, found, _ := cache.Get("key")
+		Expect(found).To(BeFalse())
+		Expect(cacheDir).ToNot(BeADirectory())
+	})
+})
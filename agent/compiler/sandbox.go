@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	compileCgroupParent = "bosh-compile"
+
+	CompileLimitMemory = "memory"
+	CompileLimitPids   = "pids"
+	CompileLimitTime   = "time"
+
+	// timeoutKillAfter is how long a timed out script may take to exit
+	// after SIGTERM before it is killed
+	timeoutKillAfter = 10 * time.Second
+)
+
+// CompileLimitExceededError is returned when a packaging script failed
+// after running into one of the limits of the compilation sandbox
+type CompileLimitExceededError struct {
+	Package string
+	Limit   string
+
+	// Max is the configured limit in bytes, processes or seconds
+	Max   int64
+	Stats boshcgroup.Stats
+
+	Cause error
+}
+
+type CompileLimitExceededDetails struct {
+	Type    string           `json:"type"`
+	Package string           `json:"package"`
+	Limit   string           `json:"limit"`
+	Max     int64            `json:"max"`
+	Stats   boshcgroup.Stats `json:"stats"`
+}
+
+func (e CompileLimitExceededError) Error() string {
+	return fmt.Sprintf("Compiling package '%s' exceeded the %s limit of %d %s: %s",
+		e.Package, e.Limit, e.Max, compileLimitUnits[e.Limit], e.Cause.Error())
+}
+
+func (e CompileLimitExceededError) ErrorDetails() interface{} {
+	return CompileLimitExceededDetails{
+		Type:    "compile_limit_exceeded",
+		Package: e.Package,
+		Limit:   e.Limit,
+		Max:     e.Max,
+		Stats:   e.Stats,
+	}
+}
+
+func (e CompileLimitExceededError) Unwrap() error {
+	return e.Cause
+}
+
+var compileLimitUnits = map[string]string{
+	CompileLimitMemory: "bytes",
+	CompileLimitPids:   "processes",
+	CompileLimitTime:   "seconds",
+}
+
+func (o SandboxOptions) resources() boshcgroup.Resources {
+	return boshcgroup.Resources{
+		CPUs:        o.CPULimit,
+		MemoryBytes: o.MemoryLimitInMB * 1024 * 1024,
+		PidsMax:     o.PidsLimit,
+	}
+}
+
+// sandboxCommand wraps command in a shell that moves itself into the
+// compilation cgroup before exec'ing the packaging script, so that all
+// processes spawned by the script are accounted for
+func (o SandboxOptions) sandboxCommand(command boshsys.Command, procsFiles []string) boshsys.Command {
+	quotedProcsFiles := make([]string, 0, len(procsFiles))
+	for _, procsFile := range procsFiles {
+		quotedProcsFiles = append(quotedProcsFiles, shellQuote(procsFile))
+	}
+
+	script := []string{
+		"set -e",
+		fmt.Sprintf(`for procs in %s; do echo $$ > "$procs"; done`, strings.Join(quotedProcsFiles, " ")),
+	}
+
+	exec := []string{"exec"}
+
+	if o.TimeoutInSeconds > 0 {
+		exec = append(exec, "timeout", fmt.Sprintf("--kill-after=%d", int64(timeoutKillAfter.Seconds())), fmt.Sprintf("%d", o.TimeoutInSeconds))
+	}
+
+	if o.User != "" {
+		user := shellQuote(o.User)
+		script = append(script, fmt.Sprintf(`chown -R -H %s: "$BOSH_COMPILE_TARGET" "$BOSH_INSTALL_TARGET"`, user))
+		exec = append(exec, "setpriv", "--reuid="+user, "--regid="+user, "--init-groups")
+	}
+
+	script = append(script, strings.Join(append(exec, `"$@"`), " "))
+
+	return boshsys.Command{
+		Name:       "sh",
+		Args:       append([]string{"-c", strings.Join(script, "\n"), "compile-sandbox", command.Name}, command.Args...),
+		Env:        command.Env,
+		WorkingDir: command.WorkingDir,
+	}
+}
+
+// exceededLimit determines which limit, if any, made a packaging script fail
+func (o SandboxOptions) exceededLimit(stats boshcgroup.Stats, elapsed time.Duration) (string, int64, bool) {
+	switch {
+	case stats.OOMKills > 0:
+		return CompileLimitMemory, o.MemoryLimitInMB * 1024 * 1024, true
+	case stats.PidsLimitHits > 0:
+		return CompileLimitPids, o.PidsLimit, true
+	case o.TimeoutInSeconds > 0 && elapsed >= time.Duration(o.TimeoutInSeconds)*time.Second:
+		return CompileLimitTime, o.TimeoutInSeconds, true
+	}
+
+	return "", 0, false
+}
+
+func compileCgroupPath(pkg Package) string {
+	return compileCgroupParent + "/" + pkg.Name
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
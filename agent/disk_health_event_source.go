@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	DiskEventSourceDiskHealth = "disk-health"
+
+	DiskEventHealthFailed = "disk health failed"
+	DiskEventMediaErrors  = "disk media errors"
+	DiskEventIOErrors     = "disk io errors"
+)
+
+// NewDiskHealthEventSource polls the health of the system, ephemeral and
+// persistent disks and reports disks starting to fail, i.e. their devices
+// failing SMART health checks and their error counters going up
+func NewDiskHealthEventSource(
+	vitalsService boshvitals.Service,
+	pollInterval time.Duration,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) boshjobsuper.JobEventSource {
+	return boshjobsuper.NewPollingJobEventSource[map[string]boshvitals.SpecificDiskHealthVitals](
+		DiskEventSourceDiskHealth,
+		diskHealthPoller{
+			DiskHealthViolations: DiskHealthViolations{TimeService: timeService},
+			vitalsService:        vitalsService,
+		},
+		pollInterval,
+		timeService,
+		logger,
+	)
+}
+
+type diskHealthPoller struct {
+	DiskHealthViolations
+	vitalsService boshvitals.Service
+}
+
+func (p diskHealthPoller) Poll() (map[string]boshvitals.SpecificDiskHealthVitals, error) {
+	vitals, err := p.vitalsService.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	health := map[string]boshvitals.SpecificDiskHealthVitals{}
+
+	for diskName, diskVitals := range vitals.Disk {
+		if diskVitals.Health != nil {
+			health[diskName] = *diskVitals.Health
+		}
+	}
+
+	return health, nil
+}
+
+// DiskHealthViolations finds disks that started to fail between two polls
+// of their health
+type DiskHealthViolations struct {
+	TimeService clock.Clock
+}
+
+func (v DiskHealthViolations) Diff(previous, current map[string]boshvitals.SpecificDiskHealthVitals) []boshalert.MonitAlert {
+	alerts := []boshalert.MonitAlert{}
+
+	diskNames := make([]string, 0, len(current))
+	for diskName := range current {
+		diskNames = append(diskNames, diskName)
+	}
+	sort.Strings(diskNames)
+
+	alert := func(diskName, event, description string) {
+		alerts = append(alerts, boshjobsuper.NewJobEvent(v.TimeService, DiskEventSourceDiskHealth, diskName+" disk", event, "alert", description))
+	}
+
+	for _, diskName := range diskNames {
+		health := current[diskName]
+		before, found := previous[diskName]
+
+		// A disk that is failing already when the agent starts is reported once
+		if health.Status == string(boshdisk.HealthStatusFailing) && before.Status != health.Status {
+			alert(diskName, DiskEventHealthFailed,
+				fmt.Sprintf("Devices %v failed their SMART health check", health.Devices))
+		}
+
+		// Counters may have gone up over the whole uptime of the device,
+		// e.g. a persistent disk attached to another VM before
+		if !found {
+			continue
+		}
+
+		if health.MediaErrors > before.MediaErrors {
+			alert(diskName, DiskEventMediaErrors,
+				fmt.Sprintf("%d media errors were reported by devices %v", health.MediaErrors-before.MediaErrors, health.Devices))
+		}
+
+		if health.IOErrors > before.IOErrors {
+			alert(diskName, DiskEventIOErrors,
+				fmt.Sprintf("%d IO errors were seen by the kernel on devices %v", health.IOErrors-before.IOErrors, health.Devices))
+		}
+	}
+
+	return alerts
+}
@@ -0,0 +1,85 @@
+package agent_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+)
+
+var _ = Describe("DiskHealthViolations", func() {
+	var (
+		diff     func(previous, current map[string]boshvitals.SpecificDiskHealthVitals) []boshalert.MonitAlert
+		previous map[string]boshvitals.SpecificDiskHealthVitals
+	)
+
+	BeforeEach(func() {
+		diff = DiskHealthViolations{TimeService: fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 10, 0, time.UTC))}.Diff
+		previous = map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "ok", MediaErrors: 2, IOErrors: 1},
+		}
+	})
+
+	It("does not report errors counted before it started", func() {
+		Expect(diff(nil, previous)).To(BeEmpty())
+		Expect(diff(previous, map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "ok", MediaErrors: 2, IOErrors: 1},
+		})).To(BeEmpty())
+	})
+
+	It("reports disks failing their health check", func() {
+		Expect(diff(previous, map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "failing", MediaErrors: 2, IOErrors: 1},
+		})).To(Equal([]boshalert.MonitAlert{{
+			ID:          "1767323050000000000.persistent disk@disk-health",
+			Service:     "persistent disk",
+			Event:       "disk health failed",
+			Action:      "alert",
+			Date:        "Fri, 02 Jan 2026 03:04:10 +0000",
+			Description: "Devices [sdc] failed their SMART health check",
+		}}))
+	})
+
+	It("reports new media errors", func() {
+		alerts := diff(previous, map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "ok", MediaErrors: 5, IOErrors: 1},
+		})
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Event).To(Equal("disk media errors"))
+		Expect(alerts[0].Description).To(Equal("3 media errors were reported by devices [sdc]"))
+	})
+
+	It("reports new kernel IO errors", func() {
+		alerts := diff(previous, map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "ok", MediaErrors: 2, IOErrors: 4},
+		})
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Event).To(Equal("disk io errors"))
+		Expect(alerts[0].Description).To(Equal("3 IO errors were seen by the kernel on devices [sdc]"))
+	})
+
+	It("does not report counters of disks that were just attached", func() {
+		Expect(diff(previous, map[string]boshvitals.SpecificDiskHealthVitals{
+			"persistent": {Devices: []string{"sdc"}, Status: "ok", MediaErrors: 2, IOErrors: 1},
+			"ephemeral":  {Devices: []string{"sdb"}, Status: "unknown", MediaErrors: 9, IOErrors: 9},
+		})).To(BeEmpty())
+	})
+
+	It("reports a disk that is failing when it starts once", func() {
+		failing := map[string]boshvitals.SpecificDiskHealthVitals{
+			"system": {Devices: []string{"sda"}, Status: "failing"},
+		}
+
+		alerts := diff(nil, failing)
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Service).To(Equal("system disk"))
+		Expect(alerts[0].Event).To(Equal("disk health failed"))
+
+		Expect(diff(failing, failing)).To(BeEmpty())
+	})
+})
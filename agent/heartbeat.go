@@ -1,6 +1,7 @@
 package agent
 
 import (
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 )
 
@@ -14,6 +15,10 @@ type Heartbeat struct {
 	JobState   string            `json:"job_state"`
 	Vitals     boshvitals.Vitals `json:"vitals"`
 	NodeID     string            `json:"node_id"`
+
+	CrashLoopingProcesses []string `json:"crash_looping_processes,omitempty"`
+
+	JobResources map[string]boshcgroup.Stats `json:"job_resources,omitempty"`
 }
 
 // Heartbeat payload example:
@@ -21,6 +26,11 @@ type Heartbeat struct {
 //   "job": "cloud_controller",
 //   "index": 3,
 //   "job_state":"running",
+//   "crash_looping_processes": ["worker"],
+//   "job_resources": {
+//     "worker": {"cpu_usage_ns":1500000,"memory_bytes":104857600,"pids":4,
+//       "io_read_bytes":0,"io_write_bytes":4096,"oom_kills":0,"pids_limit_hits":0}
+//   },
 //   "vitals": {
 //     "load": ["0.09","0.04","0.01"],
 //     "cpu": {"user":"0.0","sys":"0.0","wait":"0.4"},
@@ -31,6 +41,10 @@ type Heartbeat struct {
 //       "ephemeral": {"percent" => "5"},
 //       "persistent": {"percent" => "94"}
 //     },
+//     "pressure": {
+//       "memory": {"some": {"avg10":1.5,"avg60":0.4,"avg300":0.1},
+//         "full": {"avg10":0.2,"avg60":0.05,"avg300":0.01}}
+//     },
 //   "ntp": {
 //       "offset": "-0.06423",
 //       "timestamp": "14 Oct 11:13:19"
@@ -0,0 +1,204 @@
+package logtail
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const lineScanBlockSize = 4096
+
+type concreteTailer struct {
+	fs          boshsys.FileSystem
+	dirProvider boshdirs.Provider
+}
+
+func NewTailer(fs boshsys.FileSystem, dirProvider boshdirs.Provider) Tailer {
+	return concreteTailer{fs: fs, dirProvider: dirProvider}
+}
+
+func (t concreteTailer) Tail(request Request) (Chunk, error) {
+	chunk := Chunk{Files: []FileChunk{}, Offsets: map[string]int64{}}
+
+	var logsDir string
+
+	switch request.LogType {
+	case "job":
+		logsDir = t.dirProvider.LogsDir()
+	case "agent":
+		logsDir = t.dirProvider.AgentLogsDir()
+	default:
+		return chunk, bosherr.Error("Invalid log type")
+	}
+
+	filters := request.Filters
+	if len(filters) == 0 {
+		filters = []string{"**/*"}
+	}
+
+	maxBytes := request.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	paths, err := t.matchingFiles(logsDir, filters)
+	if err != nil {
+		return chunk, err
+	}
+
+	for _, relativePath := range paths {
+		offset, hasOffset := request.Offsets[relativePath]
+
+		fileChunk, err := t.readFile(filepath.Join(logsDir, relativePath), offset, hasOffset, request.Lines, maxBytes)
+		if err != nil {
+			return chunk, bosherr.WrapErrorf(err, "Tailing '%s'", relativePath)
+		}
+
+		fileChunk.Path = relativePath
+		chunk.Offsets[relativePath] = fileChunk.NextOffset
+
+		if len(fileChunk.Data) > 0 || fileChunk.Truncated {
+			chunk.Files = append(chunk.Files, fileChunk)
+		}
+	}
+
+	return chunk, nil
+}
+
+// matchingFiles returns sorted paths relative to logsDir of all regular files
+// matching filters; matches outside of logsDir are ignored
+func (t concreteTailer) matchingFiles(logsDir string, filters []string) ([]string, error) {
+	seen := map[string]bool{}
+	paths := []string{}
+
+	for _, filter := range filters {
+		matches, err := t.fs.RecursiveGlob(filepath.Join(logsDir, filter))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Finding files matching filters")
+		}
+
+		for _, match := range matches {
+			relativePath, err := filepath.Rel(logsDir, match)
+			if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+				continue
+			}
+
+			if seen[relativePath] {
+				continue
+			}
+
+			info, err := t.fs.Stat(match)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			seen[relativePath] = true
+			paths = append(paths, relativePath)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func (t concreteTailer) readFile(path string, offset int64, hasOffset bool, lines int, maxBytes int64) (FileChunk, error) {
+	var fileChunk FileChunk
+
+	file, err := t.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return fileChunk, bosherr.WrapError(err, "Opening file")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fileChunk, bosherr.WrapError(err, "Getting file info")
+	}
+
+	size := info.Size()
+
+	switch {
+	case hasOffset && (offset < 0 || offset > size):
+		fileChunk.Truncated = true
+		offset = 0
+	case hasOffset:
+	case lines > 0:
+		offset, err = lineOffset(file, size, lines)
+		if err != nil {
+			return fileChunk, err
+		}
+	default:
+		offset = size
+	}
+
+	length := size - offset
+	if length > maxBytes {
+		length = maxBytes
+	}
+
+	data := make([]byte, length)
+
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return fileChunk, bosherr.WrapError(err, "Reading file")
+	}
+
+	fileChunk.Offset = offset
+	fileChunk.NextOffset = offset + int64(n)
+	fileChunk.Data = string(data[:n])
+
+	return fileChunk, nil
+}
+
+// lineOffset finds the byte offset at which the last given number of lines
+// of the file start, not counting a trailing newline
+func lineOffset(file boshsys.File, size int64, lines int) (int64, error) {
+	end := size
+	found := 0
+	skipTrailingNewline := true
+
+	for end > 0 {
+		start := end - lineScanBlockSize
+		if start < 0 {
+			start = 0
+		}
+
+		block := make([]byte, end-start)
+
+		_, err := file.ReadAt(block, start)
+		if err != nil && err != io.EOF {
+			return 0, bosherr.WrapError(err, "Reading file")
+		}
+
+		if skipTrailingNewline && end == size && bytes.HasSuffix(block, []byte("\n")) {
+			block = block[:len(block)-1]
+		}
+		skipTrailingNewline = false
+
+		for i := len(block) - 1; i >= 0; i-- {
+			if block[i] != '\n' {
+				continue
+			}
+
+			found++
+			if found == lines {
+				return start + int64(i) + 1, nil
+			}
+		}
+
+		end = start
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,167 @@
+package logtail_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("concreteTailer", func() {
+	var (
+		baseDir     string
+		dirProvider boshdirs.Provider
+		tailer      logtail.Tailer
+	)
+
+	writeLog := func(relativePath, contents string) {
+		path := filepath.Join(dirProvider.LogsDir(), relativePath)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	appendLog := func(relativePath, contents string) {
+		file, err := os.OpenFile(filepath.Join(dirProvider.LogsDir(), relativePath), os.O_APPEND|os.O_WRONLY, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = os.MkdirTemp("", "logtail")
+		Expect(err).ToNot(HaveOccurred())
+
+		dirProvider = boshdirs.NewProvider(baseDir)
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		tailer = logtail.NewTailer(fs, dirProvider)
+
+		writeLog("job-a/job-a.stdout.log", "line 1\nline 2\nline 3\n")
+		writeLog("job-a/job-a.stderr.log", "error 1\n")
+		writeLog("job-b/job-b.log", "b 1\nb 2\n")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	It("returns an error for an unknown log type", func() {
+		_, err := tailer.Tail(logtail.Request{LogType: "other"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Invalid log type"))
+	})
+
+	It("starts at the end of files without offsets when no lines are requested", func() {
+		chunk, err := tailer.Tail(logtail.Request{LogType: "job"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(BeEmpty())
+		Expect(chunk.Offsets).To(Equal(map[string]int64{
+			"job-a/job-a.stderr.log": 8,
+			"job-a/job-a.stdout.log": 21,
+			"job-b/job-b.log":        8,
+		}))
+	})
+
+	It("returns the last lines of files matching the filters", func() {
+		chunk, err := tailer.Tail(logtail.Request{
+			LogType: "job",
+			Filters: []string{"job-a/*.stdout.log", "job-b/**/*"},
+			Lines:   2,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "job-a/job-a.stdout.log", Offset: 7, NextOffset: 21, Data: "line 2\nline 3\n"},
+			{Path: "job-b/job-b.log", Offset: 0, NextOffset: 8, Data: "b 1\nb 2\n"},
+		}))
+	})
+
+	It("returns only data appended since the given offsets", func() {
+		chunk, err := tailer.Tail(logtail.Request{LogType: "job", Filters: []string{"job-a/*"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		appendLog("job-a/job-a.stdout.log", "line 4\n")
+
+		chunk, err = tailer.Tail(logtail.Request{LogType: "job", Filters: []string{"job-a/*"}, Offsets: chunk.Offsets})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "job-a/job-a.stdout.log", Offset: 21, NextOffset: 28, Data: "line 4\n"},
+		}))
+	})
+
+	It("restarts from the beginning of files that shrank below their offset", func() {
+		writeLog("job-b/job-b.log", "new\n")
+
+		chunk, err := tailer.Tail(logtail.Request{
+			LogType: "job",
+			Filters: []string{"job-b/*"},
+			Offsets: map[string]int64{"job-b/job-b.log": 8},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "job-b/job-b.log", Offset: 0, NextOffset: 4, Data: "new\n", Truncated: true},
+		}))
+	})
+
+	It("restarts from the beginning of files given a negative offset", func() {
+		writeLog("job-b/job-b.log", "new\n")
+
+		chunk, err := tailer.Tail(logtail.Request{
+			LogType: "job",
+			Filters: []string{"job-b/*"},
+			Offsets: map[string]int64{"job-b/job-b.log": -5},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "job-b/job-b.log", Offset: 0, NextOffset: 4, Data: "new\n", Truncated: true},
+		}))
+	})
+
+	It("limits the amount of data returned per file", func() {
+		chunk, err := tailer.Tail(logtail.Request{
+			LogType:  "job",
+			Filters:  []string{"job-a/job-a.stdout.log"},
+			Offsets:  map[string]int64{"job-a/job-a.stdout.log": 0},
+			MaxBytes: 5,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "job-a/job-a.stdout.log", Offset: 0, NextOffset: 5, Data: "line "},
+		}))
+		Expect(chunk.Offsets["job-a/job-a.stdout.log"]).To(Equal(int64(5)))
+	})
+
+	It("ignores matches outside of the logs dir", func() {
+		Expect(os.WriteFile(filepath.Join(baseDir, "secret"), []byte("secret\n"), 0644)).To(Succeed())
+
+		chunk, err := tailer.Tail(logtail.Request{LogType: "job", Filters: []string{"../secret"}, Lines: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chunk.Files).To(BeEmpty())
+		Expect(chunk.Offsets).To(BeEmpty())
+	})
+
+	It("tails agent logs from the agent logs dir", func() {
+		Expect(os.MkdirAll(dirProvider.AgentLogsDir(), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dirProvider.AgentLogsDir(), "current"), []byte("agent\n"), 0644)).To(Succeed())
+
+		chunk, err := tailer.Tail(logtail.Request{LogType: "agent", Lines: 10})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chunk.Files).To(Equal([]logtail.FileChunk{
+			{Path: "current", Offset: 0, NextOffset: 6, Data: "agent\n"},
+		}))
+	})
+})
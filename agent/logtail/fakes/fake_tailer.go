@@ -0,0 +1,30 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
+)
+
+type FakeTailer struct {
+	TailRequests []logtail.Request
+	TailChunk    logtail.Chunk
+	TailErr      error
+
+	lock sync.Mutex
+}
+
+func (t *FakeTailer) Tail(request logtail.Request) (logtail.Chunk, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.TailRequests = append(t.TailRequests, request)
+	return t.TailChunk, t.TailErr
+}
+
+func (t *FakeTailer) Requests() []logtail.Request {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]logtail.Request{}, t.TailRequests...)
+}
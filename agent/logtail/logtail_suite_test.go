@@ -0,0 +1,13 @@
+package logtail_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogtail(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logtail Suite")
+}
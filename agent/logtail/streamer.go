@@ -0,0 +1,59 @@
+package logtail
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const DefaultStreamInterval = 1 * time.Second
+
+type flusher interface {
+	Flush()
+}
+
+// Streamer repeatedly tails logs and writes every non-empty FileChunk to
+// a writer as newline delimited JSON until done is closed
+type Streamer struct {
+	tailer   Tailer
+	clock    clock.Clock
+	interval time.Duration
+}
+
+func NewStreamer(tailer Tailer, clock clock.Clock, interval time.Duration) Streamer {
+	return Streamer{tailer: tailer, clock: clock, interval: interval}
+}
+
+func (s Streamer) Stream(request Request, w io.Writer, done <-chan struct{}) error {
+	encoder := json.NewEncoder(w)
+
+	for {
+		chunk, err := s.tailer.Tail(request)
+		if err != nil {
+			return err
+		}
+
+		for _, fileChunk := range chunk.Files {
+			err = encoder.Encode(fileChunk)
+			if err != nil {
+				return bosherr.WrapError(err, "Writing log chunk")
+			}
+		}
+
+		if f, ok := w.(flusher); ok && len(chunk.Files) > 0 {
+			f.Flush()
+		}
+
+		request.Offsets = chunk.Offsets
+
+		select {
+		case <-done:
+			return nil
+		case <-s.clock.After(s.interval):
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package logtail_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
+)
+
+type fakeTailer struct {
+	chunks   []logtail.Chunk
+	requests chan logtail.Request
+	err      error
+}
+
+func (t *fakeTailer) Tail(request logtail.Request) (logtail.Chunk, error) {
+	t.requests <- request
+	if t.err != nil {
+		return logtail.Chunk{}, t.err
+	}
+
+	chunk := t.chunks[0]
+	if len(t.chunks) > 1 {
+		t.chunks = t.chunks[1:]
+	}
+	return chunk, nil
+}
+
+var _ = Describe("Streamer", func() {
+	var (
+		tailer    *fakeTailer
+		fakeClock *fakeclock.FakeClock
+		streamer  logtail.Streamer
+		buffer    *gbytes.Buffer
+		done      chan struct{}
+	)
+
+	BeforeEach(func() {
+		tailer = &fakeTailer{requests: make(chan logtail.Request, 10)}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		streamer = logtail.NewStreamer(tailer, fakeClock, time.Second)
+		buffer = gbytes.NewBuffer()
+		done = make(chan struct{})
+	})
+
+	It("writes chunks as JSON lines and resumes from the previous offsets", func() {
+		tailer.chunks = []logtail.Chunk{
+			{
+				Files:   []logtail.FileChunk{{Path: "a.log", Offset: 0, NextOffset: 4, Data: "one\n"}},
+				Offsets: map[string]int64{"a.log": 4},
+			},
+			{
+				Files:   []logtail.FileChunk{{Path: "a.log", Offset: 4, NextOffset: 8, Data: "two\n"}},
+				Offsets: map[string]int64{"a.log": 8},
+			},
+		}
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- streamer.Stream(logtail.Request{LogType: "job", Lines: 5}, buffer, done)
+		}()
+
+		Eventually(buffer).Should(gbytes.Say(`{"path":"a.log","offset":0,"next_offset":4,"data":"one\\n"}\n`))
+		Expect((<-tailer.requests).Offsets).To(BeNil())
+
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+		Eventually(buffer).Should(gbytes.Say(`{"path":"a.log","offset":4,"next_offset":8,"data":"two\\n"}\n`))
+		request := <-tailer.requests
+		Expect(request.Offsets).To(Equal(map[string]int64{"a.log": 4}))
+		Expect(request.Lines).To(Equal(5))
+
+		close(done)
+		Eventually(errCh).Should(Receive(BeNil()))
+	})
+
+	It("returns tailing errors", func() {
+		tailer.err = errors.New("fake-tail-err")
+
+		err := streamer.Stream(logtail.Request{LogType: "job"}, buffer, done)
+		Expect(err).To(MatchError("fake-tail-err"))
+	})
+})
@@ -0,0 +1,41 @@
+package logtail
+
+const (
+	// DefaultMaxBytes bounds how much of a single file is returned per chunk
+	DefaultMaxBytes = 64 * 1024
+)
+
+type Request struct {
+	LogType string   `json:"log_type"`
+	Filters []string `json:"filters,omitempty"`
+
+	// Offsets are byte offsets keyed by file path relative to the logs dir,
+	// usually copied from the previous Chunk
+	Offsets map[string]int64 `json:"offsets,omitempty"`
+
+	// Lines is how many lines before the end of a file to start reading
+	// when it has no byte offset yet; zero starts at the end
+	Lines int `json:"lines,omitempty"`
+
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+type FileChunk struct {
+	Path       string `json:"path"`
+	Offset     int64  `json:"offset"`
+	NextOffset int64  `json:"next_offset"`
+	Data       string `json:"data"`
+
+	// Truncated is set when the file shrank below the requested offset,
+	// e.g. after log rotation, and reading restarted from the beginning
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type Chunk struct {
+	Files   []FileChunk      `json:"files"`
+	Offsets map[string]int64 `json:"offsets"`
+}
+
+type Tailer interface {
+	Tail(request Request) (Chunk, error)
+}
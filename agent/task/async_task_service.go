@@ -1,32 +1,46 @@
 package task
 
 import (
+	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
-// Access to the currentTasks map should always be performed in the semaphore
+// Access to the currentTasks, queues and running maps should always be performed in the semaphore
 // Use the taskSem channel for that
 
 type asyncTaskService struct {
-	uuidGen boshuuid.Generator
-	logger  boshlog.Logger
+	uuidGen     boshuuid.Generator
+	history     History
+	limits      ConcurrencyLimits
+	timeService clock.Clock
+	logger      boshlog.Logger
 
 	currentTasks map[string]Task
-	taskChan     chan Task
+	queues       map[ConcurrencyClass][]string
+	running      map[ConcurrencyClass]int
 	taskSem      chan func()
 }
 
-func NewAsyncTaskService(uuidGen boshuuid.Generator, logger boshlog.Logger) (service Service) {
+func NewAsyncTaskService(
+	uuidGen boshuuid.Generator,
+	history History,
+	limits ConcurrencyLimits,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) (service Service) {
 	s := asyncTaskService{
 		uuidGen:      uuidGen,
+		history:      history,
+		limits:       limits,
+		timeService:  timeService,
 		logger:       logger,
 		currentTasks: make(map[string]Task),
-		taskChan:     make(chan Task),
+		queues:       make(map[ConcurrencyClass][]string),
+		running:      make(map[ConcurrencyClass]int),
 		taskSem:      make(chan func()),
 	}
 
-	go s.processTasks()
 	go s.processSemFuncs()
 
 	return s
@@ -52,24 +66,32 @@ func (service asyncTaskService) CreateTaskWithID(
 	endFunc EndFunc,
 ) Task {
 	return Task{
-		ID:         id,
-		State:      StateRunning,
-		Func:       taskFunc,
-		CancelFunc: cancelFunc,
-		EndFunc:    endFunc,
+		ID:               id,
+		State:            StateRunning,
+		ConcurrencyClass: ConcurrencyClassSerial,
+		Func:             taskFunc,
+		CancelFunc:       cancelFunc,
+		EndFunc:          endFunc,
 	}
 }
 
 func (service asyncTaskService) StartTask(task Task) {
-	taskChan := make(chan Task)
+	if task.ConcurrencyClass == "" {
+		task.ConcurrencyClass = ConcurrencyClassSerial
+	}
+
+	task.QueuedAt = service.timeService.Now()
+
+	doneChan := make(chan struct{})
 
 	service.taskSem <- func() {
 		service.currentTasks[task.ID] = task
-		taskChan <- task
+		service.queues[task.ConcurrencyClass] = append(service.queues[task.ConcurrencyClass], task.ID)
+		service.schedule(task.ConcurrencyClass)
+		close(doneChan)
 	}
 
-	recordedTask := <-taskChan
-	service.taskChan <- recordedTask
+	<-doneChan
 }
 
 func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
@@ -78,11 +100,19 @@ func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 
 	service.taskSem <- func() {
 		task, found := service.currentTasks[id]
+		if found {
+			task = service.withQueueStatus(task)
+		}
 		taskChan <- task
 		foundChan <- found
 	}
 
-	return <-taskChan, <-foundChan
+	task, found := <-taskChan, <-foundChan
+	if found {
+		return task, true
+	}
+
+	return service.history.Find(id)
 }
 
 func (service asyncTaskService) processSemFuncs() {
@@ -94,33 +124,78 @@ func (service asyncTaskService) processSemFuncs() {
 	}
 }
 
-func (service asyncTaskService) processTasks() {
-	defer service.logger.HandlePanic("Task Service Process Tasks")
+// schedule must be called from within the semaphore
+func (service asyncTaskService) schedule(class ConcurrencyClass) {
+	for len(service.queues[class]) > 0 && service.limits.allows(class, service.running[class]) {
+		id := service.queues[class][0]
+		service.queues[class] = service.queues[class][1:]
+		service.running[class]++
 
-	for {
-		task := <-service.taskChan
+		task := service.currentTasks[id]
+		task.StartedAt = service.timeService.Now()
+		service.currentTasks[id] = task
 
-		value, err := task.Func()
-		if err != nil {
-			task.Error = err
-			task.State = StateFailed
-			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
-		} else {
-			task.Value = value
-			task.State = StateDone
-		}
+		go service.processTask(task)
+	}
+}
+
+// withQueueStatus must be called from within the semaphore
+func (service asyncTaskService) withQueueStatus(task Task) Task {
+	if !task.StartedAt.IsZero() {
+		task.QueueWait = task.StartedAt.Sub(task.QueuedAt)
+		return task
+	}
 
-		if task.EndFunc != nil {
-			task.EndFunc(task)
+	for i, id := range service.queues[task.ConcurrencyClass] {
+		if id == task.ID {
+			task.QueuePosition = i + 1
+			break
 		}
+	}
 
-		// Nil to prevent to memory leaks in case these are closures.
-		task.Func = nil
-		task.CancelFunc = nil
-		task.EndFunc = nil
+	task.QueueWait = service.timeService.Since(task.QueuedAt)
 
-		service.taskSem <- func() {
+	return task
+}
+
+func (service asyncTaskService) processTask(task Task) {
+	defer service.logger.HandlePanic("Task Service Process Task")
+
+	value, err := task.Func()
+	if err != nil {
+		task.Error = err
+		task.State = StateFailed
+		service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
+	} else {
+		task.Value = value
+		task.State = StateDone
+	}
+
+	if task.EndFunc != nil {
+		task.EndFunc(task)
+	}
+
+	// Nil to prevent to memory leaks in case these are closures.
+	task.Func = nil
+	task.CancelFunc = nil
+	task.ProgressFunc = nil
+	task.EndFunc = nil
+
+	// Finished tasks are served from the history, which bounds the number
+	// of retained results; keep the task in memory if it cannot be recorded
+	err = service.history.Record(task)
+	if err != nil {
+		service.logger.Error("Task Service", "Failed recording task #%s in history: %s", task.ID, err.Error())
+	}
+
+	service.taskSem <- func() {
+		if err != nil {
 			service.currentTasks[task.ID] = task
+		} else {
+			delete(service.currentTasks, task.ID)
 		}
+
+		service.running[task.ConcurrencyClass]--
+		service.schedule(task.ConcurrencyClass)
 	}
 }
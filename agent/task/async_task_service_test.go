@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
 
@@ -17,12 +19,16 @@ func init() { //nolint:funlen,gochecknoinits
 	Describe("asyncTaskService", func() {
 		var (
 			uuidGen *fakeuuid.FakeGenerator
+			fs      *fakesys.FakeFileSystem
 			service Service
 		)
 
 		BeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
 			uuidGen = &fakeuuid.FakeGenerator{}
-			service = NewAsyncTaskService(uuidGen, boshlog.NewLogger(boshlog.LevelNone))
+			fs = fakesys.NewFakeFileSystem()
+			history := NewHistory(logger, fs, "/dir/task_history.json", fakeclock.NewFakeClock(time.Now()), time.Hour, 500)
+			service = NewAsyncTaskService(uuidGen, history, DefaultConcurrencyLimits(), fakeclock.NewFakeClock(time.Now()), logger)
 		})
 
 		Describe("StartTask", func() {
@@ -60,34 +66,64 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(task.Error).To(Equal(err))
 			})
 
-			It("sets task Func, CancelFunc and EndFunc to nil on a successful task", func() {
+			It("sets task Func, CancelFunc, ProgressFunc and EndFunc to nil on a successful task", func() {
 				runFunc := func() (interface{}, error) { return nil, nil }
 				cancelFunc := func(_ Task) error { return nil }
 				endFunc := func(_ Task) {}
 
 				task, createErr := service.CreateTask(runFunc, cancelFunc, endFunc)
 				Expect(createErr).ToNot(HaveOccurred())
+				task.ProgressFunc = func() interface{} { return nil }
 
 				task = startAndWaitForTaskCompletion(task)
 				Expect(task.Func).To(BeNil())
 				Expect(task.CancelFunc).To(BeNil())
+				Expect(task.ProgressFunc).To(BeNil())
 				Expect(task.EndFunc).To(BeNil())
 			})
 
-			It("sets task Func, CancelFunc and EndFunc to nil on a failing task", func() {
+			It("sets task Func, CancelFunc, ProgressFunc and EndFunc to nil on a failing task", func() {
 				runFunc := func() (interface{}, error) { return nil, errors.New("fake-error") }
 				cancelFunc := func(_ Task) error { return nil }
 				endFunc := func(_ Task) {}
 
 				task, createErr := service.CreateTask(runFunc, cancelFunc, endFunc)
 				Expect(createErr).ToNot(HaveOccurred())
+				task.ProgressFunc = func() interface{} { return nil }
 
 				task = startAndWaitForTaskCompletion(task)
 				Expect(task.Func).To(BeNil())
 				Expect(task.CancelFunc).To(BeNil())
+				Expect(task.ProgressFunc).To(BeNil())
 				Expect(task.EndFunc).To(BeNil())
 			})
 
+			It("records finished tasks in the history so they survive a restart", func() {
+				runFunc := func() (interface{}, error) { return "fake-value", nil }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				startAndWaitForTaskCompletion(task)
+
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				otherHistory := NewHistory(logger, fs, "/dir/task_history.json", fakeclock.NewFakeClock(time.Now()), time.Hour, 500)
+				otherService := NewAsyncTaskService(uuidGen, otherHistory, DefaultConcurrencyLimits(), fakeclock.NewFakeClock(time.Now()), logger)
+
+				task, found := otherService.FindTaskWithID("fake-task-id")
+				Expect(found).To(BeTrue())
+				Expect(task.State).To(Equal(StateDone))
+				Expect(task.Value).To(Equal("fake-value"))
+			})
+
+			It("keeps finished tasks in memory when they cannot be recorded in the history", func() {
+				fs.WriteFileError = errors.New("fake-write-error")
+				runFunc := func() (interface{}, error) { return 123, nil }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.State).To(Equal(StateDone))
+				Expect(task.Value).To(Equal(123))
+			})
+
 			Describe("CreateTask", func() {
 				It("can run task created with CreateTask which does not have end func", func() {
 					ranFunc := false
@@ -149,6 +185,76 @@ func init() { //nolint:funlen,gochecknoinits
 				})
 			})
 
+			Describe("concurrency classes", func() {
+				var (
+					release chan struct{}
+					started chan string
+				)
+
+				BeforeEach(func() {
+					release = make(chan struct{})
+					started = make(chan string, 10)
+				})
+
+				blockingTask := func(id string, class ConcurrencyClass) Task {
+					task := service.CreateTaskWithID(id, func() (interface{}, error) {
+						started <- id
+						<-release
+						return nil, nil
+					}, nil, nil)
+					task.ConcurrencyClass = class
+					return task
+				}
+
+				It("runs tasks of a limited class one after another and reports their queue position", func() {
+					service.StartTask(blockingTask("fake-task-1", ConcurrencyClassSerial))
+					service.StartTask(blockingTask("fake-task-2", ConcurrencyClassSerial))
+					service.StartTask(blockingTask("fake-task-3", ConcurrencyClassSerial))
+
+					Eventually(started).Should(Receive(Equal("fake-task-1")))
+					Consistently(started).ShouldNot(Receive())
+
+					task, found := service.FindTaskWithID("fake-task-3")
+					Expect(found).To(BeTrue())
+					Expect(task.State).To(Equal(StateRunning))
+					Expect(task.QueuePosition).To(Equal(2))
+
+					task, _ = service.FindTaskWithID("fake-task-1")
+					Expect(task.QueuePosition).To(Equal(0))
+
+					release <- struct{}{}
+					Eventually(started).Should(Receive(Equal("fake-task-2")))
+
+					task, _ = service.FindTaskWithID("fake-task-3")
+					Expect(task.QueuePosition).To(Equal(1))
+
+					close(release)
+					Eventually(started).Should(Receive(Equal("fake-task-3")))
+				})
+
+				It("runs tasks of different classes next to each other", func() {
+					service.StartTask(blockingTask("fake-task-1", ConcurrencyClassSerial))
+					service.StartTask(blockingTask("fake-task-2", ConcurrencyClassCompile))
+
+					Eventually(started).Should(Receive())
+					Eventually(started).Should(Receive())
+
+					close(release)
+				})
+
+				It("runs tasks of an unbounded class in parallel", func() {
+					service.StartTask(blockingTask("fake-task-1", ConcurrencyClassParallel))
+					service.StartTask(blockingTask("fake-task-2", ConcurrencyClassParallel))
+					service.StartTask(blockingTask("fake-task-3", ConcurrencyClassParallel))
+
+					Eventually(started).Should(Receive())
+					Eventually(started).Should(Receive())
+					Eventually(started).Should(Receive())
+
+					close(release)
+				})
+			})
+
 			It("can process many tasks simultaneously", func() {
 				taskFunc := func() (interface{}, error) {
 					time.Sleep(10 * time.Millisecond)
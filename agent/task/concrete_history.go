@@ -0,0 +1,246 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type concreteHistoryProvider struct{}
+
+func NewHistoryProvider() HistoryProvider {
+	return concreteHistoryProvider{}
+}
+
+func (provider concreteHistoryProvider) NewHistory(
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	dir string,
+	timeService clock.Clock,
+) History {
+	return NewHistory(
+		logger,
+		fs,
+		path.Join(dir, "task_history.json"),
+		timeService,
+		DefaultHistoryMaxAge,
+		DefaultHistoryMaxEntries,
+	)
+}
+
+type concreteHistory struct {
+	logger      boshlog.Logger
+	timeService clock.Clock
+
+	fs          boshsys.FileSystem
+	fsSem       chan func()
+	historyPath string
+
+	maxAge     time.Duration
+	maxEntries int
+
+	// Access to entries and loaded must be synchronized via fsSem
+	entries map[string]HistoryEntry
+	loaded  bool
+}
+
+func NewHistory(
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	historyPath string,
+	timeService clock.Clock,
+	maxAge time.Duration,
+	maxEntries int,
+) History {
+	h := &concreteHistory{
+		logger:      logger,
+		timeService: timeService,
+		fs:          fs,
+		fsSem:       make(chan func()),
+		historyPath: historyPath,
+		maxAge:      maxAge,
+		maxEntries:  maxEntries,
+		entries:     make(map[string]HistoryEntry),
+	}
+
+	go h.processFsFuncs()
+
+	return h
+}
+
+func (h *concreteHistory) Record(task Task) error {
+	entry := HistoryEntry{
+		TaskID:     task.ID,
+		State:      task.State,
+		Value:      task.Value,
+		FinishedAt: h.timeService.Now(),
+	}
+
+	if task.Error != nil {
+		entry.Error = task.Error.Error()
+		entry.ErrorDetails = boshhandler.ErrorDetails(task.Error)
+	}
+
+	// Values that cannot be serialized would make the whole history unreadable
+	if _, err := json.Marshal(entry.Value); err != nil {
+		h.logger.Warn("Task History", "Dropping unserializable value of task #%s: %s", task.ID, err.Error())
+		entry.Value = nil
+	}
+
+	if _, err := json.Marshal(entry.ErrorDetails); err != nil {
+		h.logger.Warn("Task History", "Dropping unserializable error details of task #%s: %s", task.ID, err.Error())
+		entry.ErrorDetails = nil
+	}
+
+	errCh := make(chan error)
+
+	h.fsSem <- func() {
+		h.load()
+		h.entries[entry.TaskID] = entry
+		h.evict()
+		errCh <- h.writeEntries()
+	}
+
+	return <-errCh
+}
+
+func (h *concreteHistory) Find(taskID string) (Task, bool) {
+	entryCh := make(chan HistoryEntry)
+	foundCh := make(chan bool)
+
+	h.fsSem <- func() {
+		h.load()
+		entry, found := h.entries[taskID]
+		entryCh <- entry
+		foundCh <- found
+	}
+
+	entry, found := <-entryCh, <-foundCh
+	if !found {
+		return Task{}, false
+	}
+
+	task := Task{
+		ID:    entry.TaskID,
+		State: entry.State,
+		Value: entry.Value,
+	}
+
+	switch {
+	case entry.ErrorDetails != nil:
+		task.Error = historyError{message: entry.Error, details: entry.ErrorDetails}
+	case entry.Error != "":
+		task.Error = errors.New(entry.Error)
+	}
+
+	return task, true
+}
+
+// historyError restores a recorded error together with its details
+type historyError struct {
+	message string
+	details interface{}
+}
+
+func (e historyError) Error() string { return e.message }
+
+func (e historyError) ErrorDetails() interface{} { return e.details }
+
+func (h *concreteHistory) processFsFuncs() {
+	defer h.logger.HandlePanic("Task History Process Fs Funcs")
+
+	for {
+		do := <-h.fsSem
+		do()
+	}
+}
+
+func (h *concreteHistory) load() {
+	if h.loaded {
+		return
+	}
+
+	h.loaded = true
+
+	entries, err := h.readEntries()
+	if err != nil {
+		h.logger.Error("Task History", "Discarding task history: %s", err.Error())
+		return
+	}
+
+	for id, entry := range entries {
+		h.entries[id] = entry
+	}
+
+	h.evict()
+}
+
+func (h *concreteHistory) evict() {
+	cutoff := h.timeService.Now().Add(-h.maxAge)
+
+	for id, entry := range h.entries {
+		if entry.FinishedAt.Before(cutoff) {
+			delete(h.entries, id)
+		}
+	}
+
+	if len(h.entries) <= h.maxEntries {
+		return
+	}
+
+	sorted := make([]HistoryEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		sorted = append(sorted, entry)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FinishedAt.Before(sorted[j].FinishedAt)
+	})
+
+	for _, entry := range sorted[:len(sorted)-h.maxEntries] {
+		delete(h.entries, entry.TaskID)
+	}
+}
+
+func (h *concreteHistory) readEntries() (map[string]HistoryEntry, error) {
+	entries := make(map[string]HistoryEntry)
+
+	if !h.fs.FileExists(h.historyPath) {
+		return entries, nil
+	}
+
+	historyJSON, err := h.fs.ReadFile(h.historyPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading task history json")
+	}
+
+	err = json.Unmarshal(historyJSON, &entries)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling task history json")
+	}
+
+	return entries, nil
+}
+
+func (h *concreteHistory) writeEntries() error {
+	historyJSON, err := json.Marshal(h.entries)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling task history json")
+	}
+
+	err = h.fs.WriteFile(h.historyPath, historyJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing task history json")
+	}
+
+	return nil
+}
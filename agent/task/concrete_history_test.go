@@ -0,0 +1,188 @@
+package task_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+type detailedError struct{}
+
+func (e detailedError) Error() string { return "fake-detailed-error" }
+
+func (e detailedError) ErrorDetails() interface{} {
+	return map[string]string{"fake-key": "fake-value"}
+}
+
+func init() { //nolint:funlen,gochecknoinits
+	Describe("concreteHistoryProvider", func() {
+		Describe("NewHistory", func() {
+			It("returns history with task_history.json as its path", func() {
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				fs := fakesys.NewFakeFileSystem()
+				timeService := fakeclock.NewFakeClock(time.Now())
+
+				history := boshtask.NewHistoryProvider().NewHistory(logger, fs, "/dir/path", timeService)
+				err := history.Record(boshtask.Task{ID: "fake-task-id", State: boshtask.StateDone})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/dir/path/task_history.json")).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("concreteHistory", func() {
+		var (
+			logger      boshlog.Logger
+			fs          *fakesys.FakeFileSystem
+			timeService *fakeclock.FakeClock
+			history     boshtask.History
+		)
+
+		BeforeEach(func() {
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			fs = fakesys.NewFakeFileSystem()
+			timeService = fakeclock.NewFakeClock(time.Now())
+			history = boshtask.NewHistory(logger, fs, "/dir/history.json", timeService, time.Hour, 2)
+		})
+
+		Describe("Record", func() {
+			It("persists state, value and error of finished tasks", func() {
+				err := history.Record(boshtask.Task{
+					ID:    "fake-done-task",
+					State: boshtask.StateDone,
+					Value: map[string]interface{}{"fake-key": "fake-value"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = history.Record(boshtask.Task{
+					ID:    "fake-failed-task",
+					State: boshtask.StateFailed,
+					Error: errors.New("fake-task-error"),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				otherHistory := boshtask.NewHistory(logger, fs, "/dir/history.json", timeService, time.Hour, 2)
+
+				task, found := otherHistory.Find("fake-done-task")
+				Expect(found).To(BeTrue())
+				Expect(task).To(Equal(boshtask.Task{
+					ID:    "fake-done-task",
+					State: boshtask.StateDone,
+					Value: map[string]interface{}{"fake-key": "fake-value"},
+				}))
+
+				task, found = otherHistory.Find("fake-failed-task")
+				Expect(found).To(BeTrue())
+				Expect(task.State).To(Equal(boshtask.StateFailed))
+				Expect(task.Value).To(BeNil())
+				Expect(task.Error).To(MatchError("fake-task-error"))
+			})
+
+			It("persists the details of errors", func() {
+				err := history.Record(boshtask.Task{
+					ID:    "fake-failed-task",
+					State: boshtask.StateFailed,
+					Error: bosherr.WrapError(detailedError{}, "fake-wrap"),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				otherHistory := boshtask.NewHistory(logger, fs, "/dir/history.json", timeService, time.Hour, 2)
+
+				task, found := otherHistory.Find("fake-failed-task")
+				Expect(found).To(BeTrue())
+				Expect(task.Error).To(MatchError("fake-wrap: fake-detailed-error"))
+				Expect(boshhandler.ErrorDetails(task.Error)).To(Equal(map[string]interface{}{"fake-key": "fake-value"}))
+			})
+
+			It("drops values that cannot be serialized", func() {
+				err := history.Record(boshtask.Task{
+					ID:    "fake-task-id",
+					State: boshtask.StateDone,
+					Value: func() {},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				task, found := history.Find("fake-task-id")
+				Expect(found).To(BeTrue())
+				Expect(task.Value).To(BeNil())
+			})
+
+			It("evicts the oldest entries once the maximum number of entries is exceeded", func() {
+				for _, id := range []string{"fake-task-1", "fake-task-2", "fake-task-3"} {
+					err := history.Record(boshtask.Task{ID: id, State: boshtask.StateDone})
+					Expect(err).ToNot(HaveOccurred())
+					timeService.Increment(time.Second)
+				}
+
+				_, found := history.Find("fake-task-1")
+				Expect(found).To(BeFalse())
+
+				_, found = history.Find("fake-task-2")
+				Expect(found).To(BeTrue())
+
+				_, found = history.Find("fake-task-3")
+				Expect(found).To(BeTrue())
+			})
+
+			It("evicts entries older than the maximum age", func() {
+				err := history.Record(boshtask.Task{ID: "fake-old-task", State: boshtask.StateDone})
+				Expect(err).ToNot(HaveOccurred())
+
+				timeService.Increment(2 * time.Hour)
+
+				err = history.Record(boshtask.Task{ID: "fake-new-task", State: boshtask.StateDone})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, found := history.Find("fake-old-task")
+				Expect(found).To(BeFalse())
+
+				_, found = history.Find("fake-new-task")
+				Expect(found).To(BeTrue())
+			})
+
+			It("returns an error when writing the history fails", func() {
+				fs.WriteFileError = errors.New("fake-write-error")
+
+				err := history.Record(boshtask.Task{ID: "fake-task-id", State: boshtask.StateDone})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+			})
+		})
+
+		Describe("Find", func() {
+			It("returns false for unknown tasks", func() {
+				_, found := history.Find("fake-unknown-task")
+				Expect(found).To(BeFalse())
+			})
+
+			It("starts with an empty history when the history file is corrupt", func() {
+				err := fs.WriteFileString("/dir/history.json", "{not-json")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, found := history.Find("fake-task-id")
+				Expect(found).To(BeFalse())
+			})
+
+			It("evicts expired entries when loading the history", func() {
+				err := history.Record(boshtask.Task{ID: "fake-task-id", State: boshtask.StateDone})
+				Expect(err).ToNot(HaveOccurred())
+
+				timeService.Increment(2 * time.Hour)
+
+				otherHistory := boshtask.NewHistory(logger, fs, "/dir/history.json", timeService, time.Hour, 2)
+				_, found := otherHistory.Find("fake-task-id")
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+}
@@ -0,0 +1,28 @@
+package task
+
+// ConcurrencyClass groups tasks that share a limit on how many
+// of them may run at the same time
+type ConcurrencyClass string
+
+const (
+	// ConcurrencyClassSerial is used for tasks without an explicit class
+	ConcurrencyClassSerial   ConcurrencyClass = "serial"
+	ConcurrencyClassParallel ConcurrencyClass = "parallel"
+	ConcurrencyClassCompile  ConcurrencyClass = "compile"
+)
+
+// ConcurrencyLimits maps a class to the maximum number of its tasks
+// running at once. Classes without a positive limit are unbounded.
+type ConcurrencyLimits map[ConcurrencyClass]int
+
+func DefaultConcurrencyLimits() ConcurrencyLimits {
+	return ConcurrencyLimits{
+		ConcurrencyClassSerial:  1,
+		ConcurrencyClassCompile: 1,
+	}
+}
+
+func (l ConcurrencyLimits) allows(class ConcurrencyClass, running int) bool {
+	limit := l[class]
+	return limit <= 0 || running < limit
+}
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultHistoryMaxAge     = 24 * time.Hour
+	DefaultHistoryMaxEntries = 500
+)
+
+type HistoryEntry struct {
+	TaskID string      `json:"task_id"`
+	State  State       `json:"state"`
+	Value  interface{} `json:"value,omitempty"`
+	Error  string      `json:"error,omitempty"`
+
+	// ErrorDetails keeps the structured details of a failed task's error
+	ErrorDetails interface{} `json:"error_details,omitempty"`
+
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type HistoryProvider interface {
+	NewHistory(boshlog.Logger, boshsys.FileSystem, string, clock.Clock) History
+}
+
+// History keeps the outcome of finished tasks so that they
+// can still be looked up after the agent restarts
+type History interface {
+	Record(task Task) error
+	Find(taskID string) (Task, bool)
+}
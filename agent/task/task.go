@@ -1,11 +1,17 @@
 package task
 
+import (
+	"time"
+)
+
 type Func func() (value interface{}, err error)
 
 type CancelFunc func(task Task) error
 
 type EndFunc func(task Task)
 
+type ProgressFunc func() interface{}
+
 type State string
 
 const (
@@ -20,9 +26,19 @@ type Task struct {
 	Value interface{}
 	Error error
 
-	Func       Func
-	CancelFunc CancelFunc
-	EndFunc    EndFunc
+	ConcurrencyClass ConcurrencyClass
+
+	QueuedAt  time.Time
+	StartedAt time.Time
+
+	// Only populated when looking up a task that has not finished
+	QueuePosition int
+	QueueWait     time.Duration
+
+	Func         Func
+	CancelFunc   CancelFunc
+	ProgressFunc ProgressFunc
+	EndFunc      EndFunc
 }
 
 func (t Task) Cancel() error {
@@ -32,7 +48,20 @@ func (t Task) Cancel() error {
 	return nil
 }
 
+// Progress describes how far a running task has come, if its action reports it
+func (t Task) Progress() interface{} {
+	if t.ProgressFunc != nil {
+		return t.ProgressFunc()
+	}
+	return nil
+}
+
 type StateValue struct {
 	AgentTaskID string `json:"agent_task_id"`
 	State       State  `json:"state"`
+
+	QueuePosition    int     `json:"queue_position,omitempty"`
+	QueueWaitSeconds float64 `json:"queue_wait_seconds,omitempty"`
+
+	Progress interface{} `json:"progress,omitempty"`
 }
@@ -11,6 +11,7 @@ import (
 
 	boshagent "github.com/cloudfoundry/bosh-agent/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
@@ -28,8 +29,10 @@ import (
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshmbus "github.com/cloudfoundry/bosh-agent/mbus"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshsigar "github.com/cloudfoundry/bosh-agent/sigar"
@@ -48,12 +51,13 @@ type App interface {
 }
 
 type app struct {
-	logger      boshlog.Logger
-	agent       boshagent.Agent
-	platform    boshplatform.Platform
-	fs          boshsys.FileSystem
-	logTag      string
-	dirProvider boshdirs.Provider
+	logger        boshlog.Logger
+	agent         boshagent.Agent
+	platform      boshplatform.Platform
+	fs            boshsys.FileSystem
+	logTag        string
+	dirProvider   boshdirs.Provider
+	metricsServer boshmetrics.Server
 }
 
 func New(logger boshlog.Logger, fs boshsys.FileSystem) App {
@@ -73,7 +77,7 @@ func (app *app) Setup(opts Options) error {
 	app.dirProvider = boshdirs.NewProvider(opts.BaseDirectory)
 	app.logStemcellInfo()
 
-	statsCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
+	statsCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{}, app.fs, app.logger)
 	auditLoggerProvider := boshplatform.NewAuditLoggerProvider()
 	auditLogger := boshplatform.NewDelayedAuditLogger(auditLoggerProvider, app.logger)
 
@@ -109,11 +113,21 @@ func (app *app) Setup(opts Options) error {
 		specFilePath,
 	)
 
+	freezeWatchdog := boshaction.NewFreezeWatchdog(
+		app.platform,
+		app.platform.GetFs(),
+		filepath.Join(app.dirProvider.BoshDir(), "freeze_deadlines.json"),
+		timeService,
+		app.logger,
+	)
+
 	boot := boshagent.NewBootstrap(
 		app.platform,
 		app.dirProvider,
 		settingsService,
 		specService,
+		boshcgroup.NewManager(app.platform.GetFs(), boshcgroup.DefaultMountPoint),
+		freezeWatchdog,
 		app.logger,
 	)
 
@@ -161,6 +175,7 @@ func (app *app) Setup(opts Options) error {
 		app.logger,
 		app.dirProvider,
 		mbusHandler,
+		config.JobSupervisor,
 	)
 
 	jobSupervisor, err := jobSupervisorProvider.Get(opts.JobSupervisor)
@@ -186,11 +201,25 @@ func (app *app) Setup(opts Options) error {
 		jobSupervisor,
 		settingsService.GetSettings(),
 		timeService,
+		config.Compiler,
 	)
 
 	uuidGen := boshuuid.NewGenerator()
 
-	taskService := boshtask.NewAsyncTaskService(uuidGen, app.logger)
+	taskHistory := boshtask.NewHistoryProvider().NewHistory(
+		app.logger,
+		app.platform.GetFs(),
+		app.dirProvider.BoshDir(),
+		timeService,
+	)
+
+	taskService := boshtask.NewAsyncTaskService(
+		uuidGen,
+		taskHistory,
+		boshtask.DefaultConcurrencyLimits(),
+		timeService,
+		app.logger,
+	)
 
 	taskManager := boshtask.NewManagerProvider().NewManager(
 		app.logger,
@@ -217,18 +246,22 @@ func (app *app) Setup(opts Options) error {
 		jobSupervisor,
 		specService,
 		jobScriptProvider,
+		freezeWatchdog,
 		app.logger,
 		blobstoreDelegator,
 	)
 
 	actionRunner := boshaction.NewRunner()
 
+	metricsRecorder := boshmetrics.NewRecorder()
+
 	actionDispatcher := boshagent.NewActionDispatcher(
 		app.logger,
 		taskService,
 		taskManager,
 		actionFactory,
 		actionRunner,
+		metricsRecorder,
 	)
 
 	startManager := bootonce.NewStartManager(
@@ -237,6 +270,24 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider,
 	)
 
+	alertSinksSettings := settingsService.GetSettings().Env.Bosh.Alerts.Sinks
+
+	alertSinks, err := boshalert.NewSinks(alertSinksSettings, timeService, app.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Building alert sinks")
+	}
+
+	var alertSpool *boshalert.Spool
+	if alertSinksSettings.Spool.Enabled {
+		alertSpool = boshalert.NewSpool(
+			app.platform.GetFs(),
+			app.dirProvider.AlertSpoolDir(),
+			alertSinksSettings.Spool.MaxAlerts,
+			timeService,
+			app.logger,
+		)
+	}
+
 	app.agent = boshagent.New(
 		app.logger,
 		mbusHandler,
@@ -249,12 +300,29 @@ func (app *app) Setup(opts Options) error {
 		uuidGen,
 		timeService,
 		startManager,
+		metricsRecorder,
+		boshalert.NewDispatcher(mbusHandler, alertSinks, alertSpool, app.logger),
 	)
 
+	if config.Metrics.Address != "" {
+		app.metricsServer = boshmetrics.NewServer(
+			config.Metrics.Address,
+			boshmetrics.NewHandler(app.platform.GetVitalsService(), jobSupervisor, metricsRecorder, app.logger),
+			app.logger,
+		)
+	}
+
 	return nil
 }
 
 func (app *app) Run() error {
+	// Metrics are optional, so the agent keeps running without them
+	if app.metricsServer != nil {
+		if err := app.metricsServer.Start(); err != nil {
+			app.logger.Error(app.logTag, "Starting metrics server: %s", err.Error())
+		}
+	}
+
 	if err := app.agent.Run(); err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
@@ -271,6 +339,7 @@ func (app *app) buildApplierAndCompiler(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	settings boshsettings.Settings,
 	timeService clock.Clock,
+	compilerOptions boshcomp.Options,
 ) (boshapplier.Applier, boshcomp.Compiler) {
 	fileSystem := app.platform.GetFs()
 
@@ -324,15 +393,40 @@ func (app *app) buildApplierAndCompiler(
 		10*1024, // 10 Kb
 	)
 
+	packageCache := boshcomp.NewFileSystemPackageCache(
+		fileSystem,
+		dirProvider.CompileCacheDir(),
+		compilerOptions.PackageCacheMaxSize,
+	)
+
+	// Dependencies installed for compilation are fetched through the cache
+	compilerBlobstore := boshcomp.NewCachingBlobstore(blobstoreDelegator, packageCache, fileSystem)
+
+	compilerPackageApplierProvider := boshap.NewCompiledPackageApplierProvider(
+		dirProvider.DataDir(),
+		dirProvider.BaseDir(),
+		dirProvider.JobsDir(),
+		"packages",
+		compilerBlobstore,
+		app.platform.GetCompressor(),
+		fileSystem,
+		timeService,
+		app.logger,
+	)
+
 	compiler := boshcomp.NewConcreteCompiler(
 		app.platform.GetCompressor(),
-		blobstoreDelegator,
+		compilerBlobstore,
 		fileSystem,
 		cmdRunner,
 		dirProvider,
-		packageApplierProvider.Root(),
-		packageApplierProvider.RootBundleCollection(),
+		compilerPackageApplierProvider.Root(),
+		compilerPackageApplierProvider.RootBundleCollection(),
+		packageCache,
+		boshcgroup.NewManager(fileSystem, boshcgroup.DefaultMountPoint),
+		compilerOptions.Sandbox,
 		clock.NewClock(),
+		app.logger,
 	)
 
 	return applier, compiler
@@ -172,6 +172,23 @@ func init() { //nolint:funlen,gochecknoinits
 			})
 		})
 
+		Context("when DevicePathResolutionType is 'nvme'", func() {
+			BeforeEach(func() {
+				agentConfJSON = `{
+					"Platform": { "Linux": { "DevicePathResolutionType": "nvme" } },
+					"Infrastructure": { "Settings": { "Sources": [{ "Type": "CDROM", "FileName": "/fake-file-name" }] } }
+				}`
+			})
+
+			It("uses a NVMeDevicePathResolver", func() {
+				err := app.Setup(opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(app.GetPlatform().GetDevicePathResolver()).To(
+					BeAssignableToTypeOf(devicepathresolver.NVMeDevicePathResolver{}))
+			})
+		})
+
 		Context("logging stemcell version and git sha", func() {
 			var (
 				logger                  *loggerfakes.FakeLogger
@@ -3,7 +3,10 @@ package app
 import (
 	"encoding/json"
 
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -12,6 +15,9 @@ import (
 type Config struct {
 	Platform       boshplatform.Options
 	Infrastructure boshinf.Options
+	Metrics        boshmetrics.Options
+	Compiler       boshcomp.Options
+	JobSupervisor  boshjobsuper.Options
 }
 
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
@@ -4,7 +4,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -26,7 +29,9 @@ var _ = Describe("LoadConfigFromPath", func() {
 					"UsePreformattedPersistentDisk": true,
 					"BindMountPersistentDisk": true,
 					"SkipDiskSetup": true,
-					"DevicePathResolutionType": "virtio"
+					"DevicePathResolutionType": "virtio",
+					"AgentReservedMemoryBytes": 268435456,
+					"AgentCPUWeight": 500
 				}
 			},
 			"Infrastructure": {
@@ -63,6 +68,24 @@ var _ = Describe("LoadConfigFromPath", func() {
 				  "UseServerName": true,
 				  "UseRegistry": true
 				}
+			},
+			"Metrics": {
+				"Address": "127.0.0.1:9190"
+			},
+			"Compiler": {
+				"PackageCacheMaxSize": 1073741824,
+				"Sandbox": {
+					"Enabled": true,
+					"MemoryLimitInMB": 2048,
+					"TimeoutInSeconds": 3600,
+					"User": "vcap"
+				}
+			},
+			"JobSupervisor": {
+				"EventSource": "monit-status",
+				"EventPollIntervalInSeconds": 10,
+				"CrashLoopMaxRestarts": 3,
+				"CrashLoopWindowInSeconds": 300
 			}
 		}`)
 		Expect(err).NotTo(HaveOccurred())
@@ -77,6 +100,8 @@ var _ = Describe("LoadConfigFromPath", func() {
 					BindMountPersistentDisk:       true,
 					SkipDiskSetup:                 true,
 					DevicePathResolutionType:      "virtio",
+					AgentReservedMemoryBytes:      268435456,
+					AgentCPUWeight:                500,
 				},
 			},
 			Infrastructure: boshinf.Options{
@@ -109,6 +134,24 @@ var _ = Describe("LoadConfigFromPath", func() {
 					UseRegistry:   true,
 				},
 			},
+			Metrics: boshmetrics.Options{
+				Address: "127.0.0.1:9190",
+			},
+			Compiler: boshcomp.Options{
+				PackageCacheMaxSize: 1073741824,
+				Sandbox: boshcomp.SandboxOptions{
+					Enabled:          true,
+					MemoryLimitInMB:  2048,
+					TimeoutInSeconds: 3600,
+					User:             "vcap",
+				},
+			},
+			JobSupervisor: boshjobsuper.Options{
+				EventSource:                "monit-status",
+				EventPollIntervalInSeconds: 10,
+				CrashLoopMaxRestarts:       3,
+				CrashLoopWindowInSeconds:   300,
+			},
 		}))
 	})
 
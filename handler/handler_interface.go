@@ -1,5 +1,13 @@
 package handler
 
+import (
+	"errors"
+)
+
+// ErrSendNotSupported is returned by handlers that cannot push messages,
+// e.g. because the director only ever connects to the agent
+var ErrSendNotSupported = errors.New("Sending messages is not supported by the message bus handler")
+
 type Func func(req Request) (resp Response)
 
 type Handler interface {
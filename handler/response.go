@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -22,9 +24,17 @@ func (r valueResponse) Shorten() Response {
 	return r
 }
 
+// DetailedError is implemented by errors that carry structured
+// information for the director in addition to their message
+type DetailedError interface {
+	error
+	ErrorDetails() interface{}
+}
+
 type exceptionResponse struct {
 	Exception struct {
-		Message string `json:"message,omitempty"`
+		Message string      `json:"message,omitempty"`
+		Details interface{} `json:"details,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -33,14 +43,34 @@ type exceptionResponse struct {
 func NewExceptionResponse(err error) (resp Response) {
 	r := exceptionResponse{}
 	r.Exception.Message = err.Error()
+	r.Exception.Details = ErrorDetails(err)
 	r.err = err
 	return r
 }
 
+// ErrorDetails returns the details of the first DetailedError found
+// while following the causes of err
+func ErrorDetails(err error) interface{} {
+	for err != nil {
+		if detailedErr, ok := err.(DetailedError); ok {
+			return detailedErr.ErrorDetails()
+		}
+
+		if complexErr, ok := err.(bosherr.ComplexError); ok {
+			err = complexErr.Cause
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+
+	return nil
+}
+
 func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
 		sr.Exception.Message = typedErr.ShortError()
+		sr.Exception.Details = r.Exception.Details
 		sr.err = typedErr
 		return sr
 	}
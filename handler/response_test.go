@@ -2,11 +2,13 @@ package handler_test
 
 import (
 	"errors"
+	"fmt"
 
 	. "github.com/onsi/ginkgo"
 
 	. "github.com/cloudfoundry/bosh-agent/handler"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type testShortError struct {
@@ -22,6 +24,14 @@ func (e *testShortError) ShortError() string {
 	return msg
 }
 
+type testDetailedError struct{}
+
+func (e testDetailedError) Error() string { return "fake-detailed-msg" }
+
+func (e testDetailedError) ErrorDetails() interface{} {
+	return map[string]string{"limit": "memory"}
+}
+
 var _ = Describe("NewValueResponse", func() {
 	It("can be serialized to JSON", func() {
 		resp := NewValueResponse("fake-value")
@@ -91,4 +101,24 @@ var _ = Describe("NewExceptionResponse", func() {
 			)
 		})
 	})
+
+	Context("with error that carries details", func() {
+		It("serializes the details of the error", func() {
+			resp := NewExceptionResponse(testDetailedError{})
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				resp,
+				`{"exception":{"message":"fake-detailed-msg","details":{"limit":"memory"}}}`,
+			)
+		})
+
+		It("serializes the details of a wrapped cause", func() {
+			err := bosherr.WrapError(fmt.Errorf("fake-wrap: %w", testDetailedError{}), "fake-outer")
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				NewExceptionResponse(err).Shorten(),
+				`{"exception":{"message":"fake-outer: fake-wrap: fake-detailed-msg","details":{"limit":"memory"}}}`,
+			)
+		})
+	})
 })
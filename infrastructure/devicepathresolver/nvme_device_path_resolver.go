@@ -0,0 +1,166 @@
+package devicepathresolver
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const nvmeNamespaceGlob = "/sys/class/nvme/nvme*/nvme*n*"
+
+// Matches namespace block devices, but not the per path devices
+// (e.g. nvme0c0n1) that native NVMe multipathing adds
+var nvmeNamespaceNameRegexp = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// NVMeDevicePathResolver resolves device path by matching the disk ID
+// against the serial numbers of NVMe controllers in sysfs,
+// e.g. AWS exposes EBS volume "vol-0abc" as controller serial "vol0abc".
+// A numeric volume ID selects the namespace ID on the matching controller,
+// or on any controller if the disk ID is not set.
+// Disks with neither are resolved by the fallback resolver, e.g. by path.
+type NVMeDevicePathResolver struct {
+	diskWaitTimeout            time.Duration
+	fallbackDevicePathResolver DevicePathResolver
+	fs                         boshsys.FileSystem
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type nvmeNamespace struct {
+	name   string
+	serial string
+	nsid   string
+}
+
+func NewNVMeDevicePathResolver(
+	diskWaitTimeout time.Duration,
+	fallbackDevicePathResolver DevicePathResolver,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) NVMeDevicePathResolver {
+	return NVMeDevicePathResolver{
+		diskWaitTimeout:            diskWaitTimeout,
+		fallbackDevicePathResolver: fallbackDevicePathResolver,
+		fs:                         fs,
+
+		logTag: "nvmeResolver",
+		logger: logger,
+	}
+}
+
+func (nr NVMeDevicePathResolver) GetRealDevicePath(diskSettings boshsettings.DiskSettings) (string, bool, error) {
+	nsid := ""
+	if _, err := strconv.ParseUint(diskSettings.VolumeID, 10, 32); err == nil {
+		nsid = diskSettings.VolumeID
+	}
+
+	if diskSettings.ID == "" && nsid == "" {
+		nr.logger.Debug(nr.logTag, "Using fallback resolver for disk %+v without ID or numeric volume ID", diskSettings)
+
+		realPath, timeout, err := nr.fallbackDevicePathResolver.GetRealDevicePath(diskSettings)
+		if err != nil {
+			return "", timeout, bosherr.WrapError(err, "Resolving device path without NVMe disk ID")
+		}
+
+		return realPath, false, nil
+	}
+
+	stopAfter := time.Now().Add(nr.diskWaitTimeout)
+
+	for {
+		if time.Now().After(stopAfter) {
+			return "", true, bosherr.Errorf("Timed out getting real device path for NVMe disk '%s' with volume ID '%s'", diskSettings.ID, diskSettings.VolumeID)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		namespaces, err := nr.namespaces()
+		if err != nil {
+			return "", false, err
+		}
+
+		matches := nr.matchingNamespaces(namespaces, diskSettings.ID, nsid)
+
+		switch len(matches) {
+		case 0:
+			nr.logger.Debug(nr.logTag, "Waiting for NVMe namespace to appear")
+			continue
+		case 1:
+			realPath := path.Join("/dev", matches[0].name)
+			if !nr.fs.FileExists(realPath) {
+				continue
+			}
+
+			nr.logger.Debug(nr.logTag, "Found real path %s", realPath)
+			return realPath, false, nil
+		default:
+			return "", false, bosherr.Errorf("More than one NVMe namespace matched disk '%s' with volume ID '%s'", diskSettings.ID, diskSettings.VolumeID)
+		}
+	}
+}
+
+func (nr NVMeDevicePathResolver) namespaces() ([]nvmeNamespace, error) {
+	namespacePaths, err := nr.fs.Glob(nvmeNamespaceGlob)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing NVMe namespaces")
+	}
+
+	var namespaces []nvmeNamespace
+
+	for _, namespacePath := range namespacePaths {
+		name := path.Base(namespacePath)
+		if !nvmeNamespaceNameRegexp.MatchString(name) {
+			continue
+		}
+
+		serial, err := nr.fs.ReadFileString(path.Join(path.Dir(namespacePath), "serial"))
+		if err != nil {
+			nr.logger.Debug(nr.logTag, "Skipping NVMe namespace %s without controller serial: %s", name, err.Error())
+			continue
+		}
+
+		nsid, err := nr.fs.ReadFileString(path.Join(namespacePath, "nsid"))
+		if err != nil {
+			nr.logger.Debug(nr.logTag, "Skipping NVMe namespace %s without namespace ID: %s", name, err.Error())
+			continue
+		}
+
+		namespaces = append(namespaces, nvmeNamespace{
+			name:   name,
+			serial: strings.TrimSpace(serial),
+			nsid:   strings.TrimSpace(nsid),
+		})
+	}
+
+	return namespaces, nil
+}
+
+func (nr NVMeDevicePathResolver) matchingNamespaces(namespaces []nvmeNamespace, diskID, nsid string) []nvmeNamespace {
+	var matches []nvmeNamespace
+
+	for _, namespace := range namespaces {
+		if diskID != "" && normalizeNVMeSerial(namespace.serial) != normalizeNVMeSerial(diskID) {
+			continue
+		}
+
+		if nsid != "" && namespace.nsid != nsid {
+			continue
+		}
+
+		matches = append(matches, namespace)
+	}
+
+	return matches
+}
+
+func normalizeNVMeSerial(serial string) string {
+	return strings.ToLower(strings.ReplaceAll(serial, "-", ""))
+}
@@ -0,0 +1,148 @@
+package devicepathresolver_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	fakedpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("NVMeDevicePathResolver", func() {
+	var (
+		fs               *fakesys.FakeFileSystem
+		fallbackResolver *fakedpresolv.FakeDevicePathResolver
+		diskSettings     boshsettings.DiskSettings
+		pathResolver     NVMeDevicePathResolver
+	)
+
+	addNamespace := func(controller, namespace, serial, nsid string) {
+		controllerPath := "/sys/class/nvme/" + controller
+		Expect(fs.WriteFileString(controllerPath+"/serial", serial+"     \n")).To(Succeed())
+		Expect(fs.WriteFileString(controllerPath+"/"+namespace+"/nsid", nsid+"\n")).To(Succeed())
+		Expect(fs.WriteFileString("/dev/"+namespace, "")).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		diskSettings = boshsettings.DiskSettings{ID: "vol-0123456789abcdef0", VolumeID: "/dev/sdf"}
+
+		addNamespace("nvme0", "nvme0n1", "vol0fedcba9876543210", "1")
+		addNamespace("nvme1", "nvme1n1", "vol0123456789abcdef0", "1")
+		addNamespace("nvme2", "nvme2n1", "fake-shared-serial", "1")
+		addNamespace("nvme2", "nvme2n2", "fake-shared-serial", "2")
+
+		fs.SetGlob("/sys/class/nvme/nvme*/nvme*n*", []string{
+			"/sys/class/nvme/nvme0/nvme0n1",
+			"/sys/class/nvme/nvme1/nvme1c1n1",
+			"/sys/class/nvme/nvme1/nvme1n1",
+			"/sys/class/nvme/nvme2/nvme2n1",
+			"/sys/class/nvme/nvme2/nvme2n2",
+		})
+
+		fallbackResolver = fakedpresolv.NewFakeDevicePathResolver()
+		pathResolver = NewNVMeDevicePathResolver(500*time.Millisecond, fallbackResolver, fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("GetRealDevicePath", func() {
+		It("returns the namespace of the controller whose serial number matches the disk ID", func() {
+			realPath, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timedOut).To(BeFalse())
+			Expect(realPath).To(Equal("/dev/nvme1n1"))
+		})
+
+		It("selects the namespace by a numeric volume ID", func() {
+			diskSettings = boshsettings.DiskSettings{ID: "fake-shared-serial", VolumeID: "2"}
+
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/nvme2n2"))
+		})
+
+		It("matches namespaces on all controllers by volume ID when the disk ID is not set", func() {
+			diskSettings = boshsettings.DiskSettings{VolumeID: "2"}
+
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/nvme2n2"))
+		})
+
+		It("waits for the namespace to show up", func() {
+			fs.SetGlob("/sys/class/nvme/nvme*/nvme*n*",
+				[]string{},
+				[]string{"/sys/class/nvme/nvme1/nvme1n1"},
+			)
+
+			realPath, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(realPath).To(Equal("/dev/nvme1n1"))
+		})
+
+		It("times out if no namespace matches", func() {
+			diskSettings.ID = "vol-fake"
+
+			_, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out getting real device path for NVMe disk 'vol-fake'"))
+			Expect(timedOut).To(BeTrue())
+		})
+
+		It("times out if the device of the matching namespace does not exist", func() {
+			Expect(fs.RemoveAll("/dev/nvme1n1")).To(Succeed())
+
+			_, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(timedOut).To(BeTrue())
+		})
+
+		It("returns an error if more than one namespace matches", func() {
+			diskSettings = boshsettings.DiskSettings{ID: "fake-shared-serial"}
+
+			_, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("More than one NVMe namespace matched"))
+			Expect(timedOut).To(BeFalse())
+		})
+
+		Context("when neither the disk ID nor a numeric volume ID is set", func() {
+			BeforeEach(func() {
+				diskSettings = boshsettings.DiskSettings{VolumeID: "/dev/sdf", Path: "/dev/sdf"}
+			})
+
+			It("resolves the disk with the fallback resolver", func() {
+				fallbackResolver.RealDevicePath = "/dev/xvdf"
+
+				realPath, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(realPath).To(Equal("/dev/xvdf"))
+				Expect(timedOut).To(BeFalse())
+				Expect(fallbackResolver.GetRealDevicePathDiskSettings).To(Equal(diskSettings))
+			})
+
+			It("returns an error if the fallback resolver fails", func() {
+				fallbackResolver.GetRealDevicePathErr = errors.New("fake-fallback-err")
+				fallbackResolver.GetRealDevicePathTimedOut = true
+
+				_, timedOut, err := pathResolver.GetRealDevicePath(diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-fallback-err"))
+				Expect(timedOut).To(BeTrue())
+			})
+		})
+
+		It("returns an error if listing the namespaces fails", func() {
+			fs.GlobErr = errors.New("fake-glob-err")
+
+			_, _, err := pathResolver.GetRealDevicePath(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-glob-err"))
+		})
+	})
+})
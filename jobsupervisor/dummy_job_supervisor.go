@@ -1,5 +1,9 @@
 package jobsupervisor
 
+import (
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+)
+
 type dummyJobSupervisor struct {
 	status    string
 	processes []Process
@@ -41,7 +45,11 @@ func (s *dummyJobSupervisor) Processes() ([]Process, error) {
 	return s.processes, nil
 }
 
-func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+func (s *dummyJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	return map[string]boshcgroup.Stats{}, nil
+}
+
+func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
 	return nil
 }
 
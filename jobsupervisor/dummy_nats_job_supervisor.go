@@ -5,6 +5,7 @@ import (
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	bosherror "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -70,7 +71,7 @@ func (d *dummyNatsJobSupervisor) Reload() error {
 	return nil
 }
 
-func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
 	return nil
 }
 
@@ -111,6 +112,10 @@ func (d *dummyNatsJobSupervisor) Processes() ([]Process, error) {
 	return d.processes, nil
 }
 
+func (d *dummyNatsJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	return map[string]boshcgroup.Stats{}, nil
+}
+
 func (d *dummyNatsJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	d.jobFailureHandler = handler
 
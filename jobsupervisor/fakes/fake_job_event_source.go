@@ -0,0 +1,21 @@
+package fakes
+
+import (
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+)
+
+type FakeJobEventSource struct {
+	Alerts []boshalert.MonitAlert
+	RunErr error
+}
+
+func (s *FakeJobEventSource) Run(handler boshjobsuper.JobFailureHandler) error {
+	for _, alert := range s.Alerts {
+		err := handler(alert)
+		if err != nil {
+			return err
+		}
+	}
+	return s.RunErr
+}
@@ -5,6 +5,7 @@ import (
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 )
 
 type FakeJobSupervisor struct {
@@ -30,6 +31,10 @@ type FakeJobSupervisor struct {
 	ProcessesStatus []boshjobsuper.Process
 	ProcessesError  error
 
+	JobResourcesStatus map[string]boshcgroup.Stats
+	JobResourcesErr    error
+	JobResourcesStub   func() (map[string]boshcgroup.Stats, error)
+
 	JobFailureAlert *boshalert.MonitAlert
 
 	HealthRecorded      int
@@ -40,6 +45,7 @@ type AddJobArgs struct {
 	Name       string
 	Index      int
 	ConfigPath string
+	Resources  boshcgroup.Resources
 }
 
 func NewFakeJobSupervisor() *FakeJobSupervisor {
@@ -55,11 +61,12 @@ func (m *FakeJobSupervisor) Reload() error {
 	return m.ReloadErr
 }
 
-func (m *FakeJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+func (m *FakeJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
 	args := AddJobArgs{
 		Name:       jobName,
 		Index:      jobIndex,
 		ConfigPath: configPath,
+		Resources:  resources,
 	}
 	m.AddJobArgs = append(m.AddJobArgs, args)
 	return nil
@@ -99,6 +106,13 @@ func (m *FakeJobSupervisor) Processes() ([]boshjobsuper.Process, error) {
 	return m.ProcessesStatus, m.ProcessesError
 }
 
+func (m *FakeJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	if m.JobResourcesStub != nil {
+		return m.JobResourcesStub()
+	}
+	return m.JobResourcesStatus, m.JobResourcesErr
+}
+
 func (m *FakeJobSupervisor) MonitorJobFailures(handler boshjobsuper.JobFailureHandler) error {
 	if m.JobFailureAlert != nil {
 		return handler(*m.JobFailureAlert)
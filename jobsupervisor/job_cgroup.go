@@ -0,0 +1,149 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// JobsCgroup is the parent of the cgroups jobs are run in, relative to
+// the root of the cgroup hierarchy
+const JobsCgroup = "bosh-jobs"
+
+// joinCgroupScript is run by monit as root so that it can move itself
+// into the given cgroup directories before executing the command after
+// `--` as the user and group given with --user and --group. A directory
+// that cannot be joined, e.g. when the cgroup was removed by hand,
+// is reported to syslog and the root of its hierarchy is joined instead
+// so that the job neither runs in the agent's cgroup, which monit is in,
+// nor fails to start. The OOM score adjustment inherited from monit,
+// which is protected like the agent, is reset.
+const joinCgroupScript = `#!/bin/sh
+warn() {
+  logger -p user.warning -t join_cgroup "$*" 2>/dev/null
+  echo "join_cgroup: $*" >&2
+}
+
+user=""
+group=""
+while [ "$1" = "--user" ] || [ "$1" = "--group" ]; do
+  case "$1" in
+    --user) user="$2" ;;
+    --group) group="$2" ;;
+  esac
+  shift 2
+done
+
+echo 0 > /proc/self/oom_score_adj 2>/dev/null
+while [ $# -gt 0 ] && [ "$1" != "--" ]; do
+  mkdir -p "$1" 2>/dev/null
+  if ! { echo $$ > "$1/cgroup.procs"; } 2>/dev/null; then
+    warn "Could not join cgroup $1, running in the root cgroup instead"
+    { echo $$ > "${1%/` + JobsCgroup + `/*}/cgroup.procs"; } 2>/dev/null || warn "Could not leave the cgroup of monit"
+  fi
+  shift
+done
+shift
+
+if [ -n "$user" ]; then
+  exec setpriv --reuid "$user" --regid "${group:-$(id -gn "$user")}" --init-groups "$@"
+elif [ -n "$group" ]; then
+  exec setpriv --regid "$group" --clear-groups "$@"
+fi
+exec "$@"
+`
+
+// monitStartProgramRegexp matches start programs together with the user
+// and group monit would run them as
+var monitStartProgramRegexp = regexp.MustCompile(`(?i)(start\s+program\s*=?\s*)(?:"([^"]*)"|'([^']*)')(?:\s+(?:as\s+)?uid\s+(\S+))?(?:\s+(?:and\s+|as\s+)?gid\s+(\S+))?`)
+
+const jobCgroupResourcesSuffix = ".cgroup.json"
+
+func JobCgroupPath(jobName string) string {
+	return path.Join(JobsCgroup, jobName)
+}
+
+// jobCgroupResourcesPath keeps the limits of a job's cgroup next to its
+// monit control file, <index>_<job>.monitrc
+func jobCgroupResourcesPath(configPath string) string {
+	return strings.TrimSuffix(configPath, ".monitrc") + jobCgroupResourcesSuffix
+}
+
+// RestoreJobCgroups re-creates the cgroups of the jobs added to monit with
+// their limits. Cgroups do not survive a reboot, so they need to be
+// restored before monit starts the jobs.
+func RestoreJobCgroups(fs boshsys.FileSystem, monitJobsDir string, cgroupManager boshcgroup.Manager) error {
+	if cgroupManager.Version() == boshcgroup.VersionUnsupported {
+		return nil
+	}
+
+	resourcesPaths, err := fs.Glob(path.Join(monitJobsDir, "*"+jobCgroupResourcesSuffix))
+	if err != nil {
+		return bosherr.WrapError(err, "Finding job cgroup resources")
+	}
+
+	for _, resourcesPath := range resourcesPaths {
+		_, jobName, found := strings.Cut(strings.TrimSuffix(path.Base(resourcesPath), jobCgroupResourcesSuffix), "_")
+		if !found {
+			continue
+		}
+
+		resourcesJSON, err := fs.ReadFile(resourcesPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading cgroup resources of job %s", jobName)
+		}
+
+		var resources boshcgroup.Resources
+
+		err = json.Unmarshal(resourcesJSON, &resources)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling cgroup resources of job %s", jobName)
+		}
+
+		_, err = cgroupManager.Create(JobCgroupPath(jobName), resources)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Restoring cgroup of job %s", jobName)
+		}
+	}
+
+	return nil
+}
+
+// joinCgroupStartPrograms prefixes the start programs in a monit control
+// file with scriptPath so that the processes and their children are
+// started in the cgroup with procsFiles. Monit runs the start programs as
+// root, which is needed to join the cgroup, and the script switches to the
+// user and group that were configured for them.
+func joinCgroupStartPrograms(config, scriptPath string, procsFiles []string) string {
+	dirs := []string{}
+	for _, procsFile := range procsFiles {
+		dirs = append(dirs, filepath.Dir(procsFile))
+	}
+
+	return monitStartProgramRegexp.ReplaceAllStringFunc(config, func(statement string) string {
+		match := monitStartProgramRegexp.FindStringSubmatch(statement)
+
+		quote, command := `"`, match[2]
+		if strings.HasPrefix(statement[len(match[1]):], "'") {
+			quote, command = "'", match[3]
+		}
+
+		prefix := []string{scriptPath}
+		if match[4] != "" {
+			prefix = append(prefix, "--user", match[4])
+		}
+		if match[5] != "" {
+			prefix = append(prefix, "--group", match[5])
+		}
+		prefix = append(prefix, dirs...)
+		prefix = append(prefix, "--")
+
+		return match[1] + quote + strings.Join(prefix, " ") + " " + command + quote
+	})
+}
@@ -0,0 +1,55 @@
+package jobsupervisor
+
+import (
+	"fmt"
+
+	"github.com/pivotal/go-smtpd/smtpd"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	JobEventSourceSMTP        = "smtp"
+	JobEventSourceMonitStatus = "monit-status"
+	JobEventSourceSystemd     = "systemd"
+)
+
+// JobEventSource reports job events, e.g. processes failing, to a handler.
+// Run blocks while events are being watched for.
+type JobEventSource interface {
+	Run(handler JobFailureHandler) error
+}
+
+type smtpJobEventSource struct {
+	port int
+}
+
+// NewSMTPJobEventSource receives the alert emails monit sends to
+// 127.0.0.1 on port
+func NewSMTPJobEventSource(port int) JobEventSource {
+	return smtpJobEventSource{port: port}
+}
+
+func (s smtpJobEventSource) Run(handler JobFailureHandler) error {
+	alertHandler := func(smtpd.Connection, smtpd.MailAddress) (env smtpd.Envelope, err error) {
+		env = &alertEnvelope{
+			new(smtpd.BasicEnvelope),
+			handler,
+			new(boshalert.MonitAlert),
+		}
+		return
+	}
+
+	serv := &smtpd.Server{
+		Addr:      fmt.Sprintf("127.0.0.1:%d", s.port),
+		OnNewMail: alertHandler,
+	}
+
+	err := serv.ListenAndServe()
+	if err != nil {
+		return bosherr.WrapError(err, "Listen for SMTP")
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	JobEventSourceJobResources = "job-resources"
+
+	JobEventOOMKilled        = "oom killed"
+	JobEventPidsLimitReached = "pids limit reached"
+)
+
+// NewJobResourceEventSource polls the resource usage of supervisor's jobs
+// and reports jobs running into their resource limits, i.e. processes killed for
+// exceeding the memory limit and forks refused because of the pids limit
+func NewJobResourceEventSource(
+	supervisor JobSupervisor,
+	pollInterval time.Duration,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) JobEventSource {
+	return NewPollingJobEventSource[map[string]boshcgroup.Stats](
+		JobEventSourceJobResources,
+		jobResourcePoller{
+			JobResourceViolations: JobResourceViolations{TimeService: timeService},
+			supervisor:            supervisor,
+		},
+		pollInterval,
+		timeService,
+		logger,
+	)
+}
+
+type jobResourcePoller struct {
+	JobResourceViolations
+	supervisor JobSupervisor
+}
+
+func (p jobResourcePoller) Poll() (map[string]boshcgroup.Stats, error) {
+	return p.supervisor.JobResources()
+}
+
+// JobResourceViolations finds the limits jobs ran into between two polls
+// of their resource usage
+type JobResourceViolations struct {
+	TimeService clock.Clock
+}
+
+func (v JobResourceViolations) Diff(previous, current map[string]boshcgroup.Stats) []boshalert.MonitAlert {
+	alerts := []boshalert.MonitAlert{}
+
+	// Limits reached before the agent started were reported already
+	if previous == nil {
+		return alerts
+	}
+
+	jobNames := make([]string, 0, len(current))
+	for jobName := range current {
+		jobNames = append(jobNames, jobName)
+	}
+	sort.Strings(jobNames)
+
+	alert := func(jobName, event, description string) {
+		alerts = append(alerts, NewJobEvent(v.TimeService, JobEventSourceJobResources, jobName, event, "alert", description))
+	}
+
+	for _, jobName := range jobNames {
+		stats := current[jobName]
+
+		// Counters start over when a job's cgroup is recreated
+		before, found := previous[jobName]
+		if !found {
+			continue
+		}
+
+		if stats.OOMKills > before.OOMKills {
+			alert(jobName, JobEventOOMKilled,
+				fmt.Sprintf("%d processes were killed for exceeding the memory limit", stats.OOMKills-before.OOMKills))
+		}
+
+		if stats.PidsLimitHits > before.PidsLimitHits {
+			alert(jobName, JobEventPidsLimitReached,
+				fmt.Sprintf("%d forks were refused because of the pids limit", stats.PidsLimitHits-before.PidsLimitHits))
+		}
+	}
+
+	return alerts
+}
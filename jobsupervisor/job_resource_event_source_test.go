@@ -0,0 +1,58 @@
+package jobsupervisor_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+)
+
+var _ = Describe("JobResourceViolations", func() {
+	var (
+		diff     func(previous, current map[string]boshcgroup.Stats) []boshalert.MonitAlert
+		previous map[string]boshcgroup.Stats
+	)
+
+	BeforeEach(func() {
+		diff = JobResourceViolations{TimeService: fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 10, 0, time.UTC))}.Diff
+		previous = map[string]boshcgroup.Stats{"router": {OOMKills: 2, PidsLimitHits: 1}}
+	})
+
+	It("does not report limits reached before it started", func() {
+		Expect(diff(nil, previous)).To(BeEmpty())
+		Expect(diff(previous, map[string]boshcgroup.Stats{"router": {OOMKills: 2, PidsLimitHits: 1}})).To(BeEmpty())
+	})
+
+	It("reports processes killed for exceeding the memory limit", func() {
+		Expect(diff(previous, map[string]boshcgroup.Stats{"router": {OOMKills: 5, PidsLimitHits: 1}})).To(Equal([]boshalert.MonitAlert{{
+			ID:          "1767323050000000000.router@job-resources",
+			Service:     "router",
+			Event:       "oom killed",
+			Action:      "alert",
+			Date:        "Fri, 02 Jan 2026 03:04:10 +0000",
+			Description: "3 processes were killed for exceeding the memory limit",
+		}}))
+	})
+
+	It("reports forks refused because of the pids limit", func() {
+		alerts := diff(previous, map[string]boshcgroup.Stats{"router": {OOMKills: 2, PidsLimitHits: 4}})
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Event).To(Equal("pids limit reached"))
+		Expect(alerts[0].Description).To(Equal("3 forks were refused because of the pids limit"))
+	})
+
+	It("does not report jobs whose counters started over or that were just added", func() {
+		current := map[string]boshcgroup.Stats{"router": {}, "nats": {OOMKills: 1}}
+		Expect(diff(previous, current)).To(BeEmpty())
+
+		alerts := diff(current, map[string]boshcgroup.Stats{"router": {OOMKills: 1}, "nats": {OOMKills: 1}})
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Service).To(Equal("router"))
+		Expect(alerts[0].Event).To(Equal("oom killed"))
+	})
+})
@@ -2,6 +2,7 @@ package jobsupervisor
 
 import (
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 )
 
 type Process struct {
@@ -10,6 +11,11 @@ type Process struct {
 	Uptime UptimeVitals `json:"uptime,omitempty"`
 	Memory MemoryVitals `json:"mem,omitempty"`
 	CPU    CPUVitals    `json:"cpu,omitempty"`
+
+	Restarts     int                 `json:"restarts,omitempty"`
+	LastExitAt   string              `json:"last_exit_at,omitempty"`
+	CrashLooping bool                `json:"crash_looping,omitempty"`
+	History      []ProcessTransition `json:"history,omitempty"`
 }
 
 type UptimeVitals struct {
@@ -43,8 +49,12 @@ type JobSupervisor interface {
 
 	Status() string
 	Processes() ([]Process, error)
+
+	// JobResources reports the resource usage of each job's cgroup
+	JobResources() (map[string]boshcgroup.Stats, error)
+
 	// Job management
-	AddJob(jobName string, jobIndex int, configPath string) error
+	AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error
 	RemoveAllJobs() error
 
 	MonitorJobFailures(handler JobFailureHandler) error
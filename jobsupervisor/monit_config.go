@@ -0,0 +1,208 @@
+package jobsupervisor
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// MonitProcess is a `check process` entry of a job's monit file
+type MonitProcess struct {
+	Name     string
+	PidFile  string
+	Matching string
+
+	Start MonitProgram
+	Stop  MonitProgram
+
+	Groups    []string
+	DependsOn []string
+}
+
+type MonitProgram struct {
+	Command string
+	UID     string
+	GID     string
+
+	TimeoutInSeconds int
+}
+
+// ParseMonitConfig extracts the processes from monit control file content.
+// Only the statements needed to run the processes elsewhere are understood;
+// other checks and resource tests are skipped.
+func ParseMonitConfig(content string) ([]MonitProcess, error) {
+	tokens, err := tokenizeMonitConfig(content)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := []MonitProcess{}
+
+	var process *MonitProcess
+	var program *MonitProgram
+
+	next := func(i int) string {
+		if i+1 < len(tokens) {
+			return tokens[i+1]
+		}
+		return ""
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := strings.ToLower(tokens[i])
+
+		if token == "check" {
+			if process != nil {
+				processes = append(processes, *process)
+			}
+			process, program = nil, nil
+
+			if i+2 >= len(tokens) {
+				return nil, bosherr.Error("Incomplete check statement")
+			}
+
+			if strings.ToLower(tokens[i+1]) == "process" {
+				process = &MonitProcess{Name: tokens[i+2]}
+			}
+
+			i += 2
+			continue
+		}
+
+		if process == nil {
+			continue
+		}
+
+		switch token {
+		case "pidfile":
+			process.PidFile = next(i)
+			program = nil
+			i++
+
+		case "matching":
+			process.Matching = next(i)
+			program = nil
+			i++
+
+		case "start", "stop":
+			// Actions of resource tests, e.g. `if ... then stop`
+			if i > 0 && strings.ToLower(tokens[i-1]) == "then" {
+				continue
+			}
+
+			program = &process.Start
+			if token == "stop" {
+				program = &process.Stop
+			}
+
+			for i+1 < len(tokens) && (strings.ToLower(tokens[i+1]) == "program" || tokens[i+1] == "=") {
+				i++
+			}
+
+			program.Command = next(i)
+			i++
+
+		case "uid", "gid":
+			if program != nil {
+				if token == "uid" {
+					program.UID = next(i)
+				} else {
+					program.GID = next(i)
+				}
+				i++
+			}
+
+		case "timeout":
+			if program != nil {
+				timeout, err := strconv.Atoi(next(i))
+				if err == nil {
+					program.TimeoutInSeconds = timeout
+					i++
+				}
+			}
+
+		case "group":
+			process.Groups = append(process.Groups, next(i))
+			program = nil
+			i++
+
+		case "depends":
+			program = nil
+
+			if strings.ToLower(next(i)) == "on" {
+				i++
+			}
+
+			for i+1 < len(tokens) {
+				process.DependsOn = append(process.DependsOn, tokens[i+1])
+				i++
+
+				if next(i) != "," {
+					break
+				}
+				i++
+			}
+
+		case "if", "mode", "every":
+			program = nil
+		}
+	}
+
+	if process != nil {
+		processes = append(processes, *process)
+	}
+
+	for _, p := range processes {
+		if p.Start.Command == "" {
+			return nil, bosherr.Errorf("Process '%s' does not have a start program", p.Name)
+		}
+	}
+
+	return processes, nil
+}
+
+func tokenizeMonitConfig(content string) ([]string, error) {
+	tokens := []string{}
+
+	runes := []rune(content)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == ',':
+			tokens = append(tokens, ",")
+
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, bosherr.Errorf("Unterminated quoted string starting with %s", string(runes[i:]))
+			}
+
+			tokens = append(tokens, string(runes[i+1:end]))
+			i = end
+
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != ',' {
+				end++
+			}
+
+			tokens = append(tokens, string(runes[i:end]))
+			i = end - 1
+		}
+	}
+
+	return tokens, nil
+}
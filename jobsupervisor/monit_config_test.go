@@ -0,0 +1,69 @@
+package jobsupervisor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+)
+
+var _ = Describe("ParseMonitConfig", func() {
+	It("parses check process statements", func() {
+		processes, err := ParseMonitConfig(`
+# managed by bpm
+check process nats
+  with pidfile /var/vcap/sys/run/bpm/nats/nats.pid
+  start program "/var/vcap/jobs/bpm/bin/bpm start nats"
+    as uid vcap and gid vcap
+    with timeout 60 seconds
+  stop program "/var/vcap/jobs/bpm/bin/bpm stop nats"
+  group vcap
+  depends on nats_tls, syslog
+  if totalmem > 1 GB for 5 cycles then stop
+
+check file nats.log with path /var/vcap/sys/log/nats/nats.log
+  if size > 100 MB then alert
+
+check process nats_tls
+  matching 'nats-tls --config'
+  start program = "/var/vcap/jobs/nats/bin/tls_ctl start"
+  stop program = "/var/vcap/jobs/nats/bin/tls_ctl stop"
+  group vcap
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processes).To(Equal([]MonitProcess{
+			{
+				Name:    "nats",
+				PidFile: "/var/vcap/sys/run/bpm/nats/nats.pid",
+				Start: MonitProgram{
+					Command:          "/var/vcap/jobs/bpm/bin/bpm start nats",
+					UID:              "vcap",
+					GID:              "vcap",
+					TimeoutInSeconds: 60,
+				},
+				Stop:      MonitProgram{Command: "/var/vcap/jobs/bpm/bin/bpm stop nats"},
+				Groups:    []string{"vcap"},
+				DependsOn: []string{"nats_tls", "syslog"},
+			},
+			{
+				Name:     "nats_tls",
+				Matching: "nats-tls --config",
+				Start:    MonitProgram{Command: "/var/vcap/jobs/nats/bin/tls_ctl start"},
+				Stop:     MonitProgram{Command: "/var/vcap/jobs/nats/bin/tls_ctl stop"},
+				Groups:   []string{"vcap"},
+			},
+		}))
+	})
+
+	It("returns an error when a process does not have a start program", func() {
+		_, err := ParseMonitConfig("check process nats with pidfile /nats.pid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Process 'nats' does not have a start program"))
+	})
+
+	It("returns an error for unterminated quotes", func() {
+		_, err := ParseMonitConfig(`check process nats start program "/bin/nats`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unterminated quoted string"))
+	})
+})
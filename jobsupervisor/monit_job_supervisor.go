@@ -1,16 +1,16 @@
 package jobsupervisor
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
 	"strings"
 	"time"
 
 	"code.cloudfoundry.org/clock"
-	"github.com/pivotal/go-smtpd/smtpd"
 
-	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -20,14 +20,15 @@ import (
 const monitJobSupervisorLogTag = "monitJobSupervisor"
 
 type monitJobSupervisor struct {
-	fs                    boshsys.FileSystem
-	runner                boshsys.CmdRunner
-	client                boshmonit.Client
-	logger                boshlog.Logger
-	dirProvider           boshdir.Provider
-	jobFailuresServerPort int
-	reloadOptions         MonitReloadOptions
-	timeService           clock.Clock
+	fs            boshsys.FileSystem
+	runner        boshsys.CmdRunner
+	client        boshmonit.Client
+	logger        boshlog.Logger
+	dirProvider   boshdir.Provider
+	eventSource   JobEventSource
+	cgroupManager boshcgroup.Manager
+	reloadOptions MonitReloadOptions
+	timeService   clock.Clock
 }
 
 type MonitReloadOptions struct {
@@ -48,19 +49,21 @@ func NewMonitJobSupervisor(
 	client boshmonit.Client,
 	logger boshlog.Logger,
 	dirProvider boshdir.Provider,
-	jobFailuresServerPort int,
+	eventSource JobEventSource,
+	cgroupManager boshcgroup.Manager,
 	reloadOptions MonitReloadOptions,
 	timeService clock.Clock,
 ) JobSupervisor {
 	return &monitJobSupervisor{
-		fs:                    fs,
-		runner:                runner,
-		client:                client,
-		logger:                logger,
-		dirProvider:           dirProvider,
-		jobFailuresServerPort: jobFailuresServerPort,
-		reloadOptions:         reloadOptions,
-		timeService:           timeService,
+		fs:            fs,
+		runner:        runner,
+		client:        client,
+		logger:        logger,
+		dirProvider:   dirProvider,
+		eventSource:   eventSource,
+		cgroupManager: cgroupManager,
+		reloadOptions: reloadOptions,
+		timeService:   timeService,
 	}
 }
 
@@ -332,16 +335,30 @@ func (m monitJobSupervisor) getIncarnation() (int, error) {
 	return monitStatus.GetIncarnation()
 }
 
-func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
 	targetFilename := fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName)
 	targetConfigPath := path.Join(m.dirProvider.MonitJobsDir(), targetFilename)
 
-	configContent, err := m.fs.ReadFile(configPath)
+	configContent, err := m.fs.ReadFileString(configPath)
 	if err != nil {
 		return bosherr.WrapError(err, "Reading job config from file")
 	}
 
-	err = m.fs.WriteFile(targetConfigPath, configContent)
+	if m.cgroupManager.Version() != boshcgroup.VersionUnsupported {
+		configContent, err = m.joinJobCgroup(jobName, targetConfigPath, configContent, resources)
+		if err != nil {
+			if resources != (boshcgroup.Resources{}) {
+				return bosherr.WrapErrorf(err, "Limiting resources of job %s", jobName)
+			}
+
+			// Jobs without limits are still run without resource accounting
+			m.logger.Warn(monitJobSupervisorLogTag, "Running job %s in a cgroup: %s", jobName, err.Error())
+		}
+	} else if resources != (boshcgroup.Resources{}) {
+		return bosherr.Errorf("Limiting resources of job %s: cgroups are not supported", jobName)
+	}
+
+	err = m.fs.WriteFileString(targetConfigPath, configContent)
 	if err != nil {
 		return bosherr.WrapError(err, "Writing to job config file")
 	}
@@ -349,30 +366,83 @@ func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
-func (m monitJobSupervisor) RemoveAllJobs() error {
-	return m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
-}
+func (m monitJobSupervisor) joinJobCgroup(jobName, configPath, configContent string, resources boshcgroup.Resources) (string, error) {
+	cg, err := m.cgroupManager.Create(JobCgroupPath(jobName), resources)
+	if err != nil {
+		return configContent, bosherr.WrapError(err, "Creating job cgroup")
+	}
 
-func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err error) {
-	alertHandler := func(smtpd.Connection, smtpd.MailAddress) (env smtpd.Envelope, err error) {
-		env = &alertEnvelope{
-			new(smtpd.BasicEnvelope),
-			handler,
-			new(boshalert.MonitAlert),
-		}
-		return
+	resourcesJSON, err := json.Marshal(resources)
+	if err != nil {
+		return configContent, bosherr.WrapError(err, "Marshalling job cgroup resources")
 	}
 
-	serv := &smtpd.Server{
-		Addr:      fmt.Sprintf("127.0.0.1:%d", m.jobFailuresServerPort),
-		OnNewMail: alertHandler,
+	err = m.fs.WriteFile(jobCgroupResourcesPath(configPath), resourcesJSON)
+	if err != nil {
+		return configContent, bosherr.WrapError(err, "Writing job cgroup resources")
 	}
 
-	err = serv.ListenAndServe()
+	err = m.fs.WriteFileString(m.joinCgroupScriptPath(), joinCgroupScript)
 	if err != nil {
-		err = bosherr.WrapError(err, "Listen for SMTP")
+		return configContent, bosherr.WrapError(err, "Writing join cgroup script")
 	}
-	return
+
+	err = m.fs.Chmod(m.joinCgroupScriptPath(), 0755)
+	if err != nil {
+		return configContent, bosherr.WrapError(err, "Making join cgroup script executable")
+	}
+
+	return joinCgroupStartPrograms(configContent, m.joinCgroupScriptPath(), cg.ProcsFiles()), nil
+}
+
+// JobResources reports the resource usage of the cgroup of each job
+// with a monit control file
+func (m monitJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	resources := map[string]boshcgroup.Stats{}
+
+	if m.cgroupManager.Version() == boshcgroup.VersionUnsupported {
+		return resources, nil
+	}
+
+	configPaths, err := m.fs.Glob(path.Join(m.dirProvider.MonitJobsDir(), "*.monitrc"))
+	if err != nil {
+		return resources, bosherr.WrapError(err, "Finding job configs")
+	}
+
+	for _, configPath := range configPaths {
+		// Job configs are named <index>_<job>.monitrc
+		_, jobName, found := strings.Cut(strings.TrimSuffix(path.Base(configPath), ".monitrc"), "_")
+		if !found {
+			continue
+		}
+
+		cg, err := m.cgroupManager.Get(JobCgroupPath(jobName))
+		if err != nil {
+			return resources, bosherr.WrapErrorf(err, "Getting cgroup of job %s", jobName)
+		}
+
+		stats, err := cg.Stats()
+		if err != nil {
+			m.logger.Debug(monitJobSupervisorLogTag, "Getting resource usage of job %s: %s", jobName, err.Error())
+			continue
+		}
+
+		resources[jobName] = stats
+	}
+
+	return resources, nil
+}
+
+func (m monitJobSupervisor) RemoveAllJobs() error {
+	return m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
+}
+
+func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	return m.eventSource.Run(handler)
+}
+
+func (m monitJobSupervisor) joinCgroupScriptPath() string {
+	return path.Join(m.dirProvider.MonitDir(), "join_cgroup")
 }
 
 func (m monitJobSupervisor) stoppedFilePath() string {
@@ -20,6 +20,8 @@ import (
 	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup/fakes"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -35,6 +37,7 @@ var _ = Describe("monitJobSupervisor", func() {
 		jobFailuresServerPort int
 		monit                 JobSupervisor
 		timeService           *fakeclock.FakeClock
+		cgroupManager         *fakecgroup.FakeManager
 	)
 
 	var jobFailureServerPort = 5000
@@ -55,6 +58,7 @@ var _ = Describe("monitJobSupervisor", func() {
 		dirProvider = boshdir.NewProvider("/var/vcap")
 		jobFailuresServerPort = getJobFailureServerPort()
 		timeService = fakeclock.NewFakeClock(time.Now())
+		cgroupManager = fakecgroup.NewFakeManager()
 
 		monit = NewMonitJobSupervisor(
 			fs,
@@ -62,7 +66,8 @@ var _ = Describe("monitJobSupervisor", func() {
 			client,
 			logger,
 			dirProvider,
-			jobFailuresServerPort,
+			NewSMTPJobEventSource(jobFailuresServerPort),
+			cgroupManager,
 			MonitReloadOptions{
 				MaxTries:               3,
 				MaxCheckTries:          10,
@@ -299,7 +304,8 @@ var _ = Describe("monitJobSupervisor", func() {
 				client,
 				logger,
 				dirProvider,
-				jobFailuresServerPort,
+				NewSMTPJobEventSource(jobFailuresServerPort),
+				cgroupManager,
 				MonitReloadOptions{
 					MaxTries:               3,
 					MaxCheckTries:          10,
@@ -504,7 +510,8 @@ var _ = Describe("monitJobSupervisor", func() {
 					client,
 					logger,
 					dirProvider,
-					jobFailuresServerPort,
+					NewSMTPJobEventSource(jobFailuresServerPort),
+					cgroupManager,
 					MonitReloadOptions{
 						MaxTries:               3,
 						MaxCheckTries:          10,
@@ -559,7 +566,8 @@ var _ = Describe("monitJobSupervisor", func() {
 					client,
 					logger,
 					dirProvider,
-					jobFailuresServerPort,
+					NewSMTPJobEventSource(jobFailuresServerPort),
+					cgroupManager,
 					MonitReloadOptions{},
 					timeService,
 				)
@@ -796,7 +804,7 @@ var _ = Describe("monitJobSupervisor", func() {
 		Context("when reading configuration from config path succeeds", func() {
 			Context("when writing job configuration succeeds", func() {
 				It("returns no error because monit can track added job in jobs directory", func() {
-					err := monit.AddJob("router", 0, "/some/config/path")
+					err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
 					Expect(err).ToNot(HaveOccurred())
 
 					writtenConfig, err := fs.ReadFileString(
@@ -810,24 +818,177 @@ var _ = Describe("monitJobSupervisor", func() {
 				It("returns error", func() {
 					fs.WriteFileError = errors.New("fake-write-error")
 
-					err := monit.AddJob("router", 0, "/some/config/path")
+					err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-write-error"))
 				})
 			})
 		})
 
+		Context("when the job has start programs", func() {
+			BeforeEach(func() {
+				err := fs.WriteFileString("/some/config/path", `check process router
+  with pidfile /var/vcap/sys/run/router/router.pid
+  start program "/var/vcap/jobs/router/bin/router_ctl start"
+  stop program "/var/vcap/jobs/router/bin/router_ctl stop"
+  group vcap
+`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("starts the job's processes in the job's cgroup", func() {
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cgroupManager.Resources).To(HaveKeyWithValue("bosh-jobs/router", boshcgroup.Resources{}))
+
+				writtenConfig, err := fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writtenConfig).To(Equal(`check process router
+  with pidfile /var/vcap/sys/run/router/router.pid
+  start program "/var/vcap/monit/join_cgroup /sys/fs/cgroup/bosh-jobs/router -- /var/vcap/jobs/router/bin/router_ctl start"
+  stop program "/var/vcap/jobs/router/bin/router_ctl stop"
+  group vcap
+`))
+
+				Expect(fs.FileExists("/var/vcap/monit/join_cgroup")).To(BeTrue())
+				Expect(fs.GetFileTestStat("/var/vcap/monit/join_cgroup").FileMode).To(Equal(os.FileMode(0755)))
+			})
+
+			It("starts the processes as root and lets the script switch to their user and group", func() {
+				err := fs.WriteFileString("/some/config/path", `check process router
+  start program "/var/vcap/jobs/router/bin/router_ctl start"
+    as uid vcap and gid vcap
+    with timeout 60 seconds
+  stop program "/var/vcap/jobs/router/bin/router_ctl stop" as uid vcap
+check process router_metrics
+  start program = '/var/vcap/jobs/router/bin/metrics_ctl start' as uid nobody
+`)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				writtenConfig, err := fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writtenConfig).To(Equal(`check process router
+  start program "/var/vcap/monit/join_cgroup --user vcap --group vcap /sys/fs/cgroup/bosh-jobs/router -- /var/vcap/jobs/router/bin/router_ctl start"
+    with timeout 60 seconds
+  stop program "/var/vcap/jobs/router/bin/router_ctl stop" as uid vcap
+check process router_metrics
+  start program = '/var/vcap/monit/join_cgroup --user nobody /sys/fs/cgroup/bosh-jobs/router -- /var/vcap/jobs/router/bin/metrics_ctl start'
+`))
+			})
+
+			It("limits the resources of the job's cgroup", func() {
+				resources := boshcgroup.Resources{CPUWeight: 200, MemoryBytes: 1073741824, PidsMax: 512}
+
+				err := monit.AddJob("router", 0, "/some/config/path", resources)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cgroupManager.Resources).To(HaveKeyWithValue("bosh-jobs/router", resources))
+			})
+
+			It("keeps the limits so that the cgroup can be restored after a reboot", func() {
+				resources := boshcgroup.Resources{MemoryBytes: 1073741824}
+
+				err := monit.AddJob("router", 0, "/some/config/path", resources)
+				Expect(err).ToNot(HaveOccurred())
+
+				fs.SetGlob("/var/vcap/monit/job/*.cgroup.json", []string{"/var/vcap/monit/job/0000_router.cgroup.json"})
+				cgroupManager = fakecgroup.NewFakeManager()
+
+				err = RestoreJobCgroups(fs, dirProvider.MonitJobsDir(), cgroupManager)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cgroupManager.Resources).To(Equal(map[string]boshcgroup.Resources{"bosh-jobs/router": resources}))
+			})
+
+			It("returns an error when limits are requested but cgroups are not available", func() {
+				cgroupManager.VersionValue = boshcgroup.VersionUnsupported
+
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{PidsMax: 512})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Limiting resources of job router: cgroups are not supported"))
+			})
+
+			It("returns an error when limits are requested but the cgroup cannot be created", func() {
+				cgroupManager.CreateErr = errors.New("fake-create-err")
+
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{PidsMax: 512})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
+			})
+
+			It("leaves the start programs as they are when cgroups are not available", func() {
+				cgroupManager.VersionValue = boshcgroup.VersionUnsupported
+
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				writtenConfig, err := fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writtenConfig).ToNot(ContainSubstring("join_cgroup"))
+			})
+
+			It("leaves the start programs as they are when the cgroup cannot be created", func() {
+				cgroupManager.CreateErr = errors.New("fake-create-err")
+
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				writtenConfig, err := fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writtenConfig).ToNot(ContainSubstring("join_cgroup"))
+			})
+		})
+
 		Context("when reading configuration from config path fails", func() {
 			It("returns error", func() {
 				fs.ReadFileError = errors.New("fake-read-error")
 
-				err := monit.AddJob("router", 0, "/some/config/path")
+				err := monit.AddJob("router", 0, "/some/config/path", boshcgroup.Resources{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-read-error"))
 			})
 		})
 	})
 
+	Describe("JobResources", func() {
+		BeforeEach(func() {
+			fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{
+				"/var/vcap/monit/job/0000_router.monitrc",
+				"/var/vcap/monit/job/0001_router_metrics.monitrc",
+				"/var/vcap/monit/job/0002_nats.monitrc",
+			})
+
+			cgroupManager.Cgroups["bosh-jobs/router"] = fakecgroup.NewFakeCgroup("bosh-jobs/router")
+			cgroupManager.Cgroups["bosh-jobs/router"].StatsValue = boshcgroup.Stats{CPUUsageNanos: 100, MemoryBytes: 2048, Pids: 3, OOMKills: 1}
+
+			cgroupManager.Cgroups["bosh-jobs/router_metrics"] = fakecgroup.NewFakeCgroup("bosh-jobs/router_metrics")
+			cgroupManager.Cgroups["bosh-jobs/router_metrics"].StatsValue = boshcgroup.Stats{IOReadBytes: 10, IOWriteBytes: 20}
+
+			cgroupManager.Cgroups["bosh-jobs/nats"] = fakecgroup.NewFakeCgroup("bosh-jobs/nats")
+			cgroupManager.Cgroups["bosh-jobs/nats"].StatsErr = errors.New("fake-stats-err")
+		})
+
+		It("reports the usage of each job's cgroup", func() {
+			resources, err := monit.JobResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(Equal(map[string]boshcgroup.Stats{
+				"router":         {CPUUsageNanos: 100, MemoryBytes: 2048, Pids: 3, OOMKills: 1},
+				"router_metrics": {IOReadBytes: 10, IOWriteBytes: 20},
+			}))
+		})
+
+		It("reports nothing when cgroups are not available", func() {
+			cgroupManager.VersionValue = boshcgroup.VersionUnsupported
+
+			resources, err := monit.JobResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(BeEmpty())
+		})
+	})
+
 	Describe("RemoveAllJobs", func() {
 		Context("when jobs directory removal succeeds", func() {
 			It("does not return error because all jobs are removed from monit", func() {
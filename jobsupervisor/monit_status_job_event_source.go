@@ -0,0 +1,137 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	JobEventProcessStarted   = "process started"
+	JobEventProcessStopped   = "process stopped"
+	JobEventProcessRestarted = "process restarted"
+	JobEventProcessCrashed   = "process crashed"
+)
+
+type monitServicePoller struct {
+	serviceTransitions
+	client boshmonit.Client
+}
+
+// serviceTransitions finds the state transitions of supervised processes
+// by name, as reported by source
+type serviceTransitions struct {
+	source      string
+	timeService clock.Clock
+}
+
+// NewMonitStatusJobEventSource polls monit's status and reports process
+// state transitions. Unlike alert emails it does not depend on monit's
+// mail settings and templates.
+func NewMonitStatusJobEventSource(
+	client boshmonit.Client,
+	pollInterval time.Duration,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) JobEventSource {
+	return NewPollingJobEventSource[map[string]boshmonit.Service](
+		JobEventSourceMonitStatus,
+		monitServicePoller{
+			serviceTransitions: serviceTransitions{source: JobEventSourceMonitStatus, timeService: timeService},
+			client:             client,
+		},
+		pollInterval,
+		timeService,
+		logger,
+	)
+}
+
+func (p monitServicePoller) Poll() (map[string]boshmonit.Service, error) {
+	status, err := p.client.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	services := map[string]boshmonit.Service{}
+	for _, service := range status.ServicesInGroup("vcap") {
+		services[service.Name] = service
+	}
+
+	return services, nil
+}
+
+func (s serviceTransitions) Diff(previous, current map[string]boshmonit.Service) []boshalert.MonitAlert {
+	alerts := []boshalert.MonitAlert{}
+
+	// The first poll only establishes what is already running
+	if previous == nil {
+		return alerts
+	}
+
+	for _, name := range sortedServiceNames(current) {
+		service := current[name]
+
+		before, found := previous[name]
+		if !found {
+			before = boshmonit.Service{Name: name, Status: boshmonit.StatusUnknown}
+		}
+
+		wasRunning := before.Status == boshmonit.StatusRunning
+		isRunning := service.Status == boshmonit.StatusRunning
+
+		switch {
+		case !wasRunning && isRunning:
+			alerts = append(alerts, s.alert(service, JobEventProcessStarted, "start",
+				fmt.Sprintf("process is running with pid %d", service.Pid)))
+
+		case wasRunning && isRunning && service.Pid != before.Pid:
+			alerts = append(alerts, s.alert(service, JobEventProcessRestarted, "restart",
+				fmt.Sprintf("process with pid %d exited after %ds and was restarted with pid %d", before.Pid, before.Uptime, service.Pid)))
+
+		case wasRunning && !isRunning && !service.Monitored:
+			alerts = append(alerts, s.alert(service, JobEventProcessStopped, "stop",
+				fmt.Sprintf("process with pid %d was stopped after %ds", before.Pid, before.Uptime)))
+
+		case wasRunning && !isRunning:
+			alerts = append(alerts, s.alert(service, JobEventProcessCrashed, "alert",
+				fmt.Sprintf("process with pid %d exited after %ds: %s", before.Pid, before.Uptime, s.statusMessage(service))))
+		}
+	}
+
+	for _, name := range sortedServiceNames(previous) {
+		before := previous[name]
+
+		if _, found := current[name]; !found && before.Status == boshmonit.StatusRunning {
+			alerts = append(alerts, s.alert(before, JobEventProcessStopped, "stop",
+				fmt.Sprintf("process with pid %d was removed after %ds", before.Pid, before.Uptime)))
+		}
+	}
+
+	return alerts
+}
+
+func (s serviceTransitions) alert(service boshmonit.Service, event, action, description string) boshalert.MonitAlert {
+	return NewJobEvent(s.timeService, s.source, service.Name, event, action, description)
+}
+
+func (s serviceTransitions) statusMessage(service boshmonit.Service) string {
+	if service.StatusMessage != "" {
+		return service.StatusMessage
+	}
+	return "process is not running"
+}
+
+func sortedServiceNames(services map[string]boshmonit.Service) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,113 @@
+package jobsupervisor_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("monitStatusJobEventSource", func() {
+	var (
+		client      *fakemonit.FakeMonitClient
+		timeService *fakeclock.FakeClock
+		statuses    chan []boshmonit.Service
+		alerts      chan boshalert.MonitAlert
+	)
+
+	running := func(name string, pid, uptime int) boshmonit.Service {
+		return boshmonit.Service{Name: name, Monitored: true, Status: boshmonit.StatusRunning, Pid: pid, Uptime: uptime}
+	}
+
+	poll := func(services ...boshmonit.Service) {
+		statuses <- services
+		timeService.WaitForWatcherAndIncrement(5 * time.Second)
+	}
+
+	BeforeEach(func() {
+		client = fakemonit.NewFakeMonitClient()
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+		statuses = make(chan []boshmonit.Service, 10)
+		alerts = make(chan boshalert.MonitAlert, 10)
+
+		statuses := statuses
+		client.StatusStub = func() (boshmonit.Status, error) {
+			return fakemonit.FakeMonitStatus{Services: <-statuses}, nil
+		}
+
+		source := NewMonitStatusJobEventSource(client, 5*time.Second, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		go func() {
+			_ = source.Run(func(alert boshalert.MonitAlert) error {
+				alerts <- alert
+				return nil
+			})
+		}()
+
+		// Baseline
+		statuses <- []boshmonit.Service{running("nats", 100, 60)}
+	})
+
+	It("does not report processes that were running when it started", func() {
+		poll(running("nats", 100, 65))
+		Consistently(alerts).ShouldNot(Receive())
+	})
+
+	It("reports crashes with exit details", func() {
+		poll(boshmonit.Service{Name: "nats", Monitored: true, Status: boshmonit.StatusFailing, StatusMessage: "process is not running"})
+
+		Eventually(alerts).Should(Receive(Equal(boshalert.MonitAlert{
+			ID:          "1767323050000000000.nats@monit-status",
+			Service:     "nats",
+			Event:       "process crashed",
+			Action:      "alert",
+			Date:        "Fri, 02 Jan 2026 03:04:10 +0000",
+			Description: "process with pid 100 exited after 60s: process is not running",
+		})))
+	})
+
+	It("reports restarts when the pid changes", func() {
+		poll(running("nats", 200, 1))
+
+		var alert boshalert.MonitAlert
+		Eventually(alerts).Should(Receive(&alert))
+		Expect(alert.Event).To(Equal("process restarted"))
+		Expect(alert.Description).To(Equal("process with pid 100 exited after 60s and was restarted with pid 200"))
+	})
+
+	It("reports processes being stopped and started", func() {
+		poll(boshmonit.Service{Name: "nats", Status: boshmonit.StatusUnknown})
+
+		var alert boshalert.MonitAlert
+		Eventually(alerts).Should(Receive(&alert))
+		Expect(alert.Event).To(Equal("process stopped"))
+		Expect(alert.Action).To(Equal("stop"))
+
+		poll(running("nats", 300, 1), running("redis", 400, 1))
+
+		Eventually(alerts).Should(Receive(&alert))
+		Expect(alert.Service).To(Equal("nats"))
+		Expect(alert.Event).To(Equal("process started"))
+		Expect(alert.Description).To(Equal("process is running with pid 300"))
+
+		Eventually(alerts).Should(Receive(&alert))
+		Expect(alert.Service).To(Equal("redis"))
+		Expect(alert.Event).To(Equal("process started"))
+	})
+
+	It("reports processes removed from monit as stopped", func() {
+		poll()
+
+		var alert boshalert.MonitAlert
+		Eventually(alerts).Should(Receive(&alert))
+		Expect(alert.Event).To(Equal("process stopped"))
+		Expect(alert.Description).To(Equal("process with pid 100 was removed after 60s"))
+	})
+})
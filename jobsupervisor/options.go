@@ -0,0 +1,46 @@
+package jobsupervisor
+
+import (
+	"time"
+)
+
+const (
+	defaultJobEventPollInterval = 5 * time.Second
+
+	defaultCrashLoopMaxRestarts = 5
+	defaultCrashLoopWindow      = 10 * time.Minute
+)
+
+type Options struct {
+	// EventSource selects how job events are received: smtp (default)
+	// receives monit alert emails, monit-status polls monit's status
+	EventSource string
+
+	EventPollIntervalInSeconds int
+
+	// A process restarting more than CrashLoopMaxRestarts times within
+	// CrashLoopWindowInSeconds is reported as crash-looping
+	CrashLoopMaxRestarts     int
+	CrashLoopWindowInSeconds int
+}
+
+func (o Options) GetEventPollInterval() time.Duration {
+	if o.EventPollIntervalInSeconds <= 0 {
+		return defaultJobEventPollInterval
+	}
+	return time.Duration(o.EventPollIntervalInSeconds) * time.Second
+}
+
+func (o Options) GetCrashLoopMaxRestarts() int {
+	if o.CrashLoopMaxRestarts <= 0 {
+		return defaultCrashLoopMaxRestarts
+	}
+	return o.CrashLoopMaxRestarts
+}
+
+func (o Options) GetCrashLoopWindow() time.Duration {
+	if o.CrashLoopWindowInSeconds <= 0 {
+		return defaultCrashLoopWindow
+	}
+	return time.Duration(o.CrashLoopWindowInSeconds) * time.Second
+}
@@ -0,0 +1,89 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const pollingJobEventSourceLogTag = "pollingJobEventSource"
+
+// JobEventPoller polls state, e.g. of processes or disks, and finds the job
+// events between two successive polls
+type JobEventPoller[S any] interface {
+	Poll() (S, error)
+
+	// Diff returns the events for what changed from previous to current;
+	// previous is the zero value for the first poll
+	Diff(previous, current S) []boshalert.MonitAlert
+}
+
+// pollingJobEventSource reports the events between successive polls
+type pollingJobEventSource[S any] struct {
+	source       string
+	poller       JobEventPoller[S]
+	pollInterval time.Duration
+	timeService  clock.Clock
+	logger       boshlog.Logger
+}
+
+// NewPollingJobEventSource polls poller every pollInterval and reports the
+// events it finds between successive results. Failed polls are skipped.
+func NewPollingJobEventSource[S any](
+	source string,
+	poller JobEventPoller[S],
+	pollInterval time.Duration,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) JobEventSource {
+	return pollingJobEventSource[S]{
+		source:       source,
+		poller:       poller,
+		pollInterval: pollInterval,
+		timeService:  timeService,
+		logger:       logger,
+	}
+}
+
+func (s pollingJobEventSource[S]) Run(handler JobFailureHandler) error {
+	var previous S
+
+	ticker := s.timeService.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		current, err := s.poller.Poll()
+		if err != nil {
+			s.logger.Error(pollingJobEventSourceLogTag, "Polling %s: %s", s.source, err.Error())
+		} else {
+			for _, alert := range s.poller.Diff(previous, current) {
+				err = handler(alert)
+				if err != nil {
+					s.logger.Error(pollingJobEventSourceLogTag, "Handling '%s' event for '%s': %s", alert.Event, alert.Service, err.Error())
+				}
+			}
+
+			previous = current
+		}
+
+		<-ticker.C()
+	}
+}
+
+// NewJobEvent builds the alert for an event of service found by source
+func NewJobEvent(timeService clock.Clock, source, service, event, action, description string) boshalert.MonitAlert {
+	now := timeService.Now()
+
+	return boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@%s", now.UnixNano(), service, source),
+		Service:     service,
+		Event:       event,
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+}
@@ -0,0 +1,115 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// fakeJobEventPoller returns the values sent to polls, failing the poll
+// for negative values, and reports two events for values that changed
+type fakeJobEventPoller struct {
+	polls       chan int
+	diffs       chan [2]int
+	timeService clock.Clock
+}
+
+func (p fakeJobEventPoller) Poll() (int, error) {
+	value := <-p.polls
+	if value < 0 {
+		return 0, errors.New("fake-poll-err")
+	}
+	return value, nil
+}
+
+func (p fakeJobEventPoller) Diff(previous, current int) []boshalert.MonitAlert {
+	p.diffs <- [2]int{previous, current}
+	if current == previous {
+		return nil
+	}
+	return []boshalert.MonitAlert{
+		NewJobEvent(p.timeService, "fake-source", "fake-service", "changed", "alert", "fake-description"),
+		NewJobEvent(p.timeService, "fake-source", "other-service", "changed", "alert", "fake-description"),
+	}
+}
+
+var _ = Describe("pollingJobEventSource", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		polls       chan int
+		diffs       chan [2]int
+		alerts      chan boshalert.MonitAlert
+	)
+
+	poll := func(value int) {
+		polls <- value
+		timeService.WaitForWatcherAndIncrement(5 * time.Second)
+	}
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+		polls = make(chan int, 10)
+		diffs = make(chan [2]int, 10)
+		alerts = make(chan boshalert.MonitAlert, 10)
+
+		poller := fakeJobEventPoller{polls: polls, diffs: diffs, timeService: timeService}
+		source := NewPollingJobEventSource[int]("fake-source", poller, 5*time.Second, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		// Sources of earlier specs keep running, so they must not see later values
+		alerts := alerts
+
+		go func() {
+			_ = source.Run(func(alert boshalert.MonitAlert) error {
+				alerts <- alert
+				return errors.New("fake-handler-err")
+			})
+		}()
+	})
+
+	It("diffs the first poll against the zero value", func() {
+		polls <- 1
+		Eventually(diffs).Should(Receive(Equal([2]int{0, 1})))
+	})
+
+	It("reports the events between successive polls", func() {
+		polls <- 1
+		Eventually(diffs).Should(Receive())
+		Eventually(alerts).Should(Receive())
+		Eventually(alerts).Should(Receive())
+
+		poll(2)
+		Eventually(diffs).Should(Receive(Equal([2]int{1, 2})))
+		Eventually(alerts).Should(Receive(Equal(boshalert.MonitAlert{
+			ID:          "1767323050000000000.fake-service@fake-source",
+			Service:     "fake-service",
+			Event:       "changed",
+			Action:      "alert",
+			Date:        "Fri, 02 Jan 2026 03:04:10 +0000",
+			Description: "fake-description",
+		})))
+	})
+
+	It("skips failed polls", func() {
+		polls <- 1
+		Eventually(diffs).Should(Receive())
+
+		poll(-1)
+		poll(1)
+		Eventually(diffs).Should(Receive(Equal([2]int{1, 1})))
+		Consistently(diffs).ShouldNot(Receive())
+	})
+
+	It("keeps handling events when the handler fails", func() {
+		polls <- 1
+		Eventually(alerts).Should(Receive())
+		Eventually(alerts).Should(Receive())
+	})
+})
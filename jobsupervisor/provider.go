@@ -11,6 +11,7 @@ import (
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -28,17 +29,28 @@ func NewProvider(
 	logger boshlog.Logger,
 	dirProvider boshdir.Provider,
 	handler boshhandler.Handler,
+	options Options,
 ) Provider {
 	timeService := clock.NewClock()
 	fs := platform.GetFs()
 	runner := platform.GetRunner()
+	cgroupManager := boshcgroup.NewManager(fs, boshcgroup.DefaultMountPoint)
+
+	var eventSource JobEventSource
+	if options.EventSource == JobEventSourceMonitStatus {
+		eventSource = NewMonitStatusJobEventSource(client, options.GetEventPollInterval(), timeService, logger)
+	} else {
+		eventSource = NewSMTPJobEventSource(jobSupervisorListenPort)
+	}
+
 	monitJobSupervisor := NewMonitJobSupervisor(
 		fs,
 		runner,
 		client,
 		logger,
 		dirProvider,
-		jobSupervisorListenPort,
+		eventSource,
+		cgroupManager,
 		MonitReloadOptions{
 			MaxTries:               3,
 			MaxCheckTries:          10,
@@ -47,9 +59,43 @@ func NewProvider(
 		timeService,
 	)
 
+	systemdJobSupervisor := NewSystemdJobSupervisor(
+		fs,
+		runner,
+		logger,
+		dirProvider,
+		cgroupManager,
+		SystemdUnitDir,
+		options.GetEventPollInterval(),
+		timeService,
+	)
+
+	restartHistory := NewRestartHistory(
+		fs,
+		dirProvider,
+		options.GetCrashLoopMaxRestarts(),
+		options.GetCrashLoopWindow(),
+		timeService,
+	)
+
 	return Provider{
 		supervisors: map[string]JobSupervisor{
-			"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger),
+			"monit": NewWrapperJobSupervisor(
+				monitJobSupervisor,
+				fs,
+				dirProvider,
+				restartHistory,
+				NewJobResourceEventSource(monitJobSupervisor, options.GetEventPollInterval(), timeService, logger),
+				logger,
+			),
+			"systemd": NewWrapperJobSupervisor(
+				systemdJobSupervisor,
+				fs,
+				dirProvider,
+				restartHistory,
+				NewJobResourceEventSource(systemdJobSupervisor, options.GetEventPollInterval(), timeService, logger),
+				logger,
+			),
 			"dummy":      NewDummyJobSupervisor(),
 			"dummy-nats": NewDummyNatsJobSupervisor(handler),
 		},
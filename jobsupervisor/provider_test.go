@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"code.cloudfoundry.org/clock"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 
 	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
@@ -56,6 +57,7 @@ func init() { //nolint:gochecknoinits
 				logger,
 				dirProvider,
 				handler,
+				Options{},
 			)
 			if runtime.GOOS == "windows" {
 				jobSupervisorName = "windows"
@@ -75,7 +77,8 @@ func init() { //nolint:gochecknoinits
 					client,
 					logger,
 					dirProvider,
-					jobFailuresServerPort,
+					NewSMTPJobEventSource(jobFailuresServerPort),
+					boshcgroup.NewManager(fileSystem, boshcgroup.DefaultMountPoint),
 					MonitReloadOptions{
 						MaxTries:               3,
 						MaxCheckTries:          10,
@@ -88,6 +91,8 @@ func init() { //nolint:gochecknoinits
 					delegateSupervisor,
 					fileSystem,
 					dirProvider,
+					NewRestartHistory(fileSystem, dirProvider, 5, 10*time.Minute, timeService),
+					NewJobResourceEventSource(delegateSupervisor, 5*time.Second, timeService, logger),
 					logger,
 				)
 
@@ -95,6 +100,74 @@ func init() { //nolint:gochecknoinits
 			}
 		})
 
+		Context("when monit status is configured as the job event source", func() {
+			BeforeEach(func() {
+				provider = NewProvider(
+					platform,
+					client,
+					logger,
+					dirProvider,
+					handler,
+					Options{EventSource: JobEventSourceMonitStatus, EventPollIntervalInSeconds: 10},
+				)
+			})
+
+			It("provides a monit job supervisor that polls monit status for job events", func() {
+				if jobSupervisorName != "monit" {
+					Skip("monit is not used on windows")
+				}
+
+				actualSupervisor, err := provider.Get(jobSupervisorName)
+				Expect(err).ToNot(HaveOccurred())
+
+				delegateSupervisor := NewMonitJobSupervisor(
+					fileSystem,
+					cmdRunner,
+					client,
+					logger,
+					dirProvider,
+					NewMonitStatusJobEventSource(client, 10*time.Second, timeService, logger),
+					boshcgroup.NewManager(fileSystem, boshcgroup.DefaultMountPoint),
+					MonitReloadOptions{
+						MaxTries:               3,
+						MaxCheckTries:          10,
+						DelayBetweenCheckTries: 1 * time.Second,
+					},
+					timeService,
+				)
+
+				Expect(actualSupervisor).To(Equal(NewWrapperJobSupervisor(
+					delegateSupervisor,
+					fileSystem,
+					dirProvider,
+					NewRestartHistory(fileSystem, dirProvider, 5, 10*time.Minute, timeService),
+					NewJobResourceEventSource(delegateSupervisor, 10*time.Second, timeService, logger),
+					logger,
+				)))
+			})
+		})
+
+		It("provides a systemd job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("systemd is not used on windows")
+			}
+
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).ToNot(HaveOccurred())
+
+			delegateSupervisor := NewSystemdJobSupervisor(fileSystem, cmdRunner, logger, dirProvider, boshcgroup.NewManager(fileSystem, boshcgroup.DefaultMountPoint), SystemdUnitDir, 5*time.Second, timeService)
+
+			expectedSupervisor := NewWrapperJobSupervisor(
+				delegateSupervisor,
+				fileSystem,
+				dirProvider,
+				NewRestartHistory(fileSystem, dirProvider, 5, 10*time.Minute, timeService),
+				NewJobResourceEventSource(delegateSupervisor, 5*time.Second, timeService, logger),
+				logger,
+			)
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
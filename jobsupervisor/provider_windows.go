@@ -6,6 +6,8 @@ package jobsupervisor
 import (
 	"os"
 
+	"code.cloudfoundry.org/clock"
+
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
@@ -26,6 +28,7 @@ func NewProvider(
 	logger boshlog.Logger,
 	dirProvider boshdir.Provider,
 	handler boshhandler.Handler,
+	options Options,
 ) (p Provider) {
 	fs := platform.GetFs()
 	runner := platform.GetRunner()
@@ -39,11 +42,19 @@ func NewProvider(
 		machineIP = network.IP
 	}
 
+	restartHistory := NewRestartHistory(
+		fs,
+		dirProvider,
+		options.GetCrashLoopMaxRestarts(),
+		options.GetCrashLoopWindow(),
+		clock.NewClock(),
+	)
+
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"monit":      NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, restartHistory, nil, logger),
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
-		"windows":    NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"windows":    NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, restartHistory, nil, logger),
 	}
 
 	return
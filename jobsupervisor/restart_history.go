@@ -0,0 +1,216 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	JobEventProcessCrashLooping = "process crash looping"
+
+	restartHistoryFileName = "restart_history.json"
+	maxProcessTransitions  = 10
+)
+
+// Events after which the supervisor restarts the process: transitions
+// reported by polling sources and the matching monit alert events
+var processExitEvents = map[string]bool{ //nolint:gochecknoglobals
+	JobEventProcessCrashed:   true,
+	JobEventProcessRestarted: true,
+	"does not exist":         true,
+	"pid changed":            true,
+}
+
+type ProcessTransition struct {
+	Event string `json:"event"`
+	At    string `json:"at"`
+}
+
+type ProcessHistory struct {
+	Restarts     int                 `json:"restarts"`
+	LastExitAt   string              `json:"last_exit_at,omitempty"`
+	CrashLooping bool                `json:"crash_looping,omitempty"`
+	Transitions  []ProcessTransition `json:"transitions"`
+
+	// RecentExits are the unix times of exits within the crash loop window
+	RecentExits []int64 `json:"recent_exits,omitempty"`
+}
+
+type RestartHistory interface {
+	// Record adds a job event to the history of its process and returns
+	// an alert with the recent history if the process has just started
+	// crash-looping
+	Record(alert boshalert.MonitAlert) (*boshalert.MonitAlert, error)
+
+	Get() (map[string]ProcessHistory, error)
+}
+
+type restartHistory struct {
+	fs          boshsys.FileSystem
+	path        string
+	maxRestarts int
+	window      time.Duration
+	timeService clock.Clock
+
+	lock *sync.Mutex
+}
+
+// NewRestartHistory keeps the restart history of processes in the monit
+// directory. A process exiting more than maxRestarts times within window
+// is crash-looping.
+func NewRestartHistory(
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	maxRestarts int,
+	window time.Duration,
+	timeService clock.Clock,
+) RestartHistory {
+	return restartHistory{
+		fs:          fs,
+		path:        filepath.Join(dirProvider.MonitDir(), restartHistoryFileName),
+		maxRestarts: maxRestarts,
+		window:      window,
+		timeService: timeService,
+		lock:        &sync.Mutex{},
+	}
+}
+
+func (h restartHistory) Record(alert boshalert.MonitAlert) (*boshalert.MonitAlert, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	histories, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.timeService.Now()
+	history := histories[alert.Service]
+	wasCrashLooping := history.CrashLooping
+
+	history.Transitions = append(history.Transitions, ProcessTransition{
+		Event: alert.Event,
+		At:    now.UTC().Format(time.RFC3339),
+	})
+	if len(history.Transitions) > maxProcessTransitions {
+		history.Transitions = history.Transitions[len(history.Transitions)-maxProcessTransitions:]
+	}
+
+	if processExitEvents[strings.ToLower(alert.Event)] {
+		history.Restarts++
+		history.LastExitAt = now.UTC().Format(time.RFC3339)
+		history.RecentExits = append(history.RecentExits, now.Unix())
+	}
+
+	history = h.expire(history, now)
+	histories[alert.Service] = history
+
+	err = h.write(histories)
+	if err != nil {
+		return nil, err
+	}
+
+	if !history.CrashLooping || wasCrashLooping {
+		return nil, nil
+	}
+
+	crashLoopAlert := h.crashLoopAlert(alert.Service, history, now)
+	return &crashLoopAlert, nil
+}
+
+func (h restartHistory) Get() (map[string]ProcessHistory, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	histories, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.timeService.Now()
+	for name, history := range histories {
+		histories[name] = h.expire(history, now)
+	}
+
+	return histories, nil
+}
+
+// expire forgets exits that happened before the window and updates
+// whether the process is crash-looping accordingly
+func (h restartHistory) expire(history ProcessHistory, now time.Time) ProcessHistory {
+	recentExits := []int64{}
+	for _, exitedAt := range history.RecentExits {
+		if now.Sub(time.Unix(exitedAt, 0)) <= h.window {
+			recentExits = append(recentExits, exitedAt)
+		}
+	}
+
+	history.RecentExits = recentExits
+	history.CrashLooping = len(recentExits) > h.maxRestarts
+
+	return history
+}
+
+func (h restartHistory) read() (map[string]ProcessHistory, error) {
+	histories := map[string]ProcessHistory{}
+
+	if !h.fs.FileExists(h.path) {
+		return histories, nil
+	}
+
+	contents, err := h.fs.ReadFile(h.path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading restart history")
+	}
+
+	err = json.Unmarshal(contents, &histories)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling restart history")
+	}
+
+	return histories, nil
+}
+
+func (h restartHistory) write(histories map[string]ProcessHistory) error {
+	contents, err := json.Marshal(histories)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling restart history")
+	}
+
+	err = h.fs.WriteFile(h.path, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing restart history")
+	}
+
+	return nil
+}
+
+func (h restartHistory) crashLoopAlert(service string, history ProcessHistory, now time.Time) boshalert.MonitAlert {
+	transitions := make([]string, 0, len(history.Transitions))
+	for _, transition := range history.Transitions {
+		transitions = append(transitions, fmt.Sprintf("%s %s", transition.At, transition.Event))
+	}
+
+	return boshalert.MonitAlert{
+		ID:      fmt.Sprintf("%d.%s@crash-loop", now.UnixNano(), service),
+		Service: service,
+		Event:   JobEventProcessCrashLooping,
+		Action:  "alert",
+		Date:    now.Format(time.RFC1123Z),
+		Description: fmt.Sprintf(
+			"process exited %d times within %s; recent history: %s",
+			len(history.RecentExits), h.window, strings.Join(transitions, ", "),
+		),
+	}
+}
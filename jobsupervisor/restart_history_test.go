@@ -0,0 +1,115 @@
+package jobsupervisor_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("RestartHistory", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		history     RestartHistory
+	)
+
+	crash := func(service string) *boshalert.MonitAlert {
+		crashLoopAlert, err := history.Record(boshalert.MonitAlert{Service: service, Event: "process crashed"})
+		Expect(err).NotTo(HaveOccurred())
+		return crashLoopAlert
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+		history = NewRestartHistory(fs, boshdir.NewProvider("/var/vcap"), 2, time.Minute, timeService)
+	})
+
+	It("records restarts and transitions per process in the monit directory", func() {
+		crash("nats")
+		timeService.Increment(time.Second)
+
+		_, err := history.Record(boshalert.MonitAlert{Service: "nats", Event: "Does not exist"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = history.Record(boshalert.MonitAlert{Service: "redis", Event: "process started"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fs.FileExists("/var/vcap/monit/restart_history.json")).To(BeTrue())
+
+		histories, err := history.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histories).To(Equal(map[string]ProcessHistory{
+			"nats": {
+				Restarts:   2,
+				LastExitAt: "2026-01-02T03:04:06Z",
+				Transitions: []ProcessTransition{
+					{Event: "process crashed", At: "2026-01-02T03:04:05Z"},
+					{Event: "Does not exist", At: "2026-01-02T03:04:06Z"},
+				},
+				RecentExits: []int64{1767323045, 1767323046},
+			},
+			"redis": {
+				Transitions: []ProcessTransition{
+					{Event: "process started", At: "2026-01-02T03:04:06Z"},
+				},
+				RecentExits: []int64{},
+			},
+		}))
+	})
+
+	It("keeps the last ten transitions", func() {
+		for i := 0; i < 12; i++ {
+			_, err := history.Record(boshalert.MonitAlert{Service: "nats", Event: "process started"})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		histories, err := history.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histories["nats"].Transitions).To(HaveLen(10))
+	})
+
+	It("reports a process exiting too often within the window as crash-looping once", func() {
+		Expect(crash("nats")).To(BeNil())
+		Expect(crash("nats")).To(BeNil())
+
+		crashLoopAlert := crash("nats")
+		Expect(crashLoopAlert).NotTo(BeNil())
+		Expect(crashLoopAlert.Service).To(Equal("nats"))
+		Expect(crashLoopAlert.Event).To(Equal("process crash looping"))
+		Expect(crashLoopAlert.Description).To(ContainSubstring("process exited 3 times within 1m0s"))
+
+		Expect(crash("nats")).To(BeNil())
+
+		histories, err := history.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histories["nats"].CrashLooping).To(BeTrue())
+	})
+
+	It("stops reporting a process as crash-looping once its exits are outside the window", func() {
+		crash("nats")
+		crash("nats")
+		crash("nats")
+
+		timeService.Increment(61 * time.Second)
+
+		histories, err := history.Get()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histories["nats"].CrashLooping).To(BeFalse())
+		Expect(histories["nats"].Restarts).To(Equal(3))
+	})
+
+	It("returns an error when the history cannot be read", func() {
+		Expect(fs.WriteFileString("/var/vcap/monit/restart_history.json", "not-json")).To(Succeed())
+
+		_, err := history.Get()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling restart history"))
+	})
+})
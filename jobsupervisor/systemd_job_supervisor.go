@@ -0,0 +1,634 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	SystemdUnitDir   = "/etc/systemd/system"
+	SystemdJobsSlice = "bosh-jobs.slice"
+
+	systemdUnitPrefix         = "bosh-job-"
+	systemdUnitSuffix         = ".service"
+	systemdUnmonitorDropIn    = "50-bosh-unmonitor.conf"
+	systemdRestartDelaySecond = 5
+)
+
+var (
+	systemdProcessNameRegexp = regexp.MustCompile(`^[A-Za-z0-9:_.\-]+$`)
+
+	systemdShowProperties = []string{
+		"Id",
+		"ActiveState",
+		"SubState",
+		"Result",
+		"ExecMainStatus",
+		"MainPID",
+		"ActiveEnterTimestamp",
+		"MemoryCurrent",
+		"CPUUsageNSec",
+	}
+)
+
+type systemdJobSupervisor struct {
+	fs            boshsys.FileSystem
+	runner        boshsys.CmdRunner
+	logger        boshlog.Logger
+	dirProvider   boshdir.Provider
+	cgroupManager boshcgroup.Manager
+	unitDir       string
+	pollInterval  time.Duration
+	timeService   clock.Clock
+}
+
+// NewSystemdJobSupervisor runs the processes of jobs' monit files as
+// systemd services in the bosh jobs slice. Unit files are generated
+// in unitDir when jobs are added.
+func NewSystemdJobSupervisor(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	cgroupManager boshcgroup.Manager,
+	unitDir string,
+	pollInterval time.Duration,
+	timeService clock.Clock,
+) JobSupervisor {
+	return systemdJobSupervisor{
+		fs:            fs,
+		runner:        runner,
+		logger:        logger,
+		dirProvider:   dirProvider,
+		cgroupManager: cgroupManager,
+		unitDir:       unitDir,
+		pollInterval:  pollInterval,
+		timeService:   timeService,
+	}
+}
+
+func (s systemdJobSupervisor) Reload() error {
+	_, _, _, err := s.runner.RunCommand("systemctl", "daemon-reload")
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading systemd units")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Start() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) > 0 {
+		err = s.remonitor(units)
+		if err != nil {
+			return err
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Starting units %v", units)
+
+		_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"enable", "--now"}, units...)...)
+		if err != nil {
+			return bosherr.WrapError(err, "Starting units")
+		}
+	}
+
+	err = s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Stop() error {
+	return s.stop(false)
+}
+
+func (s systemdJobSupervisor) StopAndWait() error {
+	return s.stop(true)
+}
+
+func (s systemdJobSupervisor) stop(wait bool) error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) > 0 {
+		args := []string{"disable", "--now"}
+		if !wait {
+			args = append(args, "--no-block")
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Stopping units %v", units)
+
+		_, _, _, err = s.runner.RunCommand("systemctl", append(args, units...)...)
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping units")
+		}
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+// Unmonitor keeps systemd from restarting the processes by overriding
+// the units' restart policy until the next Start
+func (s systemdJobSupervisor) Unmonitor() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	for _, unit := range units {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Unmonitoring unit %s", unit)
+
+		err = s.fs.WriteFileString(s.unmonitorDropInPath(unit), "[Service]\nRestart=no\n")
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmonitoring unit %s", unit)
+		}
+	}
+
+	return s.Reload()
+}
+
+func (s systemdJobSupervisor) remonitor(units []string) error {
+	reload := false
+
+	for _, unit := range units {
+		dropInPath := s.unmonitorDropInPath(unit)
+		if !s.fs.FileExists(dropInPath) {
+			continue
+		}
+
+		err := s.fs.RemoveAll(dropInPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Monitoring unit %s", unit)
+		}
+		reload = true
+	}
+
+	if reload {
+		return s.Reload()
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Status() string {
+	services, err := s.services()
+	if err != nil {
+		return "unknown"
+	}
+
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	status := "running"
+	for _, name := range sortedServiceNames(services) {
+		service := services[name]
+
+		if service.Status == boshmonit.StatusStarting {
+			return "starting"
+		}
+		if !service.Monitored || service.Status != boshmonit.StatusRunning {
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s systemdJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	services, err := s.services()
+	if err != nil {
+		return processes, bosherr.WrapError(err, "Getting service status")
+	}
+
+	for _, name := range sortedServiceNames(services) {
+		service := services[name]
+
+		processes = append(processes, Process{
+			Name:  service.Name,
+			State: service.Status,
+			Uptime: UptimeVitals{
+				Secs: service.Uptime,
+			},
+			Memory: MemoryVitals{
+				Kb:      service.MemoryKilobytesTotal,
+				Percent: service.MemoryPercentTotal,
+			},
+			CPU: CPUVitals{
+				Total: service.CPUPercentTotal,
+			},
+		})
+	}
+
+	return processes, nil
+}
+
+// JobResources reports the resource usage of each process' unit as the
+// processes of a job are run in separate units
+func (s systemdJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	resources := map[string]boshcgroup.Stats{}
+
+	if s.cgroupManager.Version() == boshcgroup.VersionUnsupported {
+		return resources, nil
+	}
+
+	units, err := s.units()
+	if err != nil {
+		return resources, err
+	}
+
+	for _, unit := range units {
+		name := strings.TrimSuffix(strings.TrimPrefix(unit, systemdUnitPrefix), systemdUnitSuffix)
+
+		cg, err := s.cgroupManager.Get(path.Join(SystemdJobsSlice, unit))
+		if err != nil {
+			return resources, bosherr.WrapErrorf(err, "Getting cgroup of unit %s", unit)
+		}
+
+		stats, err := cg.Stats()
+		if err != nil {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Getting resource usage of unit %s: %s", unit, err.Error())
+			continue
+		}
+
+		resources[name] = stats
+	}
+
+	return resources, nil
+}
+
+func (s systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
+	configContent, err := s.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	processes, err := ParseMonitConfig(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing job config %s", configPath)
+	}
+
+	for _, process := range processes {
+		if !systemdProcessNameRegexp.MatchString(process.Name) {
+			return bosherr.Errorf("Process name '%s' cannot be used in a systemd unit name", process.Name)
+		}
+
+		unitPath := path.Join(s.unitDir, systemdUnitName(process.Name))
+
+		err = s.fs.WriteFileString(unitPath, systemdUnit(jobName, process, resources))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing unit file for process %s", process.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) RemoveAllJobs() error {
+	paths := []string{}
+
+	// Units, their drop-ins and links left behind by enabled units
+	for _, pattern := range []string{
+		path.Join(s.unitDir, systemdUnitPrefix+"*"),
+		path.Join(s.unitDir, "multi-user.target.wants", systemdUnitPrefix+"*"),
+	} {
+		matches, err := s.fs.Glob(pattern)
+		if err != nil {
+			return bosherr.WrapError(err, "Finding unit files")
+		}
+		paths = append(paths, matches...)
+	}
+
+	for _, unitPath := range paths {
+		err := s.fs.RemoveAll(unitPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing unit file %s", unitPath)
+		}
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	source := NewPollingJobEventSource[map[string]boshmonit.Service](
+		JobEventSourceSystemd,
+		systemdServicePoller{
+			serviceTransitions: serviceTransitions{source: JobEventSourceSystemd, timeService: s.timeService},
+			supervisor:         s,
+		},
+		s.pollInterval,
+		s.timeService,
+		s.logger,
+	)
+
+	return source.Run(handler)
+}
+
+func (s systemdJobSupervisor) HealthRecorder(status string) {
+}
+
+type systemdServicePoller struct {
+	serviceTransitions
+	supervisor systemdJobSupervisor
+}
+
+func (p systemdServicePoller) Poll() (map[string]boshmonit.Service, error) {
+	return p.supervisor.services()
+}
+
+func (s systemdJobSupervisor) units() ([]string, error) {
+	paths, err := s.fs.Glob(path.Join(s.unitDir, systemdUnitPrefix+"*"+systemdUnitSuffix))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding unit files")
+	}
+
+	units := []string{}
+	for _, unitPath := range paths {
+		units = append(units, path.Base(unitPath))
+	}
+
+	return units, nil
+}
+
+// services reports the state of the units in the same terms as monit
+// so that status and job events do not depend on the supervisor
+func (s systemdJobSupervisor) services() (map[string]boshmonit.Service, error) {
+	services := map[string]boshmonit.Service{}
+
+	units, err := s.units()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(units) == 0 {
+		return services, nil
+	}
+
+	args := []string{"show", "--timestamp=unix", "--property=" + strings.Join(systemdShowProperties, ",")}
+
+	stdout, _, _, err := s.runner.RunCommand("systemctl", append(args, units...)...)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting unit status")
+	}
+
+	memTotalKb := s.memTotalKb()
+	now := s.timeService.Now()
+
+	for _, properties := range parseSystemctlShow(stdout) {
+		unit := properties["Id"]
+		if !strings.HasPrefix(unit, systemdUnitPrefix) {
+			continue
+		}
+
+		service := systemdService(properties, now, memTotalKb)
+		if s.fs.FileExists(s.unmonitorDropInPath(unit)) {
+			service.Monitored = false
+		}
+
+		services[service.Name] = service
+	}
+
+	return services, nil
+}
+
+func (s systemdJobSupervisor) memTotalKb() int {
+	meminfo, err := s.fs.ReadFileString("/proc/meminfo")
+	if err != nil {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Reading total memory: %s", err.Error())
+		return 0
+	}
+
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			total, _ := strconv.Atoi(fields[1])
+			return total
+		}
+	}
+
+	return 0
+}
+
+func (s systemdJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.dirProvider.SystemdDir(), "stopped")
+}
+
+func (s systemdJobSupervisor) unmonitorDropInPath(unit string) string {
+	return path.Join(s.unitDir, unit+".d", systemdUnmonitorDropIn)
+}
+
+func systemdUnitName(processName string) string {
+	return systemdUnitPrefix + processName + systemdUnitSuffix
+}
+
+func systemdService(properties map[string]string, now time.Time, memTotalKb int) boshmonit.Service {
+	service := boshmonit.Service{
+		Name:      strings.TrimSuffix(strings.TrimPrefix(properties["Id"], systemdUnitPrefix), systemdUnitSuffix),
+		Monitored: true,
+		Status:    boshmonit.StatusUnknown,
+	}
+
+	switch properties["ActiveState"] {
+	case "active", "reloading":
+		service.Status = boshmonit.StatusRunning
+	case "activating":
+		service.Status = boshmonit.StatusStarting
+		if properties["SubState"] == "auto-restart" {
+			service.Status = boshmonit.StatusFailing
+		}
+	case "failed":
+		service.Status = boshmonit.StatusFailing
+		service.Errored = true
+	case "deactivating":
+		service.Monitored = false
+		service.Pending = true
+	default:
+		service.Monitored = false
+	}
+
+	if service.Status == boshmonit.StatusFailing {
+		service.StatusMessage = fmt.Sprintf("unit exited with result '%s' and status %s", properties["Result"], properties["ExecMainStatus"])
+	}
+
+	service.Pid, _ = strconv.Atoi(properties["MainPID"])
+
+	if service.Status != boshmonit.StatusRunning {
+		return service
+	}
+
+	// With --timestamp=unix timestamps are shown as @<seconds>
+	startedAt, err := strconv.ParseInt(strings.TrimPrefix(properties["ActiveEnterTimestamp"], "@"), 10, 64)
+	if err == nil && startedAt > 0 {
+		uptime := now.Sub(time.Unix(startedAt, 0))
+		service.Uptime = int(uptime.Seconds())
+
+		// CPU usage is averaged since the unit started
+		cpuUsage, err := strconv.ParseUint(properties["CPUUsageNSec"], 10, 64)
+		if err == nil && uptime > 0 {
+			service.CPUPercentTotal = float64(cpuUsage) / float64(uptime.Nanoseconds()) * 100
+		}
+	}
+
+	// Not reported unless memory accounting is enabled
+	memory, err := strconv.ParseUint(properties["MemoryCurrent"], 10, 64)
+	if err == nil {
+		service.MemoryKilobytesTotal = int(memory / 1024)
+		if memTotalKb > 0 {
+			service.MemoryPercentTotal = float64(service.MemoryKilobytesTotal) / float64(memTotalKb) * 100
+		}
+	}
+
+	return service
+}
+
+// parseSystemctlShow splits the output of `systemctl show` for several
+// units into the properties of each unit
+func parseSystemctlShow(output string) []map[string]string {
+	units := []map[string]string{}
+	properties := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(properties) > 0 {
+				units = append(units, properties)
+				properties = map[string]string{}
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			properties[parts[0]] = parts[1]
+		}
+	}
+
+	if len(properties) > 0 {
+		units = append(units, properties)
+	}
+
+	return units
+}
+
+// systemdUnit runs process as a service. As each process of a job has
+// its own unit, resource limits apply to each process separately.
+func systemdUnit(jobName string, process MonitProcess, resources boshcgroup.Resources) string {
+	unit := []string{
+		"[Unit]",
+		fmt.Sprintf("Description=BOSH job %s process %s", jobName, process.Name),
+		"StartLimitIntervalSec=0",
+	}
+
+	for _, dependency := range process.DependsOn {
+		unit = append(unit,
+			"Wants="+systemdUnitName(dependency),
+			"After="+systemdUnitName(dependency),
+		)
+	}
+
+	unit = append(unit, "", "[Service]")
+
+	// Monit start programs daemonize the process
+	unit = append(unit, "Type=forking")
+
+	if process.PidFile != "" {
+		unit = append(unit, "PIDFile="+process.PidFile)
+	}
+
+	unit = append(unit, "ExecStart="+systemdShellCommand(process.Start.Command))
+	if process.Start.TimeoutInSeconds > 0 {
+		unit = append(unit, fmt.Sprintf("TimeoutStartSec=%d", process.Start.TimeoutInSeconds))
+	}
+
+	if process.Stop.Command != "" {
+		unit = append(unit, "ExecStop="+systemdShellCommand(process.Stop.Command))
+	}
+	if process.Stop.TimeoutInSeconds > 0 {
+		unit = append(unit, fmt.Sprintf("TimeoutStopSec=%d", process.Stop.TimeoutInSeconds))
+	}
+
+	if process.Start.UID != "" {
+		unit = append(unit, "User="+process.Start.UID)
+	}
+	if process.Start.GID != "" {
+		unit = append(unit, "Group="+process.Start.GID)
+	}
+
+	unit = append(unit,
+		"Restart=always",
+		fmt.Sprintf("RestartSec=%d", systemdRestartDelaySecond),
+		"Slice="+SystemdJobsSlice,
+		"CPUAccounting=yes",
+		"MemoryAccounting=yes",
+		"TasksAccounting=yes",
+		"IOAccounting=yes",
+	)
+
+	if resources.CPUs > 0 {
+		unit = append(unit, "CPUQuota="+strconv.FormatFloat(resources.CPUs*100, 'f', -1, 64)+"%")
+	}
+	if resources.CPUWeight > 0 {
+		unit = append(unit, fmt.Sprintf("CPUWeight=%d", resources.CPUWeight))
+	}
+	if resources.MemoryBytes > 0 {
+		unit = append(unit, fmt.Sprintf("MemoryMax=%d", resources.MemoryBytes))
+	}
+	if resources.PidsMax > 0 {
+		unit = append(unit, fmt.Sprintf("TasksMax=%d", resources.PidsMax))
+	}
+
+	unit = append(unit,
+		"",
+		"[Install]",
+		"WantedBy=multi-user.target",
+		"",
+	)
+
+	return strings.Join(unit, "\n")
+}
+
+// systemdShellCommand runs command with sh so that systemd does not
+// expand specifiers or variables in it
+func systemdShellCommand(command string) string {
+	escaped := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`%`, `%%`,
+		`$`, `$$`,
+	).Replace(command)
+
+	return fmt.Sprintf(`/bin/sh -c "%s"`, escaped)
+}
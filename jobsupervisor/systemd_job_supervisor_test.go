@@ -0,0 +1,349 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup/fakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	const (
+		showCmd = "systemctl show --timestamp=unix --property=Id,ActiveState,SubState,Result,ExecMainStatus,MainPID,ActiveEnterTimestamp,MemoryCurrent,CPUUsageNSec bosh-job-nats.service bosh-job-redis.service"
+
+		runningShow = `Id=bosh-job-nats.service
+ActiveState=active
+SubState=running
+Result=success
+ExecMainStatus=0
+MainPID=100
+ActiveEnterTimestamp=@1767322985
+MemoryCurrent=10485760
+CPUUsageNSec=30000000000
+
+Id=bosh-job-redis.service
+ActiveState=active
+SubState=running
+Result=success
+ExecMainStatus=0
+MainPID=200
+ActiveEnterTimestamp=@1767323045
+MemoryCurrent=[not set]
+CPUUsageNSec=[not set]
+`
+	)
+
+	var (
+		fs            *fakesys.FakeFileSystem
+		runner        *fakesys.FakeCmdRunner
+		timeService   *fakeclock.FakeClock
+		cgroupManager *fakecgroup.FakeManager
+		supervisor    JobSupervisor
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Unix(1767323045, 0))
+		cgroupManager = fakecgroup.NewFakeManager()
+
+		supervisor = NewSystemdJobSupervisor(
+			fs,
+			runner,
+			boshlog.NewLogger(boshlog.LevelNone),
+			boshdir.NewProvider("/var/vcap"),
+			cgroupManager,
+			"/etc/systemd/system",
+			5*time.Second,
+			timeService,
+		)
+
+		fs.SetGlob("/etc/systemd/system/bosh-job-*.service", []string{
+			"/etc/systemd/system/bosh-job-nats.service",
+			"/etc/systemd/system/bosh-job-redis.service",
+		})
+	})
+
+	Describe("AddJob", func() {
+		It("writes a unit for each process in the monit file", func() {
+			err := fs.WriteFileString("/var/vcap/jobs/nats/monit", `
+check process nats
+  with pidfile /var/vcap/sys/run/nats/nats.pid
+  start program '/var/vcap/jobs/nats/bin/nats_ctl start --name "nats" 100%'
+    as uid vcap and gid vcap with timeout 60 seconds
+  stop program "/var/vcap/jobs/nats/bin/nats_ctl stop $PID"
+  depends on syslog
+  group vcap
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit", boshcgroup.Resources{})
+			Expect(err).ToNot(HaveOccurred())
+
+			unit, err := fs.ReadFileString("/etc/systemd/system/bosh-job-nats.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unit).To(Equal(`[Unit]
+Description=BOSH job nats process nats
+StartLimitIntervalSec=0
+Wants=bosh-job-syslog.service
+After=bosh-job-syslog.service
+
+[Service]
+Type=forking
+PIDFile=/var/vcap/sys/run/nats/nats.pid
+ExecStart=/bin/sh -c "/var/vcap/jobs/nats/bin/nats_ctl start --name \"nats\" 100%%"
+TimeoutStartSec=60
+ExecStop=/bin/sh -c "/var/vcap/jobs/nats/bin/nats_ctl stop $$PID"
+User=vcap
+Group=vcap
+Restart=always
+RestartSec=5
+Slice=bosh-jobs.slice
+CPUAccounting=yes
+MemoryAccounting=yes
+TasksAccounting=yes
+IOAccounting=yes
+
+[Install]
+WantedBy=multi-user.target
+`))
+		})
+
+		It("limits the resources of the job's units", func() {
+			err := fs.WriteFileString("/var/vcap/jobs/nats/monit", `check process nats
+  with pidfile /var/vcap/sys/run/nats/nats.pid
+  start program "/var/vcap/jobs/nats/bin/nats_ctl start"
+  group vcap
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit", boshcgroup.Resources{
+				CPUs:        1.5,
+				CPUWeight:   200,
+				MemoryBytes: 1073741824,
+				PidsMax:     512,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			unit, err := fs.ReadFileString("/etc/systemd/system/bosh-job-nats.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unit).To(ContainSubstring(`IOAccounting=yes
+CPUQuota=150%
+CPUWeight=200
+MemoryMax=1073741824
+TasksMax=512
+
+[Install]
+`))
+		})
+
+		It("returns an error when the monit file cannot be parsed", func() {
+			err := fs.WriteFileString("/var/vcap/jobs/nats/monit", "check process nats")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit", boshcgroup.Resources{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing job config"))
+		})
+
+		It("returns an error when the process name is not a valid unit name", func() {
+			err := fs.WriteFileString("/var/vcap/jobs/nats/monit", `check process "nats server" start program "/bin/nats"`)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit", boshcgroup.Resources{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot be used in a systemd unit name"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes units, drop-ins and links to enabled units", func() {
+			paths := []string{
+				"/etc/systemd/system/bosh-job-nats.service",
+				"/etc/systemd/system/bosh-job-nats.service.d/50-bosh-unmonitor.conf",
+				"/etc/systemd/system/multi-user.target.wants/bosh-job-nats.service",
+			}
+			for _, path := range paths {
+				Expect(fs.WriteFileString(path, "")).To(Succeed())
+			}
+
+			fs.SetGlob("/etc/systemd/system/bosh-job-*", []string{
+				"/etc/systemd/system/bosh-job-nats.service",
+				"/etc/systemd/system/bosh-job-nats.service.d",
+			})
+			fs.SetGlob("/etc/systemd/system/multi-user.target.wants/bosh-job-*", []string{
+				"/etc/systemd/system/multi-user.target.wants/bosh-job-nats.service",
+			})
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+
+			for _, path := range paths {
+				Expect(fs.FileExists(path)).To(BeFalse())
+			}
+		})
+	})
+
+	Describe("Start", func() {
+		It("re-monitors and starts all units", func() {
+			Expect(fs.WriteFileString("/etc/systemd/system/bosh-job-nats.service.d/50-bosh-unmonitor.conf", "")).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/systemd/stopped", "")).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(fs.FileExists("/etc/systemd/system/bosh-job-nats.service.d/50-bosh-unmonitor.conf")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/systemd/stopped")).To(BeFalse())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "daemon-reload"},
+				{"systemctl", "enable", "--now", "bosh-job-nats.service", "bosh-job-redis.service"},
+			}))
+		})
+
+		It("returns an error when starting fails", func() {
+			runner.AddCmdResult("systemctl enable --now bosh-job-nats.service bosh-job-redis.service", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Starting units"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops all units without waiting", func() {
+			Expect(supervisor.Stop()).To(Succeed())
+
+			Expect(fs.FileExists("/var/vcap/systemd/stopped")).To(BeTrue())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "disable", "--now", "--no-block", "bosh-job-nats.service", "bosh-job-redis.service"},
+			}))
+		})
+	})
+
+	Describe("StopAndWait", func() {
+		It("stops all units and waits for them to stop", func() {
+			Expect(supervisor.StopAndWait()).To(Succeed())
+
+			Expect(fs.FileExists("/var/vcap/systemd/stopped")).To(BeTrue())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "disable", "--now", "bosh-job-nats.service", "bosh-job-redis.service"},
+			}))
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("disables restarts of all units", func() {
+			Expect(supervisor.Unmonitor()).To(Succeed())
+
+			dropIn, err := fs.ReadFileString("/etc/systemd/system/bosh-job-redis.service.d/50-bosh-unmonitor.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dropIn).To(Equal("[Service]\nRestart=no\n"))
+			Expect(runner.RunCommands).To(Equal([][]string{{"systemctl", "daemon-reload"}}))
+		})
+	})
+
+	Describe("Status", func() {
+		It("is running when all units are running", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: runningShow})
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("is stopped after units were stopped", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: runningShow})
+			Expect(fs.WriteFileString("/var/vcap/systemd/stopped", "")).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+
+		It("is starting when a unit is activating", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: "Id=bosh-job-nats.service\nActiveState=activating\nSubState=start\n"})
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("is failing when a unit is waiting to be restarted", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: "Id=bosh-job-nats.service\nActiveState=activating\nSubState=auto-restart\n"})
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("is failing when a unit is unmonitored", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: runningShow})
+			Expect(fs.WriteFileString("/etc/systemd/system/bosh-job-nats.service.d/50-bosh-unmonitor.conf", "")).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("is unknown when the units cannot be queried", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("Processes", func() {
+		It("reports the units' resource usage", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: runningShow})
+			Expect(fs.WriteFileString("/proc/meminfo", "MemTotal:        1024000 kB\nMemFree:          512000 kB\n")).To(Succeed())
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{
+					Name:   "nats",
+					State:  "running",
+					Uptime: UptimeVitals{Secs: 60},
+					Memory: MemoryVitals{Kb: 10240, Percent: 1},
+					CPU:    CPUVitals{Total: 50},
+				},
+				{
+					Name:  "redis",
+					State: "running",
+				},
+			}))
+		})
+	})
+
+	Describe("JobResources", func() {
+		It("reports the usage of each unit's cgroup", func() {
+			cgroupManager.Cgroups["bosh-jobs.slice/bosh-job-nats.service"] = fakecgroup.NewFakeCgroup("bosh-jobs.slice/bosh-job-nats.service")
+			cgroupManager.Cgroups["bosh-jobs.slice/bosh-job-nats.service"].StatsValue = boshcgroup.Stats{MemoryBytes: 1024}
+			cgroupManager.Cgroups["bosh-jobs.slice/bosh-job-redis.service"] = fakecgroup.NewFakeCgroup("bosh-jobs.slice/bosh-job-redis.service")
+			cgroupManager.Cgroups["bosh-jobs.slice/bosh-job-redis.service"].StatsErr = errors.New("fake-stats-err")
+
+			resources, err := supervisor.JobResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(Equal(map[string]boshcgroup.Stats{"nats": {MemoryBytes: 1024}}))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		It("reports units that failed", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: runningShow})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{
+				Stdout: "Id=bosh-job-nats.service\nActiveState=failed\nSubState=failed\nResult=exit-code\nExecMainStatus=1\nMainPID=0\n\n" +
+					"Id=bosh-job-redis.service\nActiveState=active\nSubState=running\nMainPID=200\n",
+			})
+
+			alerts := make(chan boshalert.MonitAlert, 1)
+			go func() {
+				_ = supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+					alerts <- alert
+					return nil
+				})
+			}()
+
+			timeService.WaitForWatcherAndIncrement(5 * time.Second)
+
+			var alert boshalert.MonitAlert
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.ID).To(Equal("1767323050000000000.nats@systemd"))
+			Expect(alert.Service).To(Equal("nats"))
+			Expect(alert.Event).To(Equal("process crashed"))
+			Expect(alert.Description).To(Equal("process with pid 100 exited after 60s: unit exited with result 'exit-code' and status 1"))
+		})
+	})
+})
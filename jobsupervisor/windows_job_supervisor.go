@@ -25,6 +25,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/winsvc"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -317,7 +318,12 @@ func (w *windowsJobSupervisor) Processes() ([]Process, error) {
 	return procs, nil
 }
 
-func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+// JobResources is not supported as there are no cgroups on Windows
+func (w *windowsJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	return map[string]boshcgroup.Stats{}, nil
+}
+
+func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
 	configFileContents, err := w.fs.ReadFile(configPath)
 	if err != nil {
 		return err
@@ -25,6 +25,7 @@ import (
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/winsvc"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -288,7 +289,7 @@ func addFlappingJob(jobSupervisor JobSupervisor, jobDir string, fs boshsys.FileS
 		return &conf, err
 	}
 
-	err = jobSupervisor.AddJob(jobName, 0, confPath)
+	err = jobSupervisor.AddJob(jobName, 0, confPath, boshcgroup.Resources{})
 	if err != nil {
 		return nil, err
 	}
@@ -502,7 +503,7 @@ var _ = Describe("WindowsJobSupervisor", func() {
 			})
 
 			JustBeforeEach(func() {
-				Expect(jobSupervisor.AddJob(jobName, 0, confPath)).To(Succeed())
+				Expect(jobSupervisor.AddJob(jobName, 0, confPath, boshcgroup.Resources{})).To(Succeed())
 			})
 
 			Context("when the monit file is non-empty", func() {
@@ -560,7 +561,7 @@ var _ = Describe("WindowsJobSupervisor", func() {
 				confPath, err = writeJobConfig(jobDir, fs, conf)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(jobSupervisor.AddJob(jobName, 0, confPath)).To(Succeed())
+				Expect(jobSupervisor.AddJob(jobName, 0, confPath, boshcgroup.Resources{})).To(Succeed())
 			})
 
 			Describe("Processes", func() {
@@ -902,7 +903,7 @@ var _ = Describe("WindowsJobSupervisor", func() {
 					confPath, err := writeJobConfig(jobDir, fs, conf)
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(jobSupervisor.AddJob("flap-start", 0, confPath)).To(Succeed())
+					Expect(jobSupervisor.AddJob("flap-start", 0, confPath, boshcgroup.Resources{})).To(Succeed())
 					Expect(jobSupervisor.Start()).To(Succeed())
 
 					for i := 0; i < 5; i++ {
@@ -998,7 +999,7 @@ var _ = Describe("WindowsJobSupervisor", func() {
 				confPath, err := writeJobConfig(jobDir, fs, conf)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(jobSupervisor.AddJob("ConcurrentWait", 0, confPath)).To(Succeed())
+				Expect(jobSupervisor.AddJob("ConcurrentWait", 0, confPath, boshcgroup.Resources{})).To(Succeed())
 				Expect(jobSupervisor.Start()).To(Succeed())
 
 				// WARN WARN WARN
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"path/filepath"
 
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cloudfoundry/bosh-utils/system"
@@ -12,18 +14,32 @@ import (
 const wrapperJobSupervisorLogTag = "wrapperJobSupervisor"
 
 type wrapperJobSupervisor struct {
-	delegate    JobSupervisor
-	fs          system.FileSystem
-	dirProvider directories.Provider
-	logger      boshlog.Logger
+	delegate            JobSupervisor
+	fs                  system.FileSystem
+	dirProvider         directories.Provider
+	restartHistory      RestartHistory
+	resourceEventSource JobEventSource
+	logger              boshlog.Logger
 }
 
-func NewWrapperJobSupervisor(delegate JobSupervisor, fs system.FileSystem, dirProvider directories.Provider, logger boshlog.Logger) JobSupervisor {
+// NewWrapperJobSupervisor adds restart history to the processes of delegate.
+// Events of resourceEventSource, if any, are reported along with the
+// delegate's job failures.
+func NewWrapperJobSupervisor(
+	delegate JobSupervisor,
+	fs system.FileSystem,
+	dirProvider directories.Provider,
+	restartHistory RestartHistory,
+	resourceEventSource JobEventSource,
+	logger boshlog.Logger,
+) JobSupervisor {
 	return &wrapperJobSupervisor{
-		delegate:    delegate,
-		fs:          fs,
-		dirProvider: dirProvider,
-		logger:      logger,
+		delegate:            delegate,
+		fs:                  fs,
+		dirProvider:         dirProvider,
+		restartHistory:      restartHistory,
+		resourceEventSource: resourceEventSource,
+		logger:              logger,
 	}
 }
 
@@ -58,16 +74,67 @@ func (w *wrapperJobSupervisor) Status() string {
 	return w.delegate.Status()
 }
 func (w *wrapperJobSupervisor) Processes() ([]Process, error) {
-	return w.delegate.Processes()
+	processes, err := w.delegate.Processes()
+	if err != nil {
+		return processes, err
+	}
+
+	histories, err := w.restartHistory.Get()
+	if err != nil {
+		w.logger.Error(wrapperJobSupervisorLogTag, "Getting restart history: %s", err.Error())
+		return processes, nil
+	}
+
+	for i, process := range processes {
+		history, found := histories[process.Name]
+		if !found {
+			continue
+		}
+
+		processes[i].Restarts = history.Restarts
+		processes[i].LastExitAt = history.LastExitAt
+		processes[i].CrashLooping = history.CrashLooping
+		processes[i].History = history.Transitions
+	}
+
+	return processes, nil
+}
+func (w *wrapperJobSupervisor) JobResources() (map[string]boshcgroup.Stats, error) {
+	return w.delegate.JobResources()
 }
-func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
-	return w.delegate.AddJob(jobName, jobIndex, configPath)
+func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath string, resources boshcgroup.Resources) error {
+	return w.delegate.AddJob(jobName, jobIndex, configPath, resources)
 }
 func (w *wrapperJobSupervisor) RemoveAllJobs() error {
 	return w.delegate.RemoveAllJobs()
 }
 func (w *wrapperJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
-	return w.delegate.MonitorJobFailures(handler)
+	if w.resourceEventSource != nil {
+		go func() {
+			err := w.resourceEventSource.Run(handler)
+			if err != nil {
+				w.logger.Error(wrapperJobSupervisorLogTag, "Monitoring job resources: %s", err.Error())
+			}
+		}()
+	}
+
+	return w.delegate.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+		crashLoopAlert, err := w.restartHistory.Record(alert)
+		if err != nil {
+			w.logger.Error(wrapperJobSupervisorLogTag, "Recording '%s' event for '%s': %s", alert.Event, alert.Service, err.Error())
+		}
+
+		err = handler(alert)
+		if err != nil {
+			return err
+		}
+
+		if crashLoopAlert != nil {
+			return handler(*crashLoopAlert)
+		}
+
+		return nil
+	})
 }
 
 func (w *wrapperJobSupervisor) HealthRecorder(status string) {
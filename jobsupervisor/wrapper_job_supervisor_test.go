@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"errors"
 	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	"github.com/cloudfoundry/bosh-agent/agent/alert"
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -23,6 +27,8 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		logger         boshlog.Logger
 		dirProvider    boshdir.Provider
 		fakeSupervisor *fakes.FakeJobSupervisor
+		restartHistory RestartHistory
+		eventSource    *fakes.FakeJobEventSource
 		wrapper        JobSupervisor
 	)
 
@@ -34,11 +40,16 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		dirProvider = boshdir.NewProvider("/var/vcap")
 
 		fakeSupervisor = fakes.NewFakeJobSupervisor()
+		restartHistory = NewRestartHistory(fs, dirProvider, 1, time.Minute, fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+		eventSource = &fakes.FakeJobEventSource{}
 
 		wrapper = NewWrapperJobSupervisor(
 			fakeSupervisor,
 			fs,
 			dirProvider,
+			restartHistory,
+			eventSource,
 			logger,
 		)
 	})
@@ -131,15 +142,40 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		Expect(err).To(Equal(fakeSupervisor.ProcessesError))
 	})
 
+	It("Processes should include the restart history", func() {
+		fakeSupervisor.ProcessesStatus = []Process{{Name: "nats"}, {Name: "redis"}}
+
+		_, err := restartHistory.Record(alert.MonitAlert{Service: "nats", Event: "process crashed"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = restartHistory.Record(alert.MonitAlert{Service: "nats", Event: "process started"})
+		Expect(err).NotTo(HaveOccurred())
+
+		processes, err := wrapper.Processes()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(processes).To(Equal([]Process{
+			{
+				Name:       "nats",
+				Restarts:   1,
+				LastExitAt: "2026-01-02T03:04:05Z",
+				History: []ProcessTransition{
+					{Event: "process crashed", At: "2026-01-02T03:04:05Z"},
+					{Event: "process started", At: "2026-01-02T03:04:05Z"},
+				},
+			},
+			{Name: "redis"},
+		}))
+	})
+
 	It("AddJob should delegate to the underlying job supervisor", func() {
 		boomError := errors.New("BOOM")
 		fakeSupervisor.StartErr = boomError
-		_ = wrapper.AddJob("name", 0, "path")
+		_ = wrapper.AddJob("name", 0, "path", boshcgroup.Resources{MemoryBytes: 1024})
 		Expect(fakeSupervisor.AddJobArgs).To(Equal([]fakes.AddJobArgs{
 			{
 				Name:       "name",
 				Index:      0,
 				ConfigPath: "path",
+				Resources:  boshcgroup.Resources{MemoryBytes: 1024},
 			},
 		}))
 	})
@@ -162,4 +198,42 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		})
 		Expect(testAlert).To(Equal(fakeSupervisor.JobFailureAlert))
 	})
+
+	It("MonitorJobFailures should report job resource events", func() {
+		eventSource.Alerts = []alert.MonitAlert{{ID: "oom-alert", Service: "router", Event: "oom killed"}}
+
+		alerts := make(chan alert.MonitAlert, 1)
+		err := wrapper.MonitorJobFailures(func(a alert.MonitAlert) error {
+			alerts <- a
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(alerts).Should(Receive(Equal(eventSource.Alerts[0])))
+	})
+
+	It("MonitorJobFailures should report processes that start crash-looping", func() {
+		_, err := restartHistory.Record(alert.MonitAlert{Service: "nats", Event: "process crashed"})
+		Expect(err).NotTo(HaveOccurred())
+
+		alerts := []alert.MonitAlert{}
+
+		fakeSupervisor.JobFailureAlert = &alert.MonitAlert{ID: "test-alert", Service: "nats", Event: "process restarted"}
+		err = wrapper.MonitorJobFailures(func(a alert.MonitAlert) error {
+			alerts = append(alerts, a)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(alerts).To(HaveLen(2))
+		Expect(alerts[0]).To(Equal(*fakeSupervisor.JobFailureAlert))
+		Expect(alerts[1]).To(Equal(alert.MonitAlert{
+			ID:          "1767323045000000000.nats@crash-loop",
+			Service:     "nats",
+			Event:       "process crash looping",
+			Action:      "alert",
+			Date:        "Fri, 02 Jan 2026 03:04:05 +0000",
+			Description: "process exited 2 times within 1m0s; recent history: 2026-01-02T03:04:05Z process crashed, 2026-01-02T03:04:05Z process restarted",
+		}))
+	})
 })
@@ -6,6 +6,7 @@ import (
 	"github.com/nats-io/nats.go"
 
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -49,10 +50,11 @@ func (p HandlerProvider) Get(
 		f := func(url string, options ...nats.Option) (NatsConnection, error) {
 			return nats.Connect(url, options...)
 		}
-		return NewNatsHandler(p.settingsService, f, p.logger, platform), nil
+		return NewNatsHandler(p.settingsService, f, NewNatsJetStreamConnector(), p.logger, platform), nil
 	case "https":
 		mbusKeyPair := p.settingsService.GetSettings().GetMbusCerts()
-		return NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, p.logger, p.auditLogger), nil
+		logTailer := logtail.NewTailer(platform.GetFs(), platform.GetDirProvider())
+		return NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, logTailer, p.logger, p.auditLogger), nil
 	default:
 		return nil, bosherr.Errorf("Message Bus Handler with scheme %s could not be found", mbusURL.Scheme)
 	}
@@ -6,7 +6,12 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
 
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logtail"
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/settings"
 
@@ -21,6 +26,7 @@ const httpsHandlerLogTag = "https_handler"
 type HTTPSHandler struct {
 	parsedURL   *url.URL
 	blobManager boshagentblobstore.BlobManagerInterface
+	logStreamer logtail.Streamer
 	logger      boshlog.Logger
 	dispatcher  *HTTPSDispatcher
 	auditLogger platform.AuditLogger
@@ -30,6 +36,7 @@ func NewHTTPSHandler(
 	parsedURL *url.URL,
 	keyPair settings.CertKeyPair,
 	blobManager boshagentblobstore.BlobManagerInterface,
+	logTailer logtail.Tailer,
 	logger boshlog.Logger,
 	auditLogger platform.AuditLogger,
 ) HTTPSHandler {
@@ -37,6 +44,7 @@ func NewHTTPSHandler(
 		parsedURL:   parsedURL,
 		logger:      logger,
 		blobManager: blobManager,
+		logStreamer: logtail.NewStreamer(logTailer, clock.NewClock(), logtail.DefaultStreamInterval),
 		dispatcher:  NewHTTPSDispatcher(parsedURL, keyPair, logger),
 		auditLogger: auditLogger,
 	}
@@ -53,6 +61,7 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.Func) error {
 func (h HTTPSHandler) Start(handlerFunc boshhandler.Func) error {
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
+	h.dispatcher.AddRoute("/logs/", h.logsHandler())
 	return h.dispatcher.Start()
 }
 
@@ -65,7 +74,7 @@ func (h HTTPSHandler) RegisterAdditionalFunc(_handlerFunc boshhandler.Func) {
 }
 
 func (h HTTPSHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
-	return nil
+	return boshhandler.ErrSendNotSupported
 }
 
 func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.ResponseWriter, *http.Request) {
@@ -163,6 +172,81 @@ func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	h.generateCEFLog(r, statusCode, "")
 }
 
+// logsHandler follows job or agent logs until the client disconnects,
+// e.g. GET /logs/job?filter=nginx/*.log&lines=100&offset=nginx/access.log:2048
+func (h HTTPSHandler) logsHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, logType := path.Split(r.URL.Path)
+
+		if r.Method != "GET" || (logType != "job" && logType != "agent") {
+			w.WriteHeader(404)
+			h.generateCEFLog(r, 404, "")
+			return
+		}
+
+		request, err := h.logTailRequest(logType, r.URL.Query())
+		if err != nil {
+			w.WriteHeader(400)
+			h.generateCEFLog(r, 400, "")
+			if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
+				h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(200)
+		h.generateCEFLog(r, 200, "")
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		err = h.logStreamer.Stream(request, w, r.Context().Done())
+		if err != nil {
+			h.logger.Error(httpsHandlerLogTag, "Failed to stream logs: %s", err.Error())
+		}
+	}
+}
+
+func (h HTTPSHandler) logTailRequest(logType string, query url.Values) (logtail.Request, error) {
+	request := logtail.Request{
+		LogType: logType,
+		Filters: query["filter"],
+		Offsets: map[string]int64{},
+	}
+
+	var err error
+
+	if lines := query.Get("lines"); lines != "" {
+		request.Lines, err = strconv.Atoi(lines)
+		if err != nil {
+			return request, bosherr.WrapError(err, "Parsing lines")
+		}
+	}
+
+	if maxBytes := query.Get("max_bytes"); maxBytes != "" {
+		request.MaxBytes, err = strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil {
+			return request, bosherr.WrapError(err, "Parsing max_bytes")
+		}
+	}
+
+	for _, offset := range query["offset"] {
+		i := strings.LastIndex(offset, ":")
+		if i < 0 {
+			return request, bosherr.Errorf("Parsing offset '%s': expected <path>:<bytes>", offset)
+		}
+
+		request.Offsets[offset[:i]], err = strconv.ParseInt(offset[i+1:], 10, 64)
+		if err != nil {
+			return request, bosherr.WrapErrorf(err, "Parsing offset '%s'", offset)
+		}
+	}
+
+	return request, nil
+}
+
 func (h HTTPSHandler) generateCEFLog(r *http.Request, respStatusCode int, respJSON string) {
 	cef := boshhandler.NewCommonEventFormat()
 
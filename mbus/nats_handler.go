@@ -23,6 +23,7 @@ import (
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 const (
@@ -54,10 +55,16 @@ type NatsConnection interface {
 }
 
 type natsHandler struct {
-	settingsService boshsettings.Service
-	connector       NatsConnector
-	connection      NatsConnection
-	platform        boshplatform.Platform
+	settingsService    boshsettings.Service
+	connector          NatsConnector
+	connection         NatsConnection
+	jetStreamConnector NatsJetStreamConnector
+	jetStream          NatsJetStream // guarded by outbox.lock
+	platform           boshplatform.Platform
+
+	outbox           *natsOutbox
+	idempotencyCache *idempotencyCache
+	uuidGenerator    boshuuid.Generator
 
 	handlerFuncs     []boshhandler.Func
 	handlerFuncsLock sync.Mutex
@@ -83,16 +90,21 @@ type ConnectionTLSInfo struct {
 func NewNatsHandler(
 	settingsService boshsettings.Service,
 	client NatsConnector,
+	jetStreamConnector NatsJetStreamConnector,
 	logger boshlog.Logger,
 	platform boshplatform.Platform,
 ) Handler {
 	return &natsHandler{
-		settingsService: settingsService,
-		connector:       client,
-		platform:        platform,
-		logger:          logger,
-		logTag:          natsHandlerLogTag,
-		auditLogger:     platform.GetAuditLogger(),
+		settingsService:    settingsService,
+		connector:          client,
+		jetStreamConnector: jetStreamConnector,
+		platform:           platform,
+		outbox:             &natsOutbox{},
+		idempotencyCache:   newIdempotencyCache(),
+		uuidGenerator:      boshuuid.NewGenerator(),
+		logger:             logger,
+		logTag:             natsHandlerLogTag,
+		auditLogger:        platform.GetAuditLogger(),
 	}
 }
 func (h *natsHandler) arpClean() {
@@ -143,6 +155,7 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 		}),
 		nats.ReconnectHandler(func(c *nats.Conn) {
 			h.logger.Debug(natsHandlerLogTag, "Reconnected to %v", c.ConnectedAddr())
+			go h.flushOutbox()
 		}),
 		nats.ClosedHandler(func(c *nats.Conn) {
 			h.logger.Debug(natsHandlerLogTag, "Connection Closed with: %v", c.LastError().Error())
@@ -175,6 +188,10 @@ func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 
 	subject := fmt.Sprintf("agent.%s", settings.AgentID)
 
+	if settings.Env.Bosh.Mbus.JetStream.Enabled {
+		return h.startJetStream(subject)
+	}
+
 	h.logger.Info(h.logTag, "Subscribing to %s", subject)
 
 	_, err = h.connection.Subscribe(subject, func(natsMsg *nats.Msg) {
@@ -214,6 +231,9 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	settings := h.settingsService.GetSettings()
 
 	subject := fmt.Sprintf("%s.agent.%s.%s", target, topic, settings.AgentID)
+	if h.durableJetStream() != nil && (topic == boshhandler.Alert || topic == boshhandler.Shutdown) {
+		return h.publishDurable(subject, bytes)
+	}
 	if h.connection != nil {
 		return h.connection.Publish(subject, bytes)
 	}
@@ -240,7 +260,9 @@ func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains []
 	return errors.New("server Certificate CommonName does not match *.nats.bosh-internal")
 }
 
-func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.Func) {
+// handleNatsMsg runs handlerFunc and publishes its response, which is returned
+// when there was one
+func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.Func) *natsReply {
 	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
 		natsMsg.Data,
 		handlerFunc,
@@ -251,19 +273,25 @@ func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.F
 	if err != nil {
 		h.logger.Error(h.logTag, "Running handler: %s", err)
 		h.generateCEFLog(natsMsg, 7, err.Error())
-		return
+		return nil
 	}
 
-	if len(respBytes) > 0 {
-		err = h.connection.Publish(req.ReplyTo, respBytes)
-		if err != nil {
-			h.generateCEFLog(natsMsg, 7, err.Error())
-			h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
-			return
-		}
+	if len(respBytes) == 0 {
+		h.generateCEFLog(natsMsg, 1, "")
+		return nil
+	}
+
+	reply := &natsReply{Subject: req.ReplyTo, Data: respBytes}
+
+	err = h.connection.Publish(reply.Subject, reply.Data)
+	if err != nil {
+		h.generateCEFLog(natsMsg, 7, err.Error())
+		h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
+		return reply
 	}
 
 	h.generateCEFLog(natsMsg, 1, "")
+	return reply
 }
 
 func (h *natsHandler) runUntilInterrupted() {
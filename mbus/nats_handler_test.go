@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/nats-io/nats.go"
@@ -30,6 +31,8 @@ func init() { //nolint:funlen,gochecknoinits
 			connectorURLArg     string
 			connectorOptionsArg []nats.Option
 			connection          *mbusfakes.FakeNatsConnection
+			jetStreamConnector  mbus.NatsJetStreamConnector
+			jetStream           *mbusfakes.FakeNatsJetStream
 			logger              boshlog.Logger
 			handler             boshhandler.Handler
 			platform            *platformfakes.FakePlatform
@@ -68,13 +71,19 @@ func init() { //nolint:funlen,gochecknoinits
 				connectorOptionsArg = options
 				return connection, nil
 			}
+			jetStream = &mbusfakes.FakeNatsJetStream{}
+			jetStreamConnector = func(conn mbus.NatsConnection) (mbus.NatsJetStream, error) {
+				Expect(conn).To(Equal(connection))
+				return jetStream, nil
+			}
+
 			platform = &platformfakes.FakePlatform{}
 			auditLogger = &platformfakes.FakeAuditLogger{}
 			platform.GetAuditLoggerReturns(auditLogger)
 		})
 
 		JustBeforeEach(func() {
-			handler = mbus.NewNatsHandler(settingsService, connector, logger, platform)
+			handler = mbus.NewNatsHandler(settingsService, connector, jetStreamConnector, logger, platform)
 		})
 
 		Describe("Start", func() {
@@ -239,7 +248,7 @@ func init() { //nolint:funlen,gochecknoinits
 
 			It("does not err when no username and password", func() {
 				settingsService.Settings.Mbus = "nats://127.0.0.1:1234"
-				handler = mbus.NewNatsHandler(settingsService, connector, logger, platform)
+				handler = mbus.NewNatsHandler(settingsService, connector, jetStreamConnector, logger, platform)
 
 				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
 				Expect(err).ToNot(HaveOccurred())
@@ -427,6 +436,162 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(message).To(Equal([]byte("{\"key1\":\"value1\",\"keyA\":\"valueA\"}")))
 			})
 		})
+
+		Describe("durable JetStream mode", func() {
+			var (
+				requests   int
+				durableSub nats.MsgHandler
+			)
+
+			BeforeEach(func() {
+				settingsService.Settings.Env.Bosh.Mbus.JetStream.Enabled = true
+				requests = 0
+			})
+
+			JustBeforeEach(func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+					requests++
+					return boshhandler.NewValueResponse(fmt.Sprintf("response %d", requests))
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jetStream.SubscribeCallCount()).To(Equal(1))
+				var subj string
+				subj, durableSub, _ = jetStream.SubscribeArgsForCall(0)
+				Expect(subj).To(Equal("agent.my-agent-id"))
+			})
+
+			AfterEach(func() {
+				handler.Stop()
+			})
+
+			It("subscribes through JetStream instead of core NATS", func() {
+				Expect(connection.SubscribeCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when JetStream is unavailable", func() {
+				handler = mbus.NewNatsHandler(settingsService, connector, func(mbus.NatsConnection) (mbus.NatsJetStream, error) {
+					return nil, errors.New("fake-jetstream-err")
+				}, logger, platform)
+
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) { return nil })
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-jetstream-err"))
+			})
+
+			It("runs each request only once and replays the response to redeliveries", func() {
+				msg := &nats.Msg{
+					Subject: "agent.my-agent-id",
+					Data:    []byte(`{"method":"ping","arguments":[],"reply_to":"fake-reply-to"}`),
+				}
+
+				durableSub(msg)
+				durableSub(msg)
+
+				Expect(requests).To(Equal(1))
+				Expect(connection.PublishCallCount()).To(Equal(2))
+				for i := 0; i < 2; i++ {
+					subj, message := connection.PublishArgsForCall(i)
+					Expect(subj).To(Equal("fake-reply-to"))
+					Expect(message).To(Equal([]byte(`{"value":"response 1"}`)))
+				}
+			})
+
+			It("prefers the idempotency key header to recognise redeliveries", func() {
+				first := nats.NewMsg("agent.my-agent-id")
+				first.Header.Set(mbus.IdempotencyKeyHeader, "key-1")
+				first.Data = []byte(`{"method":"ping","arguments":[],"reply_to":"reply-1"}`)
+
+				second := nats.NewMsg("agent.my-agent-id")
+				second.Header.Set(mbus.IdempotencyKeyHeader, "key-2")
+				second.Data = first.Data
+
+				durableSub(first)
+				durableSub(second)
+
+				Expect(requests).To(Equal(2))
+			})
+
+			It("publishes alerts and shutdown notifications to JetStream with unique dedup IDs", func() {
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-1"})
+				Expect(err).ToNot(HaveOccurred())
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-1"})
+				Expect(err).ToNot(HaveOccurred())
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Shutdown, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jetStream.PublishMsgCallCount()).To(Equal(3))
+				first, _ := jetStream.PublishMsgArgsForCall(0)
+				second, _ := jetStream.PublishMsgArgsForCall(1)
+				shutdown, _ := jetStream.PublishMsgArgsForCall(2)
+
+				Expect(first.Subject).To(Equal("hm.agent.alert.my-agent-id"))
+				Expect(first.Data).To(Equal([]byte(`{"id":"alert-1"}`)))
+				Expect(first.Header.Get(nats.MsgIdHdr)).ToNot(BeEmpty())
+				Expect(second.Data).To(Equal(first.Data))
+				Expect(second.Header.Get(nats.MsgIdHdr)).ToNot(Equal(first.Header.Get(nats.MsgIdHdr)))
+
+				Expect(shutdown.Subject).To(Equal("hm.agent.shutdown.my-agent-id"))
+				Expect(shutdown.Header.Get(nats.MsgIdHdr)).ToNot(Equal(first.Header.Get(nats.MsgIdHdr)))
+
+				Expect(connection.PublishCallCount()).To(Equal(0))
+			})
+
+			It("keeps heartbeats on core NATS", func() {
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, map[string]string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jetStream.PublishMsgCallCount()).To(Equal(0))
+				Expect(connection.PublishCallCount()).To(Equal(1))
+			})
+
+			It("queues alerts that could not be published and replays them in order", func() {
+				jetStream.PublishMsgReturnsOnCall(0, nil, errors.New("fake-disconnected-err"))
+
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-1"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loggerOutBuf).To(ContainSubstring("keeping 1 message(s) for redelivery"))
+
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-2"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jetStream.PublishMsgCallCount()).To(Equal(3))
+				failed, _ := jetStream.PublishMsgArgsForCall(0)
+				retried, _ := jetStream.PublishMsgArgsForCall(1)
+				Expect(retried.Data).To(Equal([]byte(`{"id":"alert-1"}`)))
+				Expect(retried.Header.Get(nats.MsgIdHdr)).To(Equal(failed.Header.Get(nats.MsgIdHdr)))
+				next, _ := jetStream.PublishMsgArgsForCall(2)
+				Expect(next.Data).To(Equal([]byte(`{"id":"alert-2"}`)))
+			})
+
+			It("does not block other publishers while JetStream is slow", func() {
+				published := make(chan struct{})
+				jetStream.PublishMsgStub = func(*nats.Msg, ...nats.PubOpt) (*nats.PubAck, error) {
+					<-published
+					return nil, nil
+				}
+
+				firstSent := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					err := handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-1"})
+					Expect(err).ToNot(HaveOccurred())
+					close(firstSent)
+				}()
+				Eventually(jetStream.PublishMsgCallCount).Should(Equal(1))
+
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, map[string]string{"id": "alert-2"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jetStream.PublishMsgCallCount()).To(Equal(1))
+
+				close(published)
+				Eventually(firstSent).Should(BeClosed())
+
+				Expect(jetStream.PublishMsgCallCount()).To(Equal(2))
+				next, _ := jetStream.PublishMsgArgsForCall(1)
+				Expect(next.Data).To(Equal([]byte(`{"id":"alert-2"}`)))
+			})
+		})
 	})
 }
 
@@ -0,0 +1,307 @@
+package mbus
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	// IdempotencyKeyHeader lets senders pick the key used to recognise
+	// redelivered requests; the JetStream message ID and the reply subject
+	// are used when it is missing
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	natsOutboxMaxLength       = 1000
+	natsIdempotencyCacheSize  = 1000
+	natsDurableConsumerPrefix = "agent-"
+)
+
+type NatsJetStreamConnector func(connection NatsConnection) (NatsJetStream, error)
+
+//counterfeiter:generate . NatsJetStream
+
+type NatsJetStream interface {
+	PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+	Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error)
+}
+
+func NewNatsJetStreamConnector() NatsJetStreamConnector {
+	return func(connection NatsConnection) (NatsJetStream, error) {
+		conn, ok := connection.(*nats.Conn)
+		if !ok {
+			return nil, bosherr.Errorf("JetStream requires a NATS connection, got %T", connection)
+		}
+
+		return conn.JetStream()
+	}
+}
+
+// natsOutbox keeps durable messages that could not be published,
+// e.g. while disconnected, until they can be replayed in order.
+// Only one goroutine flushes at a time so that messages stay in order.
+type natsOutbox struct {
+	messages []*nats.Msg
+	flushing bool
+	lock     sync.Mutex
+}
+
+// natsReply is a response published to the requester
+type natsReply struct {
+	Subject string
+	Data    []byte
+}
+
+type idempotencyEntry struct {
+	done    bool
+	replies []natsReply
+}
+
+// idempotencyCache remembers the replies of recently handled requests so
+// that redelivered requests are answered without running the action again
+type idempotencyCache struct {
+	entries map[string]*idempotencyEntry
+	order   []string
+	lock    sync.Mutex
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: map[string]*idempotencyEntry{}}
+}
+
+// Begin marks key as in progress. It returns false together with the
+// known entry if the key was seen before.
+func (c *idempotencyCache) Begin(key string) (idempotencyEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if entry, found := c.entries[key]; found {
+		return *entry, false
+	}
+
+	if len(c.order) >= natsIdempotencyCacheSize {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	c.entries[key] = &idempotencyEntry{}
+	c.order = append(c.order, key)
+
+	return idempotencyEntry{}, true
+}
+
+func (c *idempotencyCache) Finish(key string, replies []natsReply) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if entry, found := c.entries[key]; found {
+		entry.done = true
+		entry.replies = replies
+	}
+}
+
+func natsIdempotencyKey(natsMsg *nats.Msg) string {
+	if natsMsg.Header != nil {
+		if key := natsMsg.Header.Get(IdempotencyKeyHeader); key != "" {
+			return key
+		}
+
+		if key := natsMsg.Header.Get(nats.MsgIdHdr); key != "" {
+			return key
+		}
+	}
+
+	payload := struct {
+		ReplyTo string `json:"reply_to"`
+	}{}
+
+	if err := json.Unmarshal(natsMsg.Data, &payload); err != nil {
+		return ""
+	}
+
+	return payload.ReplyTo
+}
+
+func (h *natsHandler) startJetStream(subject string) error {
+	jetStream, err := h.jetStreamConnector(h.connection)
+	if err != nil {
+		return bosherr.WrapError(err, "Connecting to JetStream")
+	}
+
+	// Send and the reconnect handler read it under the outbox lock
+	h.outbox.lock.Lock()
+	h.jetStream = jetStream
+	h.outbox.lock.Unlock()
+
+	durable := natsDurableConsumerPrefix + h.settingsService.GetSettings().AgentID
+
+	h.logger.Info(h.logTag, "Subscribing to %s with durable consumer %s", subject, durable)
+
+	_, err = jetStream.Subscribe(subject, h.handleDurableNatsMsg, nats.Durable(durable), nats.ManualAck())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Subscribing to %s", subject)
+	}
+
+	return nil
+}
+
+// handleDurableNatsMsg acknowledges requests only once they were handled
+// and answers redeliveries of handled requests from the idempotency cache
+func (h *natsHandler) handleDurableNatsMsg(natsMsg *nats.Msg) {
+	key := natsIdempotencyKey(natsMsg)
+
+	if key != "" {
+		entry, isNew := h.idempotencyCache.Begin(key)
+		if !isNew {
+			if !entry.done {
+				h.logger.Debug(h.logTag, "Ignoring redelivery of request '%s' which is still being handled", key)
+				return
+			}
+
+			h.logger.Info(h.logTag, "Replaying response to redelivered request '%s'", key)
+			for _, reply := range entry.replies {
+				h.publishReply(natsMsg, reply)
+			}
+			h.ackNatsMsg(natsMsg)
+			return
+		}
+	}
+
+	h.handlerFuncsLock.Lock()
+	handlerFuncs := h.handlerFuncs
+	h.handlerFuncsLock.Unlock()
+
+	replies := []natsReply{}
+	for _, handlerFunc := range handlerFuncs {
+		if reply := h.handleNatsMsg(natsMsg, handlerFunc); reply != nil {
+			replies = append(replies, *reply)
+		}
+	}
+
+	if key != "" {
+		h.idempotencyCache.Finish(key, replies)
+	}
+
+	h.ackNatsMsg(natsMsg)
+}
+
+func (h *natsHandler) ackNatsMsg(natsMsg *nats.Msg) {
+	if err := natsMsg.Ack(); err != nil {
+		h.logger.Debug(h.logTag, "Acknowledging message on %s: %s", natsMsg.Subject, err.Error())
+	}
+}
+
+func (h *natsHandler) publishReply(natsMsg *nats.Msg, reply natsReply) {
+	err := h.connection.Publish(reply.Subject, reply.Data)
+	if err != nil {
+		h.generateCEFLog(natsMsg, 7, err.Error())
+		h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
+	}
+}
+
+// durableJetStream returns the JetStream context once it is connected
+func (h *natsHandler) durableJetStream() NatsJetStream {
+	h.outbox.lock.Lock()
+	defer h.outbox.lock.Unlock()
+
+	return h.jetStream
+}
+
+// publishDurable publishes to JetStream with a unique message ID that is
+// kept when the message is replayed, so the server only drops duplicates
+// of the same message and not identical messages sent twice. Messages
+// that cannot be published are queued and replayed before the next
+// durable message or after reconnecting. Publishing happens outside of the
+// outbox lock, so a slow JetStream server does not block other publishers;
+// messages queued while another goroutine is flushing are published by it.
+func (h *natsHandler) publishDurable(subject string, data []byte) error {
+	msgID, err := h.uuidGenerator.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating message ID")
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, msgID)
+
+	h.outbox.lock.Lock()
+
+	h.outbox.messages = append(h.outbox.messages, msg)
+
+	if len(h.outbox.messages) > natsOutboxMaxLength {
+		dropped := h.outbox.messages[0]
+		h.outbox.messages = h.outbox.messages[1:]
+		h.logger.Error(h.logTag, "Dropping undelivered message to %s: outbox is full", dropped.Subject)
+	}
+
+	h.outbox.lock.Unlock()
+
+	h.flushOutbox()
+
+	return nil
+}
+
+func (h *natsHandler) flushOutbox() {
+	h.outbox.lock.Lock()
+	if h.outbox.flushing {
+		h.outbox.lock.Unlock()
+		return
+	}
+	h.outbox.flushing = true
+	h.outbox.lock.Unlock()
+
+	defer func() {
+		h.outbox.lock.Lock()
+		h.outbox.flushing = false
+		h.outbox.lock.Unlock()
+	}()
+
+	for {
+		h.outbox.lock.Lock()
+		jetStream := h.jetStream
+		if jetStream == nil || len(h.outbox.messages) == 0 {
+			h.outbox.lock.Unlock()
+			return
+		}
+		batch := append([]*nats.Msg{}, h.outbox.messages...)
+		h.outbox.lock.Unlock()
+
+		published, err := publishBatch(jetStream, batch)
+
+		h.outbox.lock.Lock()
+		h.outbox.removePublished(published)
+		pending := len(h.outbox.messages)
+		h.outbox.lock.Unlock()
+
+		if err != nil {
+			h.logger.Error(h.logTag, "Publishing to %s failed, keeping %d message(s) for redelivery: %s",
+				batch[len(published)].Subject, pending, err.Error())
+			return
+		}
+	}
+}
+
+// publishBatch returns the messages published before the first failure
+func publishBatch(jetStream NatsJetStream, batch []*nats.Msg) ([]*nats.Msg, error) {
+	for i, msg := range batch {
+		_, err := jetStream.PublishMsg(msg)
+		if err != nil {
+			return batch[:i], err
+		}
+	}
+
+	return batch, nil
+}
+
+// removePublished must be called with the lock held. Messages dropped
+// because the outbox was full while publishing are no longer at its front.
+func (o *natsOutbox) removePublished(published []*nats.Msg) {
+	for _, msg := range published {
+		if len(o.messages) > 0 && o.messages[0] == msg {
+			o.messages = o.messages[1:]
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const handlerLogTag = "Metrics Handler"
+
+type handler struct {
+	vitalsService boshvitals.Service
+	jobSupervisor boshjobsuper.JobSupervisor
+	recorder      Recorder
+	logger        boshlog.Logger
+}
+
+// NewHandler serves agent vitals, monit process stats and recorded
+// agent events in the OpenMetrics text format
+func NewHandler(
+	vitalsService boshvitals.Service,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	recorder Recorder,
+	logger boshlog.Logger,
+) http.Handler {
+	return handler{
+		vitalsService: vitalsService,
+		jobSupervisor: jobSupervisor,
+		recorder:      recorder,
+		logger:        logger,
+	}
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	e := newExposition()
+
+	vitals, err := h.vitalsService.Get()
+	if err != nil {
+		h.logger.Error(handlerLogTag, "Getting vitals: %s", err.Error())
+		e.add("bosh_agent_vitals_up", "gauge", "Whether collecting vitals succeeded.", 0)
+	} else {
+		e.add("bosh_agent_vitals_up", "gauge", "Whether collecting vitals succeeded.", 1)
+		h.addVitals(e, vitals)
+	}
+
+	processes, err := h.jobSupervisor.Processes()
+	if err != nil {
+		h.logger.Error(handlerLogTag, "Getting processes: %s", err.Error())
+		e.add("bosh_agent_processes_up", "gauge", "Whether collecting process stats succeeded.", 0)
+	} else {
+		e.add("bosh_agent_processes_up", "gauge", "Whether collecting process stats succeeded.", 1)
+		h.addProcesses(e, processes)
+	}
+
+	h.addRecorded(e, h.recorder.Snapshot())
+
+	w.Header().Set("Content-Type", ContentType)
+	if _, err := e.WriteTo(w); err != nil {
+		h.logger.Error(handlerLogTag, "Writing metrics: %s", err.Error())
+	}
+}
+
+func (h handler) addVitals(e *exposition, vitals boshvitals.Vitals) {
+	addParsed(e, "bosh_agent_cpu_percent", "CPU usage by mode.", vitals.CPU.User, label{"mode", "user"})
+	addParsed(e, "bosh_agent_cpu_percent", "CPU usage by mode.", vitals.CPU.Sys, label{"mode", "sys"})
+	addParsed(e, "bosh_agent_cpu_percent", "CPU usage by mode.", vitals.CPU.Wait, label{"mode", "wait"})
+
+	addParsed(e, "bosh_agent_memory_used_kilobytes", "Used memory.", vitals.Mem.Kb)
+	addParsed(e, "bosh_agent_memory_used_percent", "Used memory.", vitals.Mem.Percent)
+	addParsed(e, "bosh_agent_swap_used_kilobytes", "Used swap.", vitals.Swap.Kb)
+	addParsed(e, "bosh_agent_swap_used_percent", "Used swap.", vitals.Swap.Percent)
+
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(vitals.Load) {
+			addParsed(e, "bosh_agent_load", "System load average.", vitals.Load[i], label{"period", period})
+		}
+	}
+
+	diskNames := make([]string, 0, len(vitals.Disk))
+	for name := range vitals.Disk {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+
+	for _, name := range diskNames {
+		disk := vitals.Disk[name]
+		addParsed(e, "bosh_agent_disk_used_percent", "Used disk space.", disk.Percent, label{"disk", name})
+		addParsed(e, "bosh_agent_disk_inodes_used_percent", "Used disk inodes.", disk.InodePercent, label{"disk", name})
+	}
+
+	e.add("bosh_agent_uptime_seconds", "gauge", "System uptime.", float64(vitals.Uptime.Secs))
+}
+
+func (h handler) addProcesses(e *exposition, processes []boshjobsuper.Process) {
+	for _, process := range processes {
+		name := label{"process", process.Name}
+
+		e.add("bosh_agent_process_info", "gauge", "Monit process state.", 1, name, label{"state", process.State})
+		e.add("bosh_agent_process_cpu_percent", "gauge", "Process CPU usage.", process.CPU.Total, name)
+		e.add("bosh_agent_process_memory_kilobytes", "gauge", "Process memory usage.", float64(process.Memory.Kb), name)
+		e.add("bosh_agent_process_memory_percent", "gauge", "Process memory usage.", process.Memory.Percent, name)
+		e.add("bosh_agent_process_uptime_seconds", "gauge", "Process uptime.", float64(process.Uptime.Secs), name)
+	}
+}
+
+func (h handler) addRecorded(e *exposition, snapshot Snapshot) {
+	methods := make([]string, 0, len(snapshot.ActionDispatches))
+	for method := range snapshot.ActionDispatches {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		e.addSample("bosh_agent_action_dispatches", "counter", "Dispatched actions.", "_total",
+			float64(snapshot.ActionDispatches[method]), label{"action", method})
+	}
+
+	for _, durations := range snapshot.TaskDurations {
+		labels := []label{{"action", durations.Method}, {"state", durations.State}}
+		e.addSample("bosh_agent_task_duration_seconds", "summary", "Duration of asynchronous tasks.", "_count",
+			float64(durations.Count), labels...)
+		e.addSample("bosh_agent_task_duration_seconds", "summary", "Duration of asynchronous tasks.", "_sum",
+			durations.Sum.Seconds(), labels...)
+	}
+
+	e.addSample("bosh_agent_heartbeat_send_failures", "counter", "Heartbeats that could not be sent.", "_total",
+		float64(snapshot.HeartbeatSendFailures))
+}
+
+// Vitals are reported as formatted strings; empty values are not collected on this platform
+func addParsed(e *exposition, name, help, value string, labels ...label) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	e.add(name, "gauge", help, parsed, labels...)
+}
@@ -0,0 +1,129 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	. "github.com/cloudfoundry/bosh-agent/metrics"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		vitalsService *vitalsfakes.FakeService
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		recorder      Recorder
+		handler       http.Handler
+	)
+
+	BeforeEach(func() {
+		vitalsService = &vitalsfakes.FakeService{}
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		recorder = NewRecorder()
+		handler = NewHandler(vitalsService, jobSupervisor, recorder, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/metrics", nil))
+		return response
+	}
+
+	It("serves vitals, processes and recorded events in the OpenMetrics format", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{
+			CPU:  boshvitals.CPUVitals{User: "1.5", Sys: "2.0", Wait: "0.1"},
+			Mem:  boshvitals.MemoryVitals{Kb: "1024", Percent: "10"},
+			Swap: boshvitals.MemoryVitals{Kb: "0", Percent: "0"},
+			Load: []string{"0.10", "0.20", "0.30"},
+			Disk: boshvitals.DiskVitals{
+				"system":     {Percent: "40", InodePercent: "5"},
+				"persistent": {Percent: "80", InodePercent: "6"},
+			},
+			Uptime: boshvitals.UptimeVitals{Secs: 3600},
+		}, nil)
+
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{
+				Name:   "fake-process",
+				State:  "running",
+				Uptime: boshjobsuper.UptimeVitals{Secs: 60},
+				Memory: boshjobsuper.MemoryVitals{Kb: 2048, Percent: 1.5},
+				CPU:    boshjobsuper.CPUVitals{Total: 3.5},
+			},
+		}
+
+		recorder.ActionDispatched("apply")
+		recorder.TaskFinished("apply", "done", 1500*time.Millisecond)
+		recorder.HeartbeatSendFailed()
+
+		response := get()
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("Content-Type")).To(Equal(ContentType))
+
+		body := response.Body.String()
+		Expect(body).To(ContainSubstring("# TYPE bosh_agent_cpu_percent gauge\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_cpu_percent{mode="user"} 1.5` + "\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_memory_used_kilobytes 1024\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_swap_used_percent 0\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_load{period="15m"} 0.3` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_disk_used_percent{disk="persistent"} 80` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_disk_inodes_used_percent{disk="system"} 5` + "\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_uptime_seconds 3600\n"))
+
+		Expect(body).To(ContainSubstring(`bosh_agent_process_info{process="fake-process",state="running"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_cpu_percent{process="fake-process"} 3.5` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_memory_kilobytes{process="fake-process"} 2048` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_uptime_seconds{process="fake-process"} 60` + "\n"))
+
+		Expect(body).To(ContainSubstring("# TYPE bosh_agent_action_dispatches counter\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_action_dispatches_total{action="apply"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_task_duration_seconds_count{action="apply",state="done"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_task_duration_seconds_sum{action="apply",state="done"} 1.5` + "\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_heartbeat_send_failures_total 1\n"))
+
+		Expect(body).To(HaveSuffix("# EOF\n"))
+	})
+
+	It("skips vitals that were not collected", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{}, nil)
+
+		body := get().Body.String()
+		Expect(body).ToNot(ContainSubstring("bosh_agent_cpu_percent"))
+		Expect(body).To(ContainSubstring("bosh_agent_uptime_seconds 0\n"))
+	})
+
+	It("reports failures to collect vitals and processes", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{}, errors.New("fake-vitals-error"))
+		jobSupervisor.ProcessesError = errors.New("fake-processes-error")
+
+		response := get()
+		Expect(response.Code).To(Equal(http.StatusOK))
+
+		body := response.Body.String()
+		Expect(body).To(ContainSubstring("bosh_agent_vitals_up 0\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_processes_up 0\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_heartbeat_send_failures_total 0\n"))
+	})
+
+	It("escapes label values", func() {
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{{Name: `fake-"process"`, State: "running"}}
+
+		body := get().Body.String()
+		Expect(body).To(ContainSubstring(`bosh_agent_process_cpu_percent{process="fake-\"process\""} 0` + "\n"))
+	})
+
+	It("rejects methods other than GET", func() {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("POST", "/metrics", nil))
+		Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
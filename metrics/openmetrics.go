@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	suffix string
+	labels []label
+	value  float64
+}
+
+type family struct {
+	name    string
+	typ     string
+	help    string
+	samples []sample
+}
+
+type exposition struct {
+	families []*family
+	byName   map[string]*family
+}
+
+func newExposition() *exposition {
+	return &exposition{byName: map[string]*family{}}
+}
+
+func (e *exposition) add(name, typ, help string, value float64, labels ...label) {
+	e.addSample(name, typ, help, "", value, labels...)
+}
+
+func (e *exposition) addSample(name, typ, help, suffix string, value float64, labels ...label) {
+	f, found := e.byName[name]
+	if !found {
+		f = &family{name: name, typ: typ, help: help}
+		e.byName[name] = f
+		e.families = append(e.families, f)
+	}
+
+	f.samples = append(f.samples, sample{suffix: suffix, labels: labels, value: value})
+}
+
+func (e *exposition) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	for _, f := range e.families {
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+
+		for _, s := range f.samples {
+			b.WriteString(f.name)
+			b.WriteString(s.suffix)
+			writeLabels(&b, s.labels)
+			fmt.Fprintf(&b, " %v\n", s.value)
+		}
+	}
+
+	b.WriteString("# EOF\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeLabels(b *strings.Builder, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	sorted := make([]label, len(labels))
+	copy(sorted, labels)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	b.WriteString("{")
+	for i, l := range sorted {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", l.name, escapeLabelValue(l.value))
+	}
+	b.WriteString("}")
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
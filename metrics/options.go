@@ -0,0 +1,7 @@
+package metrics
+
+type Options struct {
+	// Address the OpenMetrics endpoint listens on, e.g. "127.0.0.1:9190".
+	// The endpoint is disabled when empty.
+	Address string
+}
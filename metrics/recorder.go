@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder counts agent events that are not part of the vitals
+type Recorder interface {
+	ActionDispatched(method string)
+	TaskFinished(method string, state string, duration time.Duration)
+	HeartbeatSendFailed()
+
+	Snapshot() Snapshot
+}
+
+type TaskDurations struct {
+	Method string
+	State  string
+	Count  uint64
+	Sum    time.Duration
+}
+
+type Snapshot struct {
+	ActionDispatches      map[string]uint64
+	TaskDurations         []TaskDurations
+	HeartbeatSendFailures uint64
+}
+
+type taskKey struct {
+	method string
+	state  string
+}
+
+type concreteRecorder struct {
+	lock sync.Mutex
+
+	actionDispatches      map[string]uint64
+	taskDurations         map[taskKey]TaskDurations
+	heartbeatSendFailures uint64
+}
+
+func NewRecorder() Recorder {
+	return &concreteRecorder{
+		actionDispatches: map[string]uint64{},
+		taskDurations:    map[taskKey]TaskDurations{},
+	}
+}
+
+func (r *concreteRecorder) ActionDispatched(method string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.actionDispatches[method]++
+}
+
+func (r *concreteRecorder) TaskFinished(method string, state string, duration time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := taskKey{method: method, state: state}
+
+	durations := r.taskDurations[key]
+	durations.Method = method
+	durations.State = state
+	durations.Count++
+	durations.Sum += duration
+
+	r.taskDurations[key] = durations
+}
+
+func (r *concreteRecorder) HeartbeatSendFailed() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.heartbeatSendFailures++
+}
+
+func (r *concreteRecorder) Snapshot() Snapshot {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	snapshot := Snapshot{
+		ActionDispatches:      make(map[string]uint64, len(r.actionDispatches)),
+		TaskDurations:         make([]TaskDurations, 0, len(r.taskDurations)),
+		HeartbeatSendFailures: r.heartbeatSendFailures,
+	}
+
+	for method, count := range r.actionDispatches {
+		snapshot.ActionDispatches[method] = count
+	}
+
+	for _, durations := range r.taskDurations {
+		snapshot.TaskDurations = append(snapshot.TaskDurations, durations)
+	}
+
+	sort.Slice(snapshot.TaskDurations, func(i, j int) bool {
+		if snapshot.TaskDurations[i].Method == snapshot.TaskDurations[j].Method {
+			return snapshot.TaskDurations[i].State < snapshot.TaskDurations[j].State
+		}
+		return snapshot.TaskDurations[i].Method < snapshot.TaskDurations[j].Method
+	})
+
+	return snapshot
+}
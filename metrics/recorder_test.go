@@ -0,0 +1,49 @@
+package metrics_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/metrics"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		recorder Recorder
+	)
+
+	BeforeEach(func() {
+		recorder = NewRecorder()
+	})
+
+	It("counts dispatched actions per method", func() {
+		recorder.ActionDispatched("apply")
+		recorder.ActionDispatched("apply")
+		recorder.ActionDispatched("get_task")
+
+		Expect(recorder.Snapshot().ActionDispatches).To(Equal(map[string]uint64{
+			"apply":    2,
+			"get_task": 1,
+		}))
+	})
+
+	It("sums task durations per method and state", func() {
+		recorder.TaskFinished("fetch_logs", "failed", time.Second)
+		recorder.TaskFinished("apply", "done", time.Second)
+		recorder.TaskFinished("apply", "done", 2*time.Second)
+
+		Expect(recorder.Snapshot().TaskDurations).To(Equal([]TaskDurations{
+			{Method: "apply", State: "done", Count: 2, Sum: 3 * time.Second},
+			{Method: "fetch_logs", State: "failed", Count: 1, Sum: time.Second},
+		}))
+	})
+
+	It("counts heartbeat send failures", func() {
+		recorder.HeartbeatSendFailed()
+		recorder.HeartbeatSendFailed()
+
+		Expect(recorder.Snapshot().HeartbeatSendFailures).To(Equal(uint64(2)))
+	})
+})
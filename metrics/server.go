@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const serverLogTag = "Metrics Server"
+
+type Server interface {
+	Start() error
+	Stop() error
+}
+
+type concreteServer struct {
+	address string
+	handler http.Handler
+	logger  boshlog.Logger
+
+	server *http.Server
+}
+
+func NewServer(address string, handler http.Handler, logger boshlog.Logger) Server {
+	return &concreteServer{
+		address: address,
+		handler: handler,
+		logger:  logger,
+	}
+}
+
+func (s *concreteServer) Start() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.handler)
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on %s", s.address)
+	}
+
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		defer s.logger.HandlePanic("Metrics Server")
+
+		s.logger.Info(serverLogTag, "Serving metrics on %s", listener.Addr().String())
+
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Error(serverLogTag, "Serving metrics: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (s *concreteServer) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+
+	return s.server.Close()
+}
@@ -1,6 +1,8 @@
 package notification
 
 import (
+	"errors"
+
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 )
 
@@ -13,5 +15,9 @@ func NewNotifier(handler boshhandler.Handler) Notifier {
 }
 
 func (n concreteNotifier) NotifyShutdown() error {
-	return n.handler.Send(boshhandler.HealthMonitor, boshhandler.Shutdown, nil)
+	err := n.handler.Send(boshhandler.HealthMonitor, boshhandler.Shutdown, nil)
+	if errors.Is(err, boshhandler.ErrSendNotSupported) {
+		return nil
+	}
+	return err
 }
@@ -43,5 +43,12 @@ var _ = Describe("concreteNotifier", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-send-error"))
 		})
+
+		It("does not fail when the handler cannot send messages", func() {
+			handler.SendErr = boshhandler.ErrSendNotSupported
+
+			err := notifier.NotifyShutdown()
+			Expect(err).ToNot(HaveOccurred())
+		})
 	})
 })
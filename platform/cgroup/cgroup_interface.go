@@ -0,0 +1,77 @@
+package cgroup
+
+type Version int
+
+const (
+	VersionUnsupported Version = 0
+	V1                 Version = 1
+	V2                 Version = 2
+
+	DefaultMountPoint = "/sys/fs/cgroup"
+)
+
+// Resources are limits applied to a cgroup. Zero values leave the
+// corresponding limit unset.
+type Resources struct {
+	// CPUs caps CPU bandwidth at the given number of CPUs, e.g. 1.5
+	CPUs float64 `json:"cpus,omitempty"`
+
+	// CPUWeight is the relative share of CPU time between 1 and 10000
+	// as in cgroup v2; it is converted to cpu.shares on cgroup v1
+	CPUWeight uint64 `json:"cpu_weight,omitempty"`
+
+	MemoryBytes int64 `json:"memory_bytes,omitempty"`
+	PidsMax     int64 `json:"pids_max,omitempty"`
+
+	// MemoryReservationBytes is protected from reclaim on cgroup v2
+	// (memory.min); cgroup v1 only offers a soft limit instead
+	MemoryReservationBytes int64 `json:"memory_reservation_bytes,omitempty"`
+}
+
+type Stats struct {
+	CPUUsageNanos uint64 `json:"cpu_usage_ns"`
+
+	// MemoryBytes includes the page cache charged to the cgroup
+	MemoryBytes uint64 `json:"memory_bytes"`
+
+	Pids uint64 `json:"pids"`
+
+	IOReadBytes  uint64 `json:"io_read_bytes"`
+	IOWriteBytes uint64 `json:"io_write_bytes"`
+
+	// OOMKills counts processes killed for exceeding the memory limit
+	OOMKills uint64 `json:"oom_kills"`
+
+	// PidsLimitHits counts forks refused because of the pids limit
+	PidsLimitHits uint64 `json:"pids_limit_hits"`
+}
+
+type Manager interface {
+	Version() Version
+
+	// Create creates the cgroup at path, relative to the root of the
+	// hierarchy, or updates the resources of an existing one
+	Create(path string, resources Resources) (Cgroup, error)
+
+	// Get returns the cgroup at path without changing it. The cgroup
+	// does not have to exist.
+	Get(path string) (Cgroup, error)
+}
+
+type Cgroup interface {
+	Path() string
+
+	// ProcsFiles lists the files a pid has to be written to for a process
+	// to join the cgroup, one per hierarchy
+	ProcsFiles() []string
+
+	AddProcess(pid int) error
+	Stats() (Stats, error)
+
+	// Pids lists the processes in the cgroup
+	Pids() ([]int, error)
+
+	// Kill kills all processes in the cgroup
+	Kill() error
+	Delete() error
+}
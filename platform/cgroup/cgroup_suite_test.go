@@ -0,0 +1,13 @@
+package cgroup_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCgroup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cgroup Suite")
+}
@@ -0,0 +1,120 @@
+package fakes
+
+import (
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+)
+
+type FakeManager struct {
+	VersionValue boshcgroup.Version
+
+	Cgroups   map[string]*FakeCgroup
+	Resources map[string]boshcgroup.Resources
+	CreateErr error
+	GetErr    error
+}
+
+func NewFakeManager() *FakeManager {
+	return &FakeManager{
+		VersionValue: boshcgroup.V2,
+		Cgroups:      map[string]*FakeCgroup{},
+		Resources:    map[string]boshcgroup.Resources{},
+	}
+}
+
+func (m *FakeManager) Version() boshcgroup.Version {
+	return m.VersionValue
+}
+
+func (m *FakeManager) Create(path string, resources boshcgroup.Resources) (boshcgroup.Cgroup, error) {
+	if m.CreateErr != nil {
+		return nil, m.CreateErr
+	}
+
+	m.Resources[path] = resources
+
+	cg, found := m.Cgroups[path]
+	if !found {
+		cg = NewFakeCgroup(path)
+		m.Cgroups[path] = cg
+	}
+
+	return cg, nil
+}
+
+func (m *FakeManager) Get(path string) (boshcgroup.Cgroup, error) {
+	if m.GetErr != nil {
+		return nil, m.GetErr
+	}
+
+	cg, found := m.Cgroups[path]
+	if !found {
+		cg = NewFakeCgroup(path)
+		m.Cgroups[path] = cg
+	}
+
+	return cg, nil
+}
+
+type FakeCgroup struct {
+	PathValue       string
+	ProcsFilesValue []string
+
+	AddedPids     []int
+	AddProcessErr error
+
+	StatsValue boshcgroup.Stats
+	StatsErr   error
+
+	// PidsValues are returned one after another, the last one repeatedly
+	PidsValues [][]int
+	PidsErr    error
+
+	Killed  bool
+	KillErr error
+
+	Deleted   bool
+	DeleteErr error
+}
+
+func NewFakeCgroup(path string) *FakeCgroup {
+	return &FakeCgroup{
+		PathValue:       path,
+		ProcsFilesValue: []string{"/sys/fs/cgroup/" + path + "/cgroup.procs"},
+	}
+}
+
+func (c *FakeCgroup) Path() string { return c.PathValue }
+
+func (c *FakeCgroup) ProcsFiles() []string { return c.ProcsFilesValue }
+
+func (c *FakeCgroup) AddProcess(pid int) error {
+	c.AddedPids = append(c.AddedPids, pid)
+	return c.AddProcessErr
+}
+
+func (c *FakeCgroup) Stats() (boshcgroup.Stats, error) {
+	return c.StatsValue, c.StatsErr
+}
+
+func (c *FakeCgroup) Pids() ([]int, error) {
+	if len(c.PidsValues) == 0 {
+		return []int{}, c.PidsErr
+	}
+
+	pids := c.PidsValues[0]
+	if len(c.PidsValues) > 1 {
+		c.PidsValues = c.PidsValues[1:]
+	}
+
+	return pids, c.PidsErr
+}
+
+func (c *FakeCgroup) Kill() error {
+	c.Killed = true
+	return c.KillErr
+}
+
+func (c *FakeCgroup) Delete() error {
+	c.Deleted = true
+	return c.DeleteErr
+}
@@ -0,0 +1,156 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const cpuPeriodMicros = 100000
+
+type manager struct {
+	fs         boshsys.FileSystem
+	mountPoint string
+}
+
+// NewManager manages cgroups below mountPoint by writing to the cgroup
+// filesystem directly. Both the unified (v2) and the legacy (v1)
+// hierarchies are supported.
+func NewManager(fs boshsys.FileSystem, mountPoint string) Manager {
+	return manager{
+		fs:         fs,
+		mountPoint: mountPoint,
+	}
+}
+
+func (m manager) Version() Version {
+	if m.fs.FileExists(filepath.Join(m.mountPoint, "cgroup.controllers")) {
+		return V2
+	}
+
+	if m.fs.FileExists(filepath.Join(m.mountPoint, "memory")) {
+		return V1
+	}
+
+	return VersionUnsupported
+}
+
+func (m manager) Create(path string, resources Resources) (Cgroup, error) {
+	cg, err := m.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cg.(resourceSetter).setup(resources)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Setting up cgroup %s", path)
+	}
+
+	return cg, nil
+}
+
+func (m manager) Get(path string) (Cgroup, error) {
+	switch m.Version() {
+	case V2:
+		return v2Cgroup{fs: m.fs, mountPoint: m.mountPoint, path: path}, nil
+	case V1:
+		return v1Cgroup{fs: m.fs, mountPoint: m.mountPoint, path: path}, nil
+	default:
+		return nil, bosherr.Errorf("No cgroup hierarchy mounted at %s", m.mountPoint)
+	}
+}
+
+type resourceSetter interface {
+	setup(resources Resources) error
+}
+
+func writeValue(fs boshsys.FileSystem, path, value string) error {
+	err := fs.WriteFileString(path, value)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s' to %s", value, path)
+	}
+	return nil
+}
+
+func readUint(fs boshsys.FileSystem, path string) (uint64, error) {
+	contents, err := fs.ReadFileString(path)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading %s", path)
+	}
+
+	value := strings.TrimSpace(contents)
+	if value == "max" {
+		return 0, nil
+	}
+
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing %s", path)
+	}
+
+	return number, nil
+}
+
+// readKeyedUint returns the value of key in flat keyed files like
+// memory.events, or zero if key is missing or the file does not exist
+func readKeyedUint(fs boshsys.FileSystem, path, key string) (uint64, error) {
+	if !fs.FileExists(path) {
+		return 0, nil
+	}
+
+	contents, err := fs.ReadFileString(path)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading %s", path)
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			number, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, bosherr.WrapErrorf(err, "Parsing %s in %s", key, path)
+			}
+			return number, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func readPids(fs boshsys.FileSystem, procsFile string) ([]int, error) {
+	contents, err := fs.ReadFileString(procsFile)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading %s", procsFile)
+	}
+
+	pids := []int{}
+	for _, line := range strings.Fields(contents) {
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing pid in %s", procsFile)
+		}
+		pids = append(pids, pid)
+	}
+
+	return pids, nil
+}
+
+func killPids(pids []int) error {
+	for _, pid := range pids {
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+
+		err = process.Kill()
+		if err != nil && err != os.ErrProcessDone {
+			return bosherr.WrapErrorf(err, "Killing process %d", pid)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package cgroup_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		manager boshcgroup.Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		manager = boshcgroup.NewManager(fs, "/sys/fs/cgroup")
+	})
+
+	readFile := func(path string) string {
+		contents, err := fs.ReadFileString(path)
+		Expect(err).ToNot(HaveOccurred())
+		return contents
+	}
+
+	Describe("Version", func() {
+		It("detects the unified hierarchy", func() {
+			Expect(fs.WriteFileString("/sys/fs/cgroup/cgroup.controllers", "cpu memory")).To(Succeed())
+			Expect(manager.Version()).To(Equal(boshcgroup.V2))
+		})
+
+		It("detects the legacy hierarchy", func() {
+			Expect(fs.MkdirAll("/sys/fs/cgroup/memory", 0755)).To(Succeed())
+			Expect(manager.Version()).To(Equal(boshcgroup.V1))
+		})
+
+		It("reports missing hierarchies", func() {
+			Expect(manager.Version()).To(Equal(boshcgroup.VersionUnsupported))
+
+			_, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+			Expect(err).To(MatchError("No cgroup hierarchy mounted at /sys/fs/cgroup"))
+		})
+	})
+
+	Context("with cgroup v2", func() {
+		BeforeEach(func() {
+			Expect(fs.WriteFileString("/sys/fs/cgroup/cgroup.controllers", "cpuset cpu io memory pids")).To(Succeed())
+			Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/cgroup.controllers", "cpu memory pids")).To(Succeed())
+		})
+
+		Describe("Create", func() {
+			It("delegates controllers and writes the limits", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{
+					CPUs:                   1.5,
+					CPUWeight:              200,
+					MemoryBytes:            1024,
+					PidsMax:                64,
+					MemoryReservationBytes: 512,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cg.Path()).To(Equal("bosh/fake"))
+				Expect(cg.ProcsFiles()).To(Equal([]string{"/sys/fs/cgroup/bosh/fake/cgroup.procs"}))
+
+				Expect(readFile("/sys/fs/cgroup/cgroup.subtree_control")).To(Equal("+cpu +memory +pids +io"))
+				Expect(readFile("/sys/fs/cgroup/bosh/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/cpu.max")).To(Equal("150000 100000"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/cpu.weight")).To(Equal("200"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/memory.max")).To(Equal("1024"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/memory.min")).To(Equal("512"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/pids.max")).To(Equal("64"))
+			})
+
+			It("removes limits that are not set", func() {
+				_, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/cpu.max")).To(Equal("max 100000"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/memory.max")).To(Equal("max"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/memory.min")).To(Equal("0"))
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/pids.max")).To(Equal("max"))
+				Expect(fs.FileExists("/sys/fs/cgroup/bosh/fake/cpu.weight")).To(BeFalse())
+			})
+
+			It("returns an error when writing a limit fails", func() {
+				fs.WriteFileErrors["/sys/fs/cgroup/bosh/fake/memory.max"] = errors.New("fake-write-err")
+
+				_, err := manager.Create("bosh/fake", boshcgroup.Resources{MemoryBytes: 1024})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			})
+		})
+
+		Describe("Get", func() {
+			It("returns the cgroup without creating it", func() {
+				cg, err := manager.Get("bosh/fake")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cg.ProcsFiles()).To(Equal([]string{"/sys/fs/cgroup/bosh/fake/cgroup.procs"}))
+
+				Expect(fs.FileExists("/sys/fs/cgroup/bosh/fake")).To(BeFalse())
+				Expect(fs.FileExists("/sys/fs/cgroup/cgroup.subtree_control")).To(BeFalse())
+			})
+		})
+
+		Describe("Stats", func() {
+			It("reads usage and limit events", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/cpu.stat", "usage_usec 1500\nuser_usec 1000\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/memory.current", "4096\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/pids.current", "3\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/io.stat", "8:0 rbytes=10 wbytes=20 rios=1 wios=2\n8:16 rbytes=1 wbytes=2\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/memory.events", "low 0\nhigh 0\nmax 4\noom 1\noom_kill 1\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/pids.events", "max 2\n")).To(Succeed())
+
+				stats, err := cg.Stats()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stats).To(Equal(boshcgroup.Stats{
+					CPUUsageNanos: 1500000,
+					MemoryBytes:   4096,
+					Pids:          3,
+					IOReadBytes:   11,
+					IOWriteBytes:  22,
+					OOMKills:      1,
+					PidsLimitHits: 2,
+				}))
+			})
+		})
+
+		Describe("AddProcess", func() {
+			It("writes the pid to cgroup.procs", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cg.AddProcess(123)).To(Succeed())
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/cgroup.procs")).To(Equal("123"))
+			})
+		})
+
+		Describe("Pids", func() {
+			It("reads cgroup.procs", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/cgroup.procs", "123\n456\n")).To(Succeed())
+
+				Expect(cg.Pids()).To(Equal([]int{123, 456}))
+			})
+		})
+
+		Describe("Kill", func() {
+			It("uses cgroup.kill when available", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/bosh/fake/cgroup.kill", "")).To(Succeed())
+
+				Expect(cg.Kill()).To(Succeed())
+				Expect(readFile("/sys/fs/cgroup/bosh/fake/cgroup.kill")).To(Equal("1"))
+			})
+		})
+
+		Describe("Delete", func() {
+			It("removes the cgroup directory", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cg.Delete()).To(Succeed())
+				Expect(fs.FileExists("/sys/fs/cgroup/bosh/fake")).To(BeFalse())
+			})
+		})
+	})
+
+	Context("with cgroup v1", func() {
+		BeforeEach(func() {
+			for _, controller := range []string{"cpu", "cpuacct", "memory", "pids", "blkio"} {
+				Expect(fs.MkdirAll("/sys/fs/cgroup/"+controller, 0755)).To(Succeed())
+			}
+		})
+
+		Describe("Create", func() {
+			It("writes the limits to the controller hierarchies", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{
+					CPUs:                   0.5,
+					CPUWeight:              100,
+					MemoryBytes:            1024,
+					PidsMax:                64,
+					MemoryReservationBytes: 512,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cg.ProcsFiles()).To(Equal([]string{
+					"/sys/fs/cgroup/cpu/bosh/fake/cgroup.procs",
+					"/sys/fs/cgroup/cpuacct/bosh/fake/cgroup.procs",
+					"/sys/fs/cgroup/memory/bosh/fake/cgroup.procs",
+					"/sys/fs/cgroup/pids/bosh/fake/cgroup.procs",
+					"/sys/fs/cgroup/blkio/bosh/fake/cgroup.procs",
+				}))
+
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh/fake/cpu.cfs_period_us")).To(Equal("100000"))
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh/fake/cpu.cfs_quota_us")).To(Equal("50000"))
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh/fake/cpu.shares")).To(Equal("2597"))
+				Expect(readFile("/sys/fs/cgroup/memory/bosh/fake/memory.limit_in_bytes")).To(Equal("1024"))
+				Expect(readFile("/sys/fs/cgroup/memory/bosh/fake/memory.soft_limit_in_bytes")).To(Equal("512"))
+				Expect(readFile("/sys/fs/cgroup/pids/bosh/fake/pids.max")).To(Equal("64"))
+			})
+
+			It("only lists hierarchies shared by several controllers once", func() {
+				Expect(fs.RemoveAll("/sys/fs/cgroup/cpuacct")).To(Succeed())
+				Expect(fs.Symlink("/sys/fs/cgroup/cpu", "/sys/fs/cgroup/cpuacct")).To(Succeed())
+
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cg.ProcsFiles()).ToNot(ContainElement("/sys/fs/cgroup/cpuacct/bosh/fake/cgroup.procs"))
+			})
+
+			It("returns an error when a limit needs a controller that is not mounted", func() {
+				Expect(fs.RemoveAll("/sys/fs/cgroup/pids")).To(Succeed())
+
+				_, err := manager.Create("bosh/fake", boshcgroup.Resources{PidsMax: 64})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("The pids cgroup controller is not mounted"))
+			})
+		})
+
+		Describe("Stats", func() {
+			It("reads usage and limit events", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.WriteFileString("/sys/fs/cgroup/cpuacct/bosh/fake/cpuacct.usage", "1500\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/memory/bosh/fake/memory.usage_in_bytes", "4096\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/memory/bosh/fake/memory.oom_control", "oom_kill_disable 0\nunder_oom 0\noom_kill 2\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/pids/bosh/fake/pids.current", "3\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/pids/bosh/fake/pids.events", "max 1\n")).To(Succeed())
+				Expect(fs.WriteFileString("/sys/fs/cgroup/blkio/bosh/fake/blkio.throttle.io_service_bytes", "8:0 Read 10\n8:0 Write 20\n8:0 Total 30\nTotal 30\n")).To(Succeed())
+
+				stats, err := cg.Stats()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stats).To(Equal(boshcgroup.Stats{
+					CPUUsageNanos: 1500,
+					MemoryBytes:   4096,
+					Pids:          3,
+					IOReadBytes:   10,
+					IOWriteBytes:  20,
+					OOMKills:      2,
+					PidsLimitHits: 1,
+				}))
+			})
+		})
+
+		Describe("AddProcess", func() {
+			It("writes the pid to every hierarchy", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cg.AddProcess(123)).To(Succeed())
+				Expect(readFile("/sys/fs/cgroup/memory/bosh/fake/cgroup.procs")).To(Equal("123"))
+				Expect(readFile("/sys/fs/cgroup/blkio/bosh/fake/cgroup.procs")).To(Equal("123"))
+			})
+		})
+
+		Describe("Pids", func() {
+			It("lists the processes of every hierarchy once", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+				for _, procsFile := range cg.ProcsFiles() {
+					Expect(fs.WriteFileString(procsFile, "123\n")).To(Succeed())
+				}
+				Expect(fs.WriteFileString("/sys/fs/cgroup/blkio/bosh/fake/cgroup.procs", "123\n456\n")).To(Succeed())
+
+				pids, err := cg.Pids()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pids).To(ConsistOf(123, 456))
+			})
+		})
+
+		Describe("Delete", func() {
+			It("removes the cgroup from every hierarchy", func() {
+				cg, err := manager.Create("bosh/fake", boshcgroup.Resources{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cg.Delete()).To(Succeed())
+				Expect(fs.FileExists("/sys/fs/cgroup/cpu/bosh/fake")).To(BeFalse())
+				Expect(fs.FileExists("/sys/fs/cgroup/memory/bosh/fake")).To(BeFalse())
+			})
+		})
+	})
+})
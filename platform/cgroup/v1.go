@@ -0,0 +1,262 @@
+package cgroup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var v1Controllers = []string{"cpu", "cpuacct", "memory", "pids", "blkio"}
+
+type v1Cgroup struct {
+	fs         boshsys.FileSystem
+	mountPoint string
+	path       string
+}
+
+func (c v1Cgroup) Path() string { return c.path }
+
+func (c v1Cgroup) dir(controller string) string {
+	return filepath.Join(c.mountPoint, controller, c.path)
+}
+
+// controllers returns the mounted controllers. cpu and cpuacct usually
+// share a hierarchy and are only listed once in that case.
+func (c v1Cgroup) controllers() []string {
+	controllers := []string{}
+	seen := map[string]bool{}
+
+	for _, controller := range v1Controllers {
+		controllerRoot := filepath.Join(c.mountPoint, controller)
+		if !c.fs.FileExists(controllerRoot) {
+			continue
+		}
+
+		target, err := c.fs.ReadAndFollowLink(controllerRoot)
+		if err != nil {
+			target = controllerRoot
+		}
+
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		controllers = append(controllers, controller)
+	}
+
+	return controllers
+}
+
+func (c v1Cgroup) ProcsFiles() []string {
+	procsFiles := []string{}
+	for _, controller := range c.controllers() {
+		procsFiles = append(procsFiles, filepath.Join(c.dir(controller), "cgroup.procs"))
+	}
+	return procsFiles
+}
+
+func (c v1Cgroup) setup(resources Resources) error {
+	for _, controller := range c.controllers() {
+		err := c.fs.MkdirAll(c.dir(controller), 0755)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating %s", c.dir(controller))
+		}
+	}
+
+	quota := "-1"
+	if resources.CPUs > 0 {
+		quota = strconv.FormatInt(int64(resources.CPUs*cpuPeriodMicros), 10)
+	}
+
+	memoryLimit := "-1"
+	if resources.MemoryBytes > 0 {
+		memoryLimit = strconv.FormatInt(resources.MemoryBytes, 10)
+	}
+
+	memoryReservation := "-1"
+	if resources.MemoryReservationBytes > 0 {
+		memoryReservation = strconv.FormatInt(resources.MemoryReservationBytes, 10)
+	}
+
+	values := [][3]string{
+		{"cpu", "cpu.cfs_period_us", strconv.Itoa(cpuPeriodMicros)},
+		{"cpu", "cpu.cfs_quota_us", quota},
+		{"memory", "memory.limit_in_bytes", memoryLimit},
+		{"memory", "memory.soft_limit_in_bytes", memoryReservation},
+		{"pids", "pids.max", limitOrMax(resources.PidsMax)},
+	}
+
+	if resources.CPUWeight > 0 {
+		values = append(values, [3]string{"cpu", "cpu.shares", strconv.FormatUint(cpuWeightToShares(resources.CPUWeight), 10)})
+	}
+
+	for _, value := range values {
+		dir := c.dir(value[0])
+		if !c.fs.FileExists(dir) {
+			if value[2] == "-1" || value[2] == "max" || value[1] == "cpu.cfs_period_us" {
+				continue
+			}
+			return bosherr.Errorf("The %s cgroup controller is not mounted", value[0])
+		}
+
+		err := writeValue(c.fs, filepath.Join(dir, value[1]), value[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cpuWeightToShares maps the cgroup v2 weight range [1, 10000] onto the
+// v1 shares range [2, 262144], the inverse of what container runtimes do
+func cpuWeightToShares(weight uint64) uint64 {
+	return 2 + ((weight-1)*262142)/9999
+}
+
+func (c v1Cgroup) AddProcess(pid int) error {
+	for _, procsFile := range c.ProcsFiles() {
+		err := writeValue(c.fs, procsFile, strconv.Itoa(pid))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c v1Cgroup) Stats() (Stats, error) {
+	var stats Stats
+	var err error
+
+	if dir := c.dir("cpuacct"); c.fs.FileExists(dir) {
+		stats.CPUUsageNanos, err = readUint(c.fs, filepath.Join(dir, "cpuacct.usage"))
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	if dir := c.dir("memory"); c.fs.FileExists(dir) {
+		stats.MemoryBytes, err = readUint(c.fs, filepath.Join(dir, "memory.usage_in_bytes"))
+		if err != nil {
+			return stats, err
+		}
+
+		stats.OOMKills, err = readKeyedUint(c.fs, filepath.Join(dir, "memory.oom_control"), "oom_kill")
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	if dir := c.dir("pids"); c.fs.FileExists(dir) {
+		stats.Pids, err = readUint(c.fs, filepath.Join(dir, "pids.current"))
+		if err != nil {
+			return stats, err
+		}
+
+		stats.PidsLimitHits, err = readKeyedUint(c.fs, filepath.Join(dir, "pids.events"), "max")
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	stats.IOReadBytes, stats.IOWriteBytes, err = c.ioBytes()
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// ioBytes sums up blkio lines like "8:0 Read 1024"
+func (c v1Cgroup) ioBytes() (uint64, uint64, error) {
+	ioServiceBytesPath := filepath.Join(c.dir("blkio"), "blkio.throttle.io_service_bytes")
+	if !c.fs.FileExists(ioServiceBytesPath) {
+		return 0, 0, nil
+	}
+
+	contents, err := c.fs.ReadFileString(ioServiceBytesPath)
+	if err != nil {
+		return 0, 0, bosherr.WrapErrorf(err, "Reading %s", ioServiceBytesPath)
+	}
+
+	var readBytes, writeBytes uint64
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		number, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[1] {
+		case "Read":
+			readBytes += number
+		case "Write":
+			writeBytes += number
+		}
+	}
+
+	return readBytes, writeBytes, nil
+}
+
+// Pids lists processes of all hierarchies, which differ while a
+// process is being moved
+func (c v1Cgroup) Pids() ([]int, error) {
+	seen := map[int]bool{}
+	pids := []int{}
+
+	for _, procsFile := range c.ProcsFiles() {
+		procsFilePids, err := readPids(c.fs, procsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pid := range procsFilePids {
+			if !seen[pid] {
+				seen[pid] = true
+				pids = append(pids, pid)
+			}
+		}
+	}
+
+	return pids, nil
+}
+
+func (c v1Cgroup) Kill() error {
+	for _, procsFile := range c.ProcsFiles() {
+		pids, err := readPids(c.fs, procsFile)
+		if err != nil {
+			return err
+		}
+
+		err = killPids(pids)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c v1Cgroup) Delete() error {
+	for _, controller := range c.controllers() {
+		err := c.fs.RemoveAll(c.dir(controller))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing %s cgroup %s", controller, c.path)
+		}
+	}
+	return nil
+}
+
+func (c v1Cgroup) String() string {
+	return fmt.Sprintf("cgroup v1 %s", c.path)
+}
@@ -0,0 +1,227 @@
+package cgroup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var v2Controllers = []string{"cpu", "memory", "pids", "io"}
+
+type v2Cgroup struct {
+	fs         boshsys.FileSystem
+	mountPoint string
+	path       string
+}
+
+func (c v2Cgroup) Path() string { return c.path }
+
+func (c v2Cgroup) dir() string { return filepath.Join(c.mountPoint, c.path) }
+
+func (c v2Cgroup) ProcsFiles() []string {
+	return []string{filepath.Join(c.dir(), "cgroup.procs")}
+}
+
+func (c v2Cgroup) setup(resources Resources) error {
+	err := c.enableControllers()
+	if err != nil {
+		return err
+	}
+
+	err = c.fs.MkdirAll(c.dir(), 0755)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating %s", c.dir())
+	}
+
+	cpuMax := fmt.Sprintf("max %d", cpuPeriodMicros)
+	if resources.CPUs > 0 {
+		cpuMax = fmt.Sprintf("%d %d", int64(resources.CPUs*cpuPeriodMicros), cpuPeriodMicros)
+	}
+
+	memoryMin := "0"
+	if resources.MemoryReservationBytes > 0 {
+		memoryMin = strconv.FormatInt(resources.MemoryReservationBytes, 10)
+	}
+
+	values := [][2]string{{"cpu.max", cpuMax}}
+
+	if resources.CPUWeight > 0 {
+		values = append(values, [2]string{"cpu.weight", strconv.FormatUint(resources.CPUWeight, 10)})
+	}
+
+	values = append(values,
+		[2]string{"memory.max", limitOrMax(resources.MemoryBytes)},
+		[2]string{"memory.min", memoryMin},
+		[2]string{"pids.max", limitOrMax(resources.PidsMax)},
+	)
+
+	for _, value := range values {
+		err = writeValue(c.fs, filepath.Join(c.dir(), value[0]), value[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enableControllers delegates the controllers to every level between the
+// root and the cgroup; controllers the kernel does not offer are skipped
+func (c v2Cgroup) enableControllers() error {
+	parents := []string{c.mountPoint}
+
+	for _, segment := range strings.Split(filepath.Dir(filepath.Clean(c.path)), string(filepath.Separator)) {
+		if segment != "" && segment != "." {
+			parents = append(parents, filepath.Join(parents[len(parents)-1], segment))
+		}
+	}
+
+	for _, parent := range parents {
+		err := c.fs.MkdirAll(parent, 0755)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating %s", parent)
+		}
+
+		available, err := c.fs.ReadFileString(filepath.Join(parent, "cgroup.controllers"))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading controllers of %s", parent)
+		}
+
+		enable := []string{}
+		for _, controller := range v2Controllers {
+			for _, availableController := range strings.Fields(available) {
+				if controller == availableController {
+					enable = append(enable, "+"+controller)
+				}
+			}
+		}
+
+		if len(enable) == 0 {
+			continue
+		}
+
+		err = writeValue(c.fs, filepath.Join(parent, "cgroup.subtree_control"), strings.Join(enable, " "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c v2Cgroup) AddProcess(pid int) error {
+	return writeValue(c.fs, filepath.Join(c.dir(), "cgroup.procs"), strconv.Itoa(pid))
+}
+
+func (c v2Cgroup) Stats() (Stats, error) {
+	var stats Stats
+	var err error
+
+	usageMicros, err := readKeyedUint(c.fs, filepath.Join(c.dir(), "cpu.stat"), "usage_usec")
+	if err != nil {
+		return stats, err
+	}
+	stats.CPUUsageNanos = usageMicros * 1000
+
+	stats.MemoryBytes, err = readUint(c.fs, filepath.Join(c.dir(), "memory.current"))
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Pids, err = readUint(c.fs, filepath.Join(c.dir(), "pids.current"))
+	if err != nil {
+		return stats, err
+	}
+
+	stats.IOReadBytes, stats.IOWriteBytes, err = c.ioBytes()
+	if err != nil {
+		return stats, err
+	}
+
+	stats.OOMKills, err = readKeyedUint(c.fs, filepath.Join(c.dir(), "memory.events"), "oom_kill")
+	if err != nil {
+		return stats, err
+	}
+
+	stats.PidsLimitHits, err = readKeyedUint(c.fs, filepath.Join(c.dir(), "pids.events"), "max")
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// ioBytes sums up io.stat lines like "8:0 rbytes=1024 wbytes=2048 rios=1 ..."
+func (c v2Cgroup) ioBytes() (uint64, uint64, error) {
+	ioStatPath := filepath.Join(c.dir(), "io.stat")
+	if !c.fs.FileExists(ioStatPath) {
+		return 0, 0, nil
+	}
+
+	contents, err := c.fs.ReadFileString(ioStatPath)
+	if err != nil {
+		return 0, 0, bosherr.WrapErrorf(err, "Reading %s", ioStatPath)
+	}
+
+	var readBytes, writeBytes uint64
+
+	for _, line := range strings.Split(contents, "\n") {
+		for _, field := range strings.Fields(line) {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			number, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			switch key {
+			case "rbytes":
+				readBytes += number
+			case "wbytes":
+				writeBytes += number
+			}
+		}
+	}
+
+	return readBytes, writeBytes, nil
+}
+
+func (c v2Cgroup) Pids() ([]int, error) {
+	return readPids(c.fs, filepath.Join(c.dir(), "cgroup.procs"))
+}
+
+func (c v2Cgroup) Kill() error {
+	killPath := filepath.Join(c.dir(), "cgroup.kill")
+	if c.fs.FileExists(killPath) {
+		return writeValue(c.fs, killPath, "1")
+	}
+
+	pids, err := readPids(c.fs, filepath.Join(c.dir(), "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+
+	return killPids(pids)
+}
+
+func (c v2Cgroup) Delete() error {
+	err := c.fs.RemoveAll(c.dir())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing cgroup %s", c.path)
+	}
+	return nil
+}
+
+func limitOrMax(limit int64) string {
+	if limit <= 0 {
+		return "max"
+	}
+	return strconv.FormatInt(limit, 10)
+}
@@ -0,0 +1,131 @@
+package disk
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// ErrHealthNotChecked is returned for partitions whose health
+// has not been checked in the background yet
+var ErrHealthNotChecked = errors.New("health has not been checked yet")
+
+type cachedHealth struct {
+	health      Health
+	err         error
+	requestedAt time.Time
+}
+
+// CachingHealthChecker checks the health of partitions in the background
+// every interval so that reading it never waits for slow devices.
+// Partitions are checked from the first time their health is read until
+// it has not been read for two intervals.
+type CachingHealthChecker struct {
+	checker     HealthChecker
+	interval    time.Duration
+	timeService clock.Clock
+
+	lock    *sync.Mutex
+	cache   map[string]cachedHealth
+	checkCh chan struct{}
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewCachingHealthChecker(
+	checker HealthChecker,
+	interval time.Duration,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) CachingHealthChecker {
+	return CachingHealthChecker{
+		checker:     checker,
+		interval:    interval,
+		timeService: timeService,
+
+		lock:    &sync.Mutex{},
+		cache:   map[string]cachedHealth{},
+		checkCh: make(chan struct{}, 1),
+
+		logTag: "cachingHealthChecker",
+		logger: logger,
+	}
+}
+
+// CheckHealth returns the health found by the last check of partitionPath
+func (c CachingHealthChecker) CheckHealth(partitionPath string) (Health, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cached, found := c.cache[partitionPath]
+	if !found {
+		cached.err = ErrHealthNotChecked
+
+		// New partitions are checked right away rather than after the interval
+		select {
+		case c.checkCh <- struct{}{}:
+		default:
+		}
+	}
+
+	cached.requestedAt = c.timeService.Now()
+	c.cache[partitionPath] = cached
+
+	return cached.health, cached.err
+}
+
+func (c CachingHealthChecker) StartChecking() {
+	go c.checkPeriodically()
+}
+
+func (c CachingHealthChecker) checkPeriodically() {
+	defer c.logger.HandlePanic("Caching Health Checker")
+
+	ticker := c.timeService.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-c.checkCh:
+		}
+
+		for _, partitionPath := range c.requestedPartitions() {
+			health, err := c.checker.CheckHealth(partitionPath)
+			if err != nil {
+				c.logger.Debug(c.logTag, "Checking health of %s: %s", partitionPath, err.Error())
+			}
+
+			c.lock.Lock()
+			if cached, found := c.cache[partitionPath]; found {
+				cached.health, cached.err = health, err
+				c.cache[partitionPath] = cached
+			}
+			c.lock.Unlock()
+		}
+	}
+}
+
+// requestedPartitions forgets partitions whose health is no longer read,
+// e.g. of persistent disks that were unmounted
+func (c CachingHealthChecker) requestedPartitions() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var partitionPaths []string
+
+	for partitionPath, cached := range c.cache {
+		if c.timeService.Since(cached.requestedAt) > 2*c.interval {
+			delete(c.cache, partitionPath)
+			continue
+		}
+
+		partitionPaths = append(partitionPaths, partitionPath)
+	}
+
+	return partitionPaths
+}
@@ -0,0 +1,80 @@
+package disk_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("CachingHealthChecker", func() {
+	var (
+		checker       *fakedisk.FakeHealthChecker
+		fakeClock     *fakeclock.FakeClock
+		healthChecker CachingHealthChecker
+	)
+
+	BeforeEach(func() {
+		checker = fakedisk.NewFakeHealthChecker()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		healthChecker = NewCachingHealthChecker(checker, time.Minute, fakeClock, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	// Copied so that checks still running after the spec do not
+	// race with the next spec reassigning the variables
+	checkedHealth := func() func() (Health, error) {
+		healthChecker := healthChecker
+		return func() (Health, error) { return healthChecker.CheckHealth("/dev/sdb1") }
+	}
+
+	It("returns an error for partitions that have not been checked yet", func() {
+		_, err := healthChecker.CheckHealth("/dev/sdb1")
+		Expect(err).To(Equal(ErrHealthNotChecked))
+		Expect(checker.PartitionPaths()).To(BeEmpty())
+	})
+
+	Context("when checking", func() {
+		BeforeEach(func() {
+			checker.SetHealth("/dev/sdb1", Health{Status: HealthStatusOK})
+			healthChecker.StartChecking()
+		})
+
+		It("checks new partitions right away and returns their last health", func() {
+			_, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).To(Equal(ErrHealthNotChecked))
+
+			Eventually(checkedHealth()).Should(Equal(Health{Status: HealthStatusOK}))
+			Expect(checker.PartitionPaths()).To(Equal([]string{"/dev/sdb1"}))
+		})
+
+		It("checks partitions again every interval", func() {
+			_, _ = healthChecker.CheckHealth("/dev/sdb1")
+			Eventually(checkedHealth()).Should(Equal(Health{Status: HealthStatusOK}))
+
+			checker.SetHealth("/dev/sdb1", Health{Status: HealthStatusFailing})
+			Consistently(checkedHealth()).Should(Equal(Health{Status: HealthStatusOK}))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(checkedHealth()).Should(Equal(Health{Status: HealthStatusFailing}))
+		})
+
+		It("stops checking partitions whose health is no longer read", func() {
+			_, _ = healthChecker.CheckHealth("/dev/sdb1")
+			Eventually(checker.PartitionPaths).Should(HaveLen(1))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(checker.PartitionPaths).Should(HaveLen(2))
+
+			fakeClock.Increment(time.Minute)
+			Eventually(checker.PartitionPaths).Should(HaveLen(3))
+
+			fakeClock.Increment(time.Minute)
+			Consistently(checker.PartitionPaths).Should(HaveLen(3))
+		})
+	})
+})
@@ -0,0 +1,18 @@
+package disk
+
+import (
+	"errors"
+)
+
+// ErrHealthNotSupported is returned by platforms that cannot check the health of disks
+var ErrHealthNotSupported = errors.New("checking disk health is not supported")
+
+type dummyHealthChecker struct{}
+
+func NewDummyHealthChecker() HealthChecker {
+	return dummyHealthChecker{}
+}
+
+func (c dummyHealthChecker) CheckHealth(partitionPath string) (Health, error) {
+	return Health{}, ErrHealthNotSupported
+}
@@ -0,0 +1,29 @@
+package disk
+
+import "fmt"
+
+// Encryption describes whether and with which key a persistent disk
+// partition is wrapped in a LUKS container. Key takes precedence over KeyFile.
+type Encryption struct {
+	Enabled bool   `json:"enabled"`
+	Key     string `json:"key,omitempty"`
+	KeyFile string `json:"key_file,omitempty"`
+}
+
+// String keeps key material out of logged disk settings
+func (e Encryption) String() string {
+	return fmt.Sprintf("{Enabled:%t KeyFile:%s}", e.Enabled, e.KeyFile)
+}
+
+type Encryptor interface {
+	IsEncrypted(devicePath string) (bool, error)
+	Format(devicePath string, encryption Encryption) error
+
+	// Open unlocks devicePath as /dev/mapper/<name> unless it is already
+	// unlocked and returns the path of the mapped device
+	Open(devicePath, name string, encryption Encryption) (string, error)
+	Close(name string) error
+	Resize(name string, encryption Encryption) error
+
+	MappedDevicePath(name string) string
+}
@@ -0,0 +1,62 @@
+package fakes
+
+import (
+	"path/filepath"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeEncryptor struct {
+	Encrypted       map[string]bool
+	IsEncryptedErr  error
+	FormatDevices   []string
+	FormatErr       error
+	OpenDevices     []string
+	OpenNames       []string
+	OpenEncryptions []boshdisk.Encryption
+	OpenErr         error
+	ClosedNames     []string
+	CloseErr        error
+	ResizedNames    []string
+	ResizeErr       error
+}
+
+func NewFakeEncryptor() *FakeEncryptor {
+	return &FakeEncryptor{Encrypted: map[string]bool{}}
+}
+
+func (e *FakeEncryptor) IsEncrypted(devicePath string) (bool, error) {
+	return e.Encrypted[devicePath], e.IsEncryptedErr
+}
+
+func (e *FakeEncryptor) Format(devicePath string, encryption boshdisk.Encryption) error {
+	e.FormatDevices = append(e.FormatDevices, devicePath)
+	if e.FormatErr == nil {
+		e.Encrypted[devicePath] = true
+	}
+	return e.FormatErr
+}
+
+func (e *FakeEncryptor) Open(devicePath, name string, encryption boshdisk.Encryption) (string, error) {
+	e.OpenDevices = append(e.OpenDevices, devicePath)
+	e.OpenNames = append(e.OpenNames, name)
+	e.OpenEncryptions = append(e.OpenEncryptions, encryption)
+	if e.OpenErr != nil {
+		return "", e.OpenErr
+	}
+	return e.MappedDevicePath(name), nil
+}
+
+func (e *FakeEncryptor) Close(name string) error {
+	e.ClosedNames = append(e.ClosedNames, name)
+	return e.CloseErr
+}
+
+func (e *FakeEncryptor) Resize(name string, encryption boshdisk.Encryption) error {
+	e.ResizedNames = append(e.ResizedNames, name)
+	return e.ResizeErr
+}
+
+func (e *FakeEncryptor) MappedDevicePath(name string) string {
+	return filepath.Join("/dev/mapper", name)
+}
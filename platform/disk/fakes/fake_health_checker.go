@@ -0,0 +1,45 @@
+package fakes
+
+import (
+	"sync"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeHealthChecker struct {
+	CheckHealthPartitionPaths []string
+	CheckHealthHealth         map[string]boshdisk.Health
+	CheckHealthErr            error
+
+	mutex sync.Mutex
+}
+
+func NewFakeHealthChecker() *FakeHealthChecker {
+	return &FakeHealthChecker{
+		CheckHealthHealth: map[string]boshdisk.Health{},
+	}
+}
+
+func (c *FakeHealthChecker) CheckHealth(partitionPath string) (boshdisk.Health, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.CheckHealthPartitionPaths = append(c.CheckHealthPartitionPaths, partitionPath)
+	return c.CheckHealthHealth[partitionPath], c.CheckHealthErr
+}
+
+// SetHealth is safe to call while health is checked in the background
+func (c *FakeHealthChecker) SetHealth(partitionPath string, health boshdisk.Health) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.CheckHealthHealth[partitionPath] = health
+}
+
+// PartitionPaths is safe to call while health is checked in the background
+func (c *FakeHealthChecker) PartitionPaths() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string{}, c.CheckHealthPartitionPaths...)
+}
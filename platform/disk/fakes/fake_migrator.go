@@ -0,0 +1,35 @@
+package fakes
+
+import (
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeMigrator struct {
+	MigrateFromDirs []string
+	MigrateToDirs   []string
+	MigrateProgress []boshdisk.MigrationProgress
+	MigrateErr      error
+
+	FinishedDirs []string
+	FinishErr    error
+}
+
+func NewFakeMigrator() *FakeMigrator {
+	return &FakeMigrator{}
+}
+
+func (m *FakeMigrator) Migrate(fromDir, toDir string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) error {
+	m.MigrateFromDirs = append(m.MigrateFromDirs, fromDir)
+	m.MigrateToDirs = append(m.MigrateToDirs, toDir)
+
+	for _, progress := range m.MigrateProgress {
+		progressFunc(progress)
+	}
+
+	return m.MigrateErr
+}
+
+func (m *FakeMigrator) Finish(toDir string) error {
+	m.FinishedDirs = append(m.FinishedDirs, toDir)
+	return m.FinishErr
+}
@@ -16,6 +16,7 @@ type FakePartitioner struct {
 	ResizeSinglePartitionCalled     bool
 	ResizeSinglePartitionDevicePath string
 	ResizeSinglePartitionErr        error
+	ResizeSinglePartitionStub       func(devicePath string) error
 
 	PartitionCalled     bool
 	PartitionDevicePath string
@@ -51,6 +52,9 @@ func (p *FakePartitioner) SinglePartitionNeedsResize(devicePath string, expected
 func (p *FakePartitioner) ResizeSinglePartition(devicePath string) (err error) {
 	p.ResizeSinglePartitionCalled = true
 	p.ResizeSinglePartitionDevicePath = devicePath
+	if p.ResizeSinglePartitionStub != nil {
+		return p.ResizeSinglePartitionStub(devicePath)
+	}
 	return p.ResizeSinglePartitionErr
 }
 
@@ -6,6 +6,7 @@ const (
 	FileSystemSwap    FileSystemType = "swap"
 	FileSystemExt4    FileSystemType = "ext4"
 	FileSystemXFS     FileSystemType = "xfs"
+	FileSystemBtrfs   FileSystemType = "btrfs"
 	FileSystemDefault FileSystemType = ""
 
 	FileSystemExtResizeUtility = "resize2fs"
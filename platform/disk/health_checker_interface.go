@@ -0,0 +1,30 @@
+package disk
+
+type HealthStatus string
+
+const (
+	HealthStatusOK      HealthStatus = "ok"
+	HealthStatusFailing HealthStatus = "failing"
+
+	// HealthStatusUnknown is reported for devices without SMART support,
+	// e.g. most virtual disks, or when smartctl is not installed
+	HealthStatusUnknown HealthStatus = "unknown"
+)
+
+// Health describes the physical devices backing a partition.
+// Counters are summed up over all devices, e.g. the paths of a
+// multipath device or the device below an encrypted partition.
+type Health struct {
+	Devices []string
+	Status  HealthStatus
+
+	// MediaErrors counts unrecoverable data errors reported by the device
+	MediaErrors uint64
+
+	// IOErrors counts commands the kernel saw failing
+	IOErrors uint64
+}
+
+type HealthChecker interface {
+	CheckHealth(partitionPath string) (Health, error)
+}
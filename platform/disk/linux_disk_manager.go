@@ -18,6 +18,8 @@ type linuxDiskManager struct {
 	diskUtil              Util
 
 	formatter Formatter
+	encryptor Encryptor
+	migrator  Migrator
 
 	mounter        Mounter
 	mountsSearcher MountsSearcher
@@ -83,6 +85,8 @@ func NewLinuxDiskManager(
 		ephemeralPartitioner:  ephemeralPartitioner,
 		diskUtil:              diskUtil,
 		formatter:             NewLinuxFormatter(runner, fs),
+		encryptor:             NewLinuxEncryptor(runner, fs),
+		migrator:              NewLinuxMigrator(fs, logger),
 		fs:                    fs,
 		logger:                logger,
 		mounter:               mounter,
@@ -111,7 +115,9 @@ func (m linuxDiskManager) GetPersistentDevicePartitioner(partitionerType string)
 	}
 }
 
+func (m linuxDiskManager) GetEncryptor() Encryptor           { return m.encryptor }
 func (m linuxDiskManager) GetFormatter() Formatter           { return m.formatter }
+func (m linuxDiskManager) GetMigrator() Migrator             { return m.migrator }
 func (m linuxDiskManager) GetMounter() Mounter               { return m.mounter }
 func (m linuxDiskManager) GetMountsSearcher() MountsSearcher { return m.mountsSearcher }
 
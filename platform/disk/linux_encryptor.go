@@ -0,0 +1,106 @@
+package disk
+
+import (
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	mapperDir = "/dev/mapper"
+
+	// cryptsetup isLuks exits with 1 for devices that are not LUKS containers
+	cryptsetupNotLuksExitStatus = 1
+)
+
+type linuxEncryptor struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+}
+
+func NewLinuxEncryptor(runner boshsys.CmdRunner, fs boshsys.FileSystem) Encryptor {
+	return linuxEncryptor{
+		runner: runner,
+		fs:     fs,
+	}
+}
+
+func (e linuxEncryptor) IsEncrypted(devicePath string) (bool, error) {
+	_, _, exitStatus, err := e.runner.RunCommand("cryptsetup", "isLuks", devicePath)
+	if err != nil {
+		if exitStatus == cryptsetupNotLuksExitStatus {
+			return false, nil
+		}
+		return false, bosherr.WrapError(err, "Shelling out to cryptsetup isLuks")
+	}
+
+	return true, nil
+}
+
+func (e linuxEncryptor) Format(devicePath string, encryption Encryption) error {
+	err := e.runWithKey(encryption, "luksFormat", "--batch-mode", "--type", "luks2", devicePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup luksFormat")
+	}
+
+	return nil
+}
+
+func (e linuxEncryptor) Open(devicePath, name string, encryption Encryption) (string, error) {
+	mappedPath := e.MappedDevicePath(name)
+
+	if e.fs.FileExists(mappedPath) {
+		return mappedPath, nil
+	}
+
+	err := e.runWithKey(encryption, "open", "--type", "luks", devicePath, name)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Shelling out to cryptsetup open")
+	}
+
+	return mappedPath, nil
+}
+
+func (e linuxEncryptor) Close(name string) error {
+	if !e.fs.FileExists(e.MappedDevicePath(name)) {
+		return nil
+	}
+
+	_, _, _, err := e.runner.RunCommand("cryptsetup", "close", name)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup close")
+	}
+
+	return nil
+}
+
+func (e linuxEncryptor) Resize(name string, encryption Encryption) error {
+	err := e.runWithKey(encryption, "resize", name)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to cryptsetup resize")
+	}
+
+	return nil
+}
+
+func (e linuxEncryptor) MappedDevicePath(name string) string {
+	return filepath.Join(mapperDir, name)
+}
+
+// runWithKey passes the key on stdin so it never shows up in process lists or logs
+func (e linuxEncryptor) runWithKey(encryption Encryption, args ...string) error {
+	if encryption.Key != "" {
+		args = append([]string{args[0], "--key-file", "-"}, args[1:]...)
+		_, _, _, err := e.runner.RunCommandWithInput(encryption.Key, "cryptsetup", args...)
+		return err
+	}
+
+	if encryption.KeyFile != "" {
+		args = append([]string{args[0], "--key-file", encryption.KeyFile}, args[1:]...)
+		_, _, _, err := e.runner.RunCommand("cryptsetup", args...)
+		return err
+	}
+
+	return bosherr.Error("No key or key file configured for disk encryption")
+}
@@ -0,0 +1,130 @@
+package disk_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Linux Encryptor", func() {
+	var (
+		fakeRunner *fakesys.FakeCmdRunner
+		fakeFs     *fakesys.FakeFileSystem
+		encryptor  Encryptor
+	)
+
+	BeforeEach(func() {
+		fakeRunner = fakesys.NewFakeCmdRunner()
+		fakeFs = fakesys.NewFakeFileSystem()
+		encryptor = NewLinuxEncryptor(fakeRunner, fakeFs)
+	})
+
+	Describe("Encryption", func() {
+		It("does not print the key", func() {
+			encryption := Encryption{Enabled: true, Key: "fake-secret", KeyFile: "/fake-key-file"}
+			Expect(fmt.Sprintf("%+v", struct{ Encryption Encryption }{encryption})).ToNot(ContainSubstring("fake-secret"))
+		})
+	})
+
+	Describe("IsEncrypted", func() {
+		It("returns true for LUKS containers", func() {
+			encrypted, err := encryptor.IsEncrypted("/dev/sdc1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).To(BeTrue())
+
+			Expect(fakeRunner.RunCommands).To(Equal([][]string{{"cryptsetup", "isLuks", "/dev/sdc1"}}))
+		})
+
+		It("returns false when cryptsetup reports the device is not a LUKS container", func() {
+			fakeRunner.AddCmdResult("cryptsetup isLuks /dev/sdc1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("exit 1")})
+
+			encrypted, err := encryptor.IsEncrypted("/dev/sdc1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encrypted).To(BeFalse())
+		})
+
+		It("returns an error when cryptsetup fails otherwise", func() {
+			fakeRunner.AddCmdResult("cryptsetup isLuks /dev/sdc1", fakesys.FakeCmdResult{ExitStatus: -1, Error: errors.New("fake-err")})
+
+			_, err := encryptor.IsEncrypted("/dev/sdc1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Format", func() {
+		It("passes a key from settings on stdin", func() {
+			err := encryptor.Format("/dev/sdc1", Encryption{Enabled: true, Key: "fake-key"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner.RunCommands).To(BeEmpty())
+			Expect(fakeRunner.RunCommandsWithInput).To(Equal([][]string{
+				{"fake-key", "cryptsetup", "luksFormat", "--key-file", "-", "--batch-mode", "--type", "luks2", "/dev/sdc1"},
+			}))
+		})
+
+		It("uses a key file when no key is given", func() {
+			err := encryptor.Format("/dev/sdc1", Encryption{Enabled: true, KeyFile: "/var/vcap/bosh/disk.key"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner.RunCommands).To(Equal([][]string{
+				{"cryptsetup", "luksFormat", "--key-file", "/var/vcap/bosh/disk.key", "--batch-mode", "--type", "luks2", "/dev/sdc1"},
+			}))
+		})
+
+		It("returns an error without key material", func() {
+			err := encryptor.Format("/dev/sdc1", Encryption{Enabled: true})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No key or key file configured"))
+		})
+	})
+
+	Describe("Open", func() {
+		It("opens the container under /dev/mapper", func() {
+			mappedPath, err := encryptor.Open("/dev/sdc1", "bosh-crypt-disk", Encryption{Enabled: true, KeyFile: "/key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/bosh-crypt-disk"))
+
+			Expect(fakeRunner.RunCommands).To(Equal([][]string{
+				{"cryptsetup", "open", "--key-file", "/key", "--type", "luks", "/dev/sdc1", "bosh-crypt-disk"},
+			}))
+		})
+
+		It("does nothing when the container is already open", func() {
+			Expect(fakeFs.WriteFileString("/dev/mapper/bosh-crypt-disk", "")).To(Succeed())
+
+			mappedPath, err := encryptor.Open("/dev/sdc1", "bosh-crypt-disk", Encryption{Enabled: true, KeyFile: "/key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/bosh-crypt-disk"))
+			Expect(fakeRunner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Close", func() {
+		It("closes open containers", func() {
+			Expect(fakeFs.WriteFileString("/dev/mapper/bosh-crypt-disk", "")).To(Succeed())
+
+			Expect(encryptor.Close("bosh-crypt-disk")).To(Succeed())
+			Expect(fakeRunner.RunCommands).To(Equal([][]string{{"cryptsetup", "close", "bosh-crypt-disk"}}))
+		})
+
+		It("ignores containers that are not open", func() {
+			Expect(encryptor.Close("bosh-crypt-disk")).To(Succeed())
+			Expect(fakeRunner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Resize", func() {
+		It("grows the mapping to the size of the partition", func() {
+			Expect(encryptor.Resize("bosh-crypt-disk", Encryption{Enabled: true, Key: "fake-key"})).To(Succeed())
+			Expect(fakeRunner.RunCommandsWithInput).To(Equal([][]string{
+				{"fake-key", "cryptsetup", "resize", "--key-file", "-", "bosh-crypt-disk"},
+			}))
+		})
+	})
+})
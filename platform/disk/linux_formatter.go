@@ -9,14 +9,16 @@ import (
 )
 
 type linuxFormatter struct {
-	runner boshsys.CmdRunner
-	fs     boshsys.FileSystem
+	runner         boshsys.CmdRunner
+	fs             boshsys.FileSystem
+	mountsSearcher MountsSearcher
 }
 
 func NewLinuxFormatter(runner boshsys.CmdRunner, fs boshsys.FileSystem) Formatter {
 	return linuxFormatter{
-		runner: runner,
-		fs:     fs,
+		runner:         runner,
+		fs:             fs,
+		mountsSearcher: NewProcMountsSearcher(fs),
 	}
 }
 
@@ -31,7 +33,7 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 			return err
 		}
 		// swap is not user-configured, so we're not concerned about reformatting
-	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS {
+	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS || existingFsType == FileSystemBtrfs {
 		// never reformat if it is already formatted in a supported format
 		return err
 	}
@@ -59,6 +61,12 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 		if err != nil {
 			return bosherr.WrapError(err, "Shelling out to mkfs.xfs")
 		}
+
+	case FileSystemBtrfs:
+		_, _, _, err = f.runner.RunCommand("mkfs.btrfs", partitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Shelling out to mkfs.btrfs")
+		}
 	case FileSystemDefault:
 		return nil
 	}
@@ -91,12 +99,57 @@ func (f linuxFormatter) GrowFilesystem(partitionPath string) error {
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow XFS filesystem")
 		}
+
+	case FileSystemBtrfs:
+		// btrfs only grows while mounted and wants the mount point
+		mountPoint, err := f.findMountPoint(partitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Failed to grow Btrfs filesystem")
+		}
+
+		_, _, _, err = f.runner.RunCommand(
+			"btrfs",
+			"filesystem",
+			"resize",
+			"max",
+			mountPoint,
+		)
+		if err != nil {
+			return bosherr.WrapError(err, "Failed to grow Btrfs filesystem")
+		}
 	case FileSystemDefault, FileSystemSwap:
 		return nil
 	}
 	return nil
 }
 
+func (f linuxFormatter) findMountPoint(partitionPath string) (string, error) {
+	mounts, err := f.mountsSearcher.SearchMounts()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Searching mounts")
+	}
+
+	// Partitions may be given as symlinks, e.g. in /dev/disk/by-id or /dev/mapper
+	realPartitionPath := f.resolvePath(partitionPath)
+
+	for _, mount := range mounts {
+		if f.resolvePath(mount.PartitionPath) == realPartitionPath {
+			return mount.MountPoint, nil
+		}
+	}
+
+	return "", bosherr.Errorf("Partition %s is not mounted", partitionPath)
+}
+
+func (f linuxFormatter) resolvePath(path string) string {
+	realPath, err := f.fs.ReadAndFollowLink(path)
+	if err != nil {
+		return path
+	}
+
+	return realPath
+}
+
 func (f linuxFormatter) makeFileSystemExt4(partitionPath string) error {
 	var err error
 	if f.fs.FileExists("/sys/fs/ext4/features/lazy_itable_init") {
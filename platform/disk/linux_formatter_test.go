@@ -221,6 +221,47 @@ var _ = Describe("Linux Formatter", func() {
 				Expect(err.Error()).To(Equal("Shelling out to mkfs.xfs: Sadness"))
 			})
 		})
+
+		Context("when using btrfs", func() {
+			It("formats a blank disk with type btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemBtrfs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(2).To(Equal(len(fakeRunner.RunCommands)))
+				Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"mkfs.btrfs", "/dev/xvda2"}))
+			})
+
+			It("does not re-format if fs is already btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemExt4)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(1).To(Equal(len(fakeRunner.RunCommands)))
+				Expect(fakeRunner.RunCommands[0]).To(Equal([]string{"blkid", "-p", "/dev/xvda1"}))
+			})
+
+			It("throws an error if formatting filesystem fails", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("mkfs.btrfs /dev/xvda2", fakesys.FakeCmdResult{Error: errors.New("Sadness")})
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{Stderr: "", ExitStatus: 2})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemBtrfs)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Shelling out to mkfs.btrfs: Sadness"))
+			})
+		})
 	})
 
 	Describe("GrowFilesystem", func() {
@@ -304,5 +345,68 @@ var _ = Describe("Linux Formatter", func() {
 				})
 			})
 		})
+
+		Context("when using Btrfs", func() {
+			BeforeEach(func() {
+				fakeRunner.AddCmdResult("blkid -p /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+				formatter = NewLinuxFormatter(fakeRunner, fakeFs)
+			})
+
+			Context("when the partition is mounted", func() {
+				BeforeEach(func() {
+					err := fakeFs.WriteFileString("/proc/mounts", "/dev/sda1 / ext4 rw 0 0\n/dev/nvme2n1p1 /mnt/point btrfs rw 0 0\n")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("grows the Btrfs filesystem through its mount point", func() {
+					err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"btrfs", "filesystem", "resize", "max", "/mnt/point"}))
+				})
+
+				It("finds the mount point of partitions given through a symlink", func() {
+					err := fakeFs.WriteFileString("/dev/nvme2n1p1", "")
+					Expect(err).NotTo(HaveOccurred())
+					err = fakeFs.Symlink("/dev/nvme2n1p1", "/dev/disk/by-id/nvme-disk-part1")
+					Expect(err).NotTo(HaveOccurred())
+					fakeRunner.AddCmdResult("blkid -p /dev/disk/by-id/nvme-disk-part1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+
+					err = formatter.GrowFilesystem("/dev/disk/by-id/nvme-disk-part1")
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"btrfs", "filesystem", "resize", "max", "/mnt/point"}))
+				})
+
+				Context("when btrfs filesystem resize fails", func() {
+					BeforeEach(func() {
+						fakeRunner.AddCmdResult("btrfs filesystem resize max /mnt/point", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("btrfs failure")})
+					})
+
+					It("returns an error", func() {
+						err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Failed to grow Btrfs filesystem"))
+						Expect(err.Error()).To(ContainSubstring("btrfs failure"))
+					})
+				})
+			})
+
+			Context("when the partition is not mounted", func() {
+				BeforeEach(func() {
+					err := fakeFs.WriteFileString("/proc/mounts", "/dev/sda1 / ext4 rw 0 0\n")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("returns an error", func() {
+					err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Partition /dev/nvme2n1p1 is not mounted"))
+					Expect(fakeRunner.RunCommands).To(HaveLen(1))
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,212 @@
+package disk
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	sysBlockDir = "/sys/class/block"
+
+	// Devices that stopped responding can block smartctl for minutes
+	smartctlTimeout          = 30 * time.Second
+	smartctlTimeoutKillAfter = 5 * time.Second
+
+	// Exit status of timeout when the command did not finish in time
+	timeoutExitStatus = 124
+)
+
+var (
+	// Partitions of devices whose names end with a digit, e.g. nvme0n1p1
+	numberedDevicePartitionRegexp = regexp.MustCompile(`^(.*\d)p\d+$`)
+	devicePartitionRegexp         = regexp.MustCompile(`^(.*\D)\d+$`)
+)
+
+// ATA attributes counting sectors that could not be read or were remapped:
+// reallocated, current pending and offline uncorrectable sectors
+var ataMediaErrorAttributes = map[int]bool{5: true, 197: true, 198: true}
+
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+
+	NVMeHealthLog *struct {
+		MediaErrors uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+
+	ATAAttributes *struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+
+	SCSIErrorCounterLog *struct {
+		Read struct {
+			TotalUncorrectedErrors uint64 `json:"total_uncorrected_errors"`
+		} `json:"read"`
+		Write struct {
+			TotalUncorrectedErrors uint64 `json:"total_uncorrected_errors"`
+		} `json:"write"`
+	} `json:"scsi_error_counter_log"`
+}
+
+type linuxHealthChecker struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+	logTag string
+	logger boshlog.Logger
+}
+
+// NewLinuxHealthChecker reads the health of devices from smartctl,
+// which understands ATA, SCSI and NVMe devices, and kernel IO error
+// counts from sysfs
+func NewLinuxHealthChecker(runner boshsys.CmdRunner, fs boshsys.FileSystem, logger boshlog.Logger) HealthChecker {
+	return linuxHealthChecker{
+		runner: runner,
+		fs:     fs,
+		logTag: "linuxHealthChecker",
+		logger: logger,
+	}
+}
+
+func (c linuxHealthChecker) CheckHealth(partitionPath string) (Health, error) {
+	realPath, err := c.fs.ReadAndFollowLink(partitionPath)
+	if err != nil {
+		return Health{}, bosherr.WrapErrorf(err, "Resolving partition path %s", partitionPath)
+	}
+
+	health := Health{Status: HealthStatusUnknown}
+
+	for _, device := range c.physicalDevices(path.Base(realPath)) {
+		health.Devices = append(health.Devices, device)
+		health.IOErrors += c.ioErrors(device)
+
+		status, mediaErrors := c.smartHealth(device)
+		health.MediaErrors += mediaErrors
+
+		switch {
+		case status == HealthStatusFailing:
+			health.Status = HealthStatusFailing
+		case status == HealthStatusOK && health.Status == HealthStatusUnknown:
+			health.Status = HealthStatusOK
+		}
+	}
+
+	return health, nil
+}
+
+// physicalDevices finds the disks below a partition or device mapper device
+func (c linuxHealthChecker) physicalDevices(name string) []string {
+	slaves, err := c.fs.Glob(path.Join(sysBlockDir, name, "slaves", "*"))
+	if err == nil && len(slaves) > 0 {
+		var devices []string
+		for _, slave := range slaves {
+			devices = append(devices, c.physicalDevices(path.Base(slave))...)
+		}
+		return devices
+	}
+
+	if !c.fs.FileExists(path.Join(sysBlockDir, name, "partition")) {
+		return []string{name}
+	}
+
+	if matches := numberedDevicePartitionRegexp.FindStringSubmatch(name); matches != nil {
+		return []string{matches[1]}
+	}
+
+	if matches := devicePartitionRegexp.FindStringSubmatch(name); matches != nil {
+		return []string{matches[1]}
+	}
+
+	return []string{name}
+}
+
+// ioErrors reads the count of failed commands SCSI devices keep,
+// other devices do not count them
+func (c linuxHealthChecker) ioErrors(device string) uint64 {
+	contents, err := c.fs.ReadFileString(path.Join(sysBlockDir, device, "device", "ioerr_cnt"))
+	if err != nil {
+		return 0
+	}
+
+	count, err := strconv.ParseUint(strings.TrimSpace(contents), 0, 64)
+	if err != nil {
+		c.logger.Debug(c.logTag, "Parsing IO error count of %s: %s", device, err.Error())
+		return 0
+	}
+
+	return count
+}
+
+func (c linuxHealthChecker) smartHealth(device string) (HealthStatus, uint64) {
+	if !c.runner.CommandExists("smartctl") {
+		return HealthStatusUnknown, 0
+	}
+
+	stdout, _, exitStatus, err := c.runner.RunCommand(
+		"timeout",
+		fmt.Sprintf("--kill-after=%d", int64(smartctlTimeoutKillAfter.Seconds())),
+		fmt.Sprintf("%d", int64(smartctlTimeout.Seconds())),
+		"smartctl", "--json", "--health", "--attributes", "--log=error", path.Join("/dev", device),
+	)
+	if exitStatus == timeoutExitStatus {
+		c.logger.Warn(c.logTag, "Timed out reading SMART data of %s after %s", device, smartctlTimeout)
+		return HealthStatusUnknown, 0
+	}
+
+	// smartctl's exit status is a bit mask that is also set for failing
+	// disks, only the lowest two bits mean that no data could be read
+	if err != nil && (exitStatus < 0 || exitStatus&3 != 0) {
+		c.logger.Debug(c.logTag, "Reading SMART data of %s: %s", device, err.Error())
+		return HealthStatusUnknown, 0
+	}
+
+	var output smartctlOutput
+	err = json.Unmarshal([]byte(stdout), &output)
+	if err != nil {
+		c.logger.Debug(c.logTag, "Parsing SMART data of %s: %s", device, err.Error())
+		return HealthStatusUnknown, 0
+	}
+
+	status := HealthStatusUnknown
+	if output.SmartStatus != nil {
+		status = HealthStatusFailing
+		if output.SmartStatus.Passed {
+			status = HealthStatusOK
+		}
+	}
+
+	var mediaErrors uint64
+
+	if output.NVMeHealthLog != nil {
+		mediaErrors += output.NVMeHealthLog.MediaErrors
+	}
+
+	if output.ATAAttributes != nil {
+		for _, attribute := range output.ATAAttributes.Table {
+			if ataMediaErrorAttributes[attribute.ID] {
+				mediaErrors += attribute.Raw.Value
+			}
+		}
+	}
+
+	if output.SCSIErrorCounterLog != nil {
+		mediaErrors += output.SCSIErrorCounterLog.Read.TotalUncorrectedErrors
+		mediaErrors += output.SCSIErrorCounterLog.Write.TotalUncorrectedErrors
+	}
+
+	return status, mediaErrors
+}
@@ -0,0 +1,198 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Linux Health Checker", func() {
+	var (
+		fakeRunner    *fakesys.FakeCmdRunner
+		fakeFs        *fakesys.FakeFileSystem
+		healthChecker HealthChecker
+	)
+
+	BeforeEach(func() {
+		fakeRunner = fakesys.NewFakeCmdRunner()
+		fakeFs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		healthChecker = NewLinuxHealthChecker(fakeRunner, fakeFs, logger)
+
+		err := fakeFs.WriteFileString("/dev/sdb1", "")
+		Expect(err).ToNot(HaveOccurred())
+		err = fakeFs.WriteFileString("/sys/class/block/sdb1/partition", "1")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns an error when the partition path cannot be resolved", func() {
+		_, err := healthChecker.CheckHealth("/dev/missing")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Resolving partition path /dev/missing"))
+	})
+
+	It("reports unknown health when smartctl is not installed", func() {
+		health, err := healthChecker.CheckHealth("/dev/sdb1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(health).To(Equal(Health{Devices: []string{"sdb"}, Status: HealthStatusUnknown}))
+		Expect(fakeRunner.RunCommands).To(BeEmpty())
+	})
+
+	It("counts kernel IO errors of the device below the partition", func() {
+		err := fakeFs.WriteFileString("/sys/class/block/sdb/device/ioerr_cnt", "0x1a\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		health, err := healthChecker.CheckHealth("/dev/sdb1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(health.IOErrors).To(Equal(uint64(26)))
+	})
+
+	It("strips the partition suffix of devices whose names end with a digit", func() {
+		err := fakeFs.WriteFileString("/dev/nvme1n1p2", "")
+		Expect(err).ToNot(HaveOccurred())
+		err = fakeFs.WriteFileString("/sys/class/block/nvme1n1p2/partition", "2")
+		Expect(err).ToNot(HaveOccurred())
+
+		health, err := healthChecker.CheckHealth("/dev/nvme1n1p2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(health.Devices).To(Equal([]string{"nvme1n1"}))
+	})
+
+	It("follows device mapper devices to the devices below them", func() {
+		err := fakeFs.WriteFileString("/dev/dm-0", "")
+		Expect(err).ToNot(HaveOccurred())
+		err = fakeFs.Symlink("/dev/dm-0", "/dev/mapper/encrypted")
+		Expect(err).ToNot(HaveOccurred())
+		fakeFs.SetGlob("/sys/class/block/dm-0/slaves/*", []string{
+			"/sys/class/block/dm-0/slaves/sdb1",
+			"/sys/class/block/dm-0/slaves/sdc",
+		})
+		err = fakeFs.WriteFileString("/sys/class/block/sdb/device/ioerr_cnt", "2")
+		Expect(err).ToNot(HaveOccurred())
+		err = fakeFs.WriteFileString("/sys/class/block/sdc/device/ioerr_cnt", "3")
+		Expect(err).ToNot(HaveOccurred())
+
+		health, err := healthChecker.CheckHealth("/dev/mapper/encrypted")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(health.Devices).To(Equal([]string{"sdb", "sdc"}))
+		Expect(health.IOErrors).To(Equal(uint64(5)))
+	})
+
+	Context("when smartctl is installed", func() {
+		BeforeEach(func() {
+			fakeRunner.AvailableCommands["smartctl"] = true
+		})
+
+		It("reports ok health and counts reallocated and pending sectors of ATA devices", func() {
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				Stdout: `{
+					"smart_status": {"passed": true},
+					"ata_smart_attributes": {"table": [
+						{"id": 5, "raw": {"value": 8}},
+						{"id": 9, "raw": {"value": 12345}},
+						{"id": 197, "raw": {"value": 2}},
+						{"id": 198, "raw": {"value": 1}}
+					]}
+				}`,
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(health).To(Equal(Health{Devices: []string{"sdb"}, Status: HealthStatusOK, MediaErrors: 11}))
+		})
+
+		It("reports failing health and media errors of NVMe devices", func() {
+			err := fakeFs.WriteFileString("/dev/nvme1n1", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/nvme1n1", fakesys.FakeCmdResult{
+				Stdout:     `{"smart_status": {"passed": false}, "nvme_smart_health_information_log": {"media_errors": 4}}`,
+				ExitStatus: 8,
+				Error:      errors.New("exit 8"),
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/nvme1n1")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(health).To(Equal(Health{Devices: []string{"nvme1n1"}, Status: HealthStatusFailing, MediaErrors: 4}))
+		})
+
+		It("counts uncorrected read and write errors of SCSI devices", func() {
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				Stdout: `{
+					"smart_status": {"passed": true},
+					"scsi_error_counter_log": {
+						"read": {"total_uncorrected_errors": 1},
+						"write": {"total_uncorrected_errors": 2}
+					}
+				}`,
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(health.MediaErrors).To(Equal(uint64(3)))
+		})
+
+		It("reports unknown health when the device cannot be opened", func() {
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				Stdout:     `{"smart_status": {"passed": false}}`,
+				ExitStatus: 2,
+				Error:      errors.New("exit 2"),
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(health.Status).To(Equal(HealthStatusUnknown))
+		})
+
+		It("reports unknown health when smartctl times out", func() {
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				ExitStatus: 124,
+				Error:      errors.New("exit 124"),
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(health.Status).To(Equal(HealthStatusUnknown))
+		})
+
+		It("reports unknown health when the device does not support SMART", func() {
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				Stdout: `{"device": {"name": "/dev/sdb"}}`,
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(health.Status).To(Equal(HealthStatusUnknown))
+		})
+
+		It("reports failing health when any device below the partition is failing", func() {
+			err := fakeFs.WriteFileString("/dev/dm-0", "")
+			Expect(err).ToNot(HaveOccurred())
+			fakeFs.SetGlob("/sys/class/block/dm-0/slaves/*", []string{
+				"/sys/class/block/dm-0/slaves/sdb",
+				"/sys/class/block/dm-0/slaves/sdc",
+			})
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdb", fakesys.FakeCmdResult{
+				Stdout: `{"smart_status": {"passed": true}}`,
+			})
+			fakeRunner.AddCmdResult("timeout --kill-after=5 30 smartctl --json --health --attributes --log=error /dev/sdc", fakesys.FakeCmdResult{
+				Stdout:     `{"smart_status": {"passed": false}}`,
+				ExitStatus: 8,
+				Error:      errors.New("exit 8"),
+			})
+
+			health, err := healthChecker.CheckHealth("/dev/dm-0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(health.Status).To(Equal(HealthStatusFailing))
+		})
+	})
+})
@@ -0,0 +1,489 @@
+package disk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	MigrationCheckpointFileName = ".bosh_migration_checkpoint"
+
+	// Saving the checkpoint flushes the new disk, so it is only done
+	// after a batch of entries or bytes rather than after every file
+	migrationCheckpointEntries = 1000
+	migrationCheckpointBytes   = 256 * 1024 * 1024
+)
+
+var errCheckpointMismatch = errors.New("checkpoint does not match the old disk")
+
+type migrationCheckpoint struct {
+	// Entries counts the entries of the old disk, in walk order, that
+	// have been copied completely; LastPath is the last of them
+	Entries  int64  `json:"entries"`
+	LastPath string `json:"last_path"`
+
+	BytesCopied int64 `json:"bytes_copied"`
+	FilesCopied int64 `json:"files_copied"`
+
+	Completed bool `json:"completed"`
+}
+
+func (c migrationCheckpoint) progress() MigrationProgress {
+	return MigrationProgress{BytesCopied: c.BytesCopied, FilesCopied: c.FilesCopied}
+}
+
+type linuxMigrator struct {
+	fs     boshsys.FileSystem
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewLinuxMigrator(fs boshsys.FileSystem, logger boshlog.Logger) Migrator {
+	return linuxMigrator{
+		fs:     fs,
+		logTag: "linuxMigrator",
+		logger: logger,
+	}
+}
+
+func (m linuxMigrator) Migrate(fromDir, toDir string, cancelCh <-chan struct{}, progressFunc MigrationProgressFunc) error {
+	checkpoint := m.readCheckpoint(toDir)
+
+	progressFunc(checkpoint.progress())
+
+	if checkpoint.Completed {
+		m.logger.Info(m.logTag, "Files were already copied from %s to %s", fromDir, toDir)
+		return nil
+	}
+
+	if checkpoint.Entries > 0 {
+		m.logger.Info(m.logTag, "Resuming copy from %s to %s after '%s'", fromDir, toDir, checkpoint.LastPath)
+	}
+
+	run := &migrationRun{
+		migrator:     m,
+		fromDir:      fromDir,
+		toDir:        toDir,
+		cancelCh:     cancelCh,
+		progressFunc: progressFunc,
+		checkpoint:   checkpoint,
+		hardlinks:    map[fileID]string{},
+	}
+
+	err := run.copyEntries()
+	if errors.Is(err, errCheckpointMismatch) {
+		// The old disk is read-only while it is migrated, so it only differs
+		// from the checkpoint if it was written to between two attempts
+		m.logger.Warn(m.logTag, "Restarting copy to %s: %s", toDir, err.Error())
+
+		// Entries copied before may no longer be on the old disk
+		err = m.clearDestination(toDir)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Clearing %s", toDir)
+		}
+
+		return m.Migrate(fromDir, toDir, cancelCh, progressFunc)
+	}
+
+	if err != nil {
+		saveErr := m.saveCheckpoint(toDir, run.checkpoint)
+		if saveErr != nil {
+			m.logger.Warn(m.logTag, "Saving migration checkpoint: %s", saveErr.Error())
+		}
+
+		if errors.Is(err, ErrMigrationCancelled) {
+			return ErrMigrationCancelled
+		}
+
+		return bosherr.WrapErrorf(err, "Copying %s to %s", fromDir, toDir)
+	}
+
+	err = run.copyDirMetadata()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying directory attributes to %s", toDir)
+	}
+
+	run.checkpoint.Completed = true
+
+	return m.saveCheckpoint(toDir, run.checkpoint)
+}
+
+func (m linuxMigrator) Finish(toDir string) error {
+	err := m.fs.RemoveAll(m.checkpointPath(toDir))
+	if err != nil {
+		return bosherr.WrapError(err, "Removing migration checkpoint")
+	}
+
+	return nil
+}
+
+// clearDestination removes everything but lost+found, which mkfs creates,
+// including the checkpoint. Btrfs subvolumes that the platform created
+// before copying are emptied rather than removed.
+func (m linuxMigrator) clearDestination(toDir string) error {
+	lostAndFound := filepath.Join(toDir, "lost+found")
+
+	return m.fs.Walk(toDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == toDir {
+			return nil
+		}
+
+		if info.IsDir() && path == lostAndFound {
+			return filepath.SkipDir
+		}
+
+		// Walking into a subvolume removes its contents instead
+		if info.IsDir() && isBtrfsSubvolume(path, info) {
+			return nil
+		}
+
+		err = m.fs.RemoveAll(path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
+
+func (m linuxMigrator) checksum(path string) ([]byte, error) {
+	file, err := m.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
+
+func (m linuxMigrator) checkpointPath(toDir string) string {
+	return filepath.Join(toDir, MigrationCheckpointFileName)
+}
+
+func (m linuxMigrator) readCheckpoint(toDir string) migrationCheckpoint {
+	var checkpoint migrationCheckpoint
+
+	path := m.checkpointPath(toDir)
+	if !m.fs.FileExists(path) {
+		return checkpoint
+	}
+
+	contents, err := m.fs.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(contents, &checkpoint)
+	}
+
+	if err != nil {
+		// Copying everything again is slower but always correct
+		m.logger.Warn(m.logTag, "Ignoring unreadable migration checkpoint %s: %s", path, err.Error())
+		return migrationCheckpoint{}
+	}
+
+	return checkpoint
+}
+
+func (m linuxMigrator) saveCheckpoint(toDir string, checkpoint migrationCheckpoint) error {
+	// Copied files have to be on the new disk before the checkpoint
+	// claims them, otherwise a crash would lose them for good
+	err := syncFilesystem(toDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Syncing %s", toDir)
+	}
+
+	contents, err := json.Marshal(checkpoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling migration checkpoint")
+	}
+
+	path := m.checkpointPath(toDir)
+
+	err = m.fs.WriteFile(path+".tmp", contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing migration checkpoint")
+	}
+
+	err = m.fs.Rename(path+".tmp", path)
+	if err != nil {
+		return bosherr.WrapError(err, "Renaming migration checkpoint")
+	}
+
+	return nil
+}
+
+type migratedDir struct {
+	relPath string
+	info    os.FileInfo
+}
+
+// migrationRun walks the old disk once, skipping the entries already
+// recorded in the checkpoint and copying the rest
+type migrationRun struct {
+	migrator     linuxMigrator
+	fromDir      string
+	toDir        string
+	cancelCh     <-chan struct{}
+	progressFunc MigrationProgressFunc
+
+	checkpoint     migrationCheckpoint
+	walkedEntries  int64
+	unsavedEntries int64
+	unsavedBytes   int64
+	hardlinks      map[fileID]string
+	dirs           []migratedDir
+}
+
+func (r *migrationRun) copyEntries() error {
+	return filepath.WalkDir(r.fromDir, func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(r.fromDir, path)
+		if err != nil {
+			return err
+		}
+
+		// A checkpoint left on the old disk by an earlier migration
+		// would overwrite the one of this migration
+		if relPath == MigrationCheckpointFileName {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			r.dirs = append(r.dirs, migratedDir{relPath: relPath, info: info})
+		}
+
+		index := r.walkedEntries
+		r.walkedEntries++
+
+		if index < r.checkpoint.Entries {
+			if index == r.checkpoint.Entries-1 && relPath != r.checkpoint.LastPath {
+				r.migrator.logger.Warn(r.migrator.logTag, "Expected '%s' but found '%s' on the old disk", r.checkpoint.LastPath, relPath)
+				return errCheckpointMismatch
+			}
+
+			r.rememberHardlink(info, relPath)
+
+			return nil
+		}
+
+		select {
+		case <-r.cancelCh:
+			return ErrMigrationCancelled
+		default:
+		}
+
+		err = r.copyEntry(relPath, info)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Copying '%s'", relPath)
+		}
+
+		r.checkpoint.Entries = index + 1
+		r.checkpoint.LastPath = relPath
+		r.unsavedEntries++
+
+		r.progressFunc(r.checkpoint.progress())
+
+		if r.unsavedEntries >= migrationCheckpointEntries || r.unsavedBytes >= migrationCheckpointBytes {
+			err = r.migrator.saveCheckpoint(r.toDir, r.checkpoint)
+			if err != nil {
+				return err
+			}
+
+			r.unsavedEntries = 0
+			r.unsavedBytes = 0
+		}
+
+		return nil
+	})
+}
+
+func (r *migrationRun) copyEntry(relPath string, info os.FileInfo) error {
+	srcPath := filepath.Join(r.fromDir, relPath)
+	dstPath := filepath.Join(r.toDir, relPath)
+
+	mode := info.Mode()
+
+	if mode.IsDir() {
+		// Directory attributes are copied once their contents are in place
+		err := os.Mkdir(dstPath, 0700)
+		if err != nil && !os.IsExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	if mode&os.ModeSocket != 0 {
+		r.migrator.logger.Debug(r.migrator.logTag, "Skipping socket '%s'", relPath)
+		return nil
+	}
+
+	// Leftovers of an interrupted attempt may be hard links to other
+	// files, so they are replaced rather than written through
+	err := os.Remove(dstPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if linkedPath, found := r.hardlinkTarget(info); found {
+		err = os.Link(filepath.Join(r.toDir, linkedPath), dstPath)
+		if err != nil {
+			return err
+		}
+
+		r.checkpoint.FilesCopied++
+
+		return nil
+	}
+
+	switch {
+	case mode.IsRegular():
+		err = r.copyFile(srcPath, dstPath, info.Size())
+	case mode&os.ModeSymlink != 0:
+		var target string
+		target, err = os.Readlink(srcPath)
+		if err == nil {
+			err = os.Symlink(target, dstPath)
+		}
+	default:
+		err = makeSpecialFile(dstPath, info)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = copyMetadata(srcPath, dstPath, info)
+	if err != nil {
+		return err
+	}
+
+	r.rememberHardlink(info, relPath)
+	r.checkpoint.FilesCopied++
+
+	return nil
+}
+
+func (r *migrationRun) copyFile(srcPath, dstPath string, size int64) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	srcHash := sha256.New()
+
+	written, err := io.Copy(dstFile, io.TeeReader(srcFile, srcHash))
+	if err == nil {
+		err = dstFile.Sync()
+	}
+
+	// Verifying the copy has to read it from the new disk
+	// rather than from the page cache
+	if err == nil {
+		err = dropPageCache(dstFile)
+	}
+
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// The old disk is read-only while it is migrated, so a short copy
+	// means that reading it failed without an error
+	if written != size {
+		return bosherr.Errorf("Copied %d of %d bytes", written, size)
+	}
+
+	dstSum, err := r.migrator.checksum(dstPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading back copied file")
+	}
+
+	if !bytes.Equal(dstSum, srcHash.Sum(nil)) {
+		return bosherr.Errorf("Checksum of copied file does not match %s", srcPath)
+	}
+
+	r.checkpoint.BytesCopied += written
+	r.unsavedBytes += written
+
+	return nil
+}
+
+func (r *migrationRun) rememberHardlink(info os.FileInfo, relPath string) {
+	id, found := hardlinkID(info)
+	if !found {
+		return
+	}
+
+	if _, seen := r.hardlinks[id]; !seen {
+		r.hardlinks[id] = relPath
+	}
+}
+
+func (r *migrationRun) hardlinkTarget(info os.FileInfo) (string, bool) {
+	id, found := hardlinkID(info)
+	if !found {
+		return "", false
+	}
+
+	relPath, found := r.hardlinks[id]
+
+	return relPath, found
+}
+
+// copyDirMetadata goes through directories deepest first because
+// setting the timestamps of a directory must follow changes to its children
+func (r *migrationRun) copyDirMetadata() error {
+	for i := len(r.dirs) - 1; i >= 0; i-- {
+		dir := r.dirs[i]
+
+		err := copyMetadata(filepath.Join(r.fromDir, dir.relPath), filepath.Join(r.toDir, dir.relPath), dir.info)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Copying attributes of '%s'", dir.relPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build linux
+// +build linux
+
+package disk
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"golang.org/x/sys/unix"
+)
+
+// btrfsSubvolumeRootIno is the inode number of the root directory of every
+// btrfs subvolume
+const btrfsSubvolumeRootIno = 256
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// hardlinkID identifies regular files that have more than one name
+func hardlinkID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.Mode().IsRegular() || stat.Nlink < 2 {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true //nolint:unconvert
+}
+
+func isBtrfsSubvolume(path string, info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.IsDir() || stat.Ino != btrfsSubvolumeRootIno {
+		return false
+	}
+
+	var statfs unix.Statfs_t
+
+	err := unix.Statfs(path, &statfs)
+	if err != nil {
+		return false
+	}
+
+	// The type of the field differs between architectures
+	return uint32(statfs.Type) == unix.BTRFS_SUPER_MAGIC
+}
+
+func makeSpecialFile(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return bosherr.Errorf("Reading device number of %s", info.Name())
+	}
+
+	return unix.Mknod(path, stat.Mode, int(stat.Rdev))
+}
+
+func copyMetadata(srcPath, dstPath string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return bosherr.Errorf("Reading ownership of %s", srcPath)
+	}
+
+	err := unix.Lchown(dstPath, int(stat.Uid), int(stat.Gid))
+	if err != nil {
+		return bosherr.WrapError(err, "Changing ownership")
+	}
+
+	// Changing ownership clears the setuid and setgid bits,
+	// so permissions have to be set afterwards
+	if info.Mode()&os.ModeSymlink == 0 {
+		err = unix.Chmod(dstPath, stat.Mode&07777)
+		if err != nil {
+			return bosherr.WrapError(err, "Changing permissions")
+		}
+	}
+
+	err = copyXattrs(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	times := []unix.Timespec{
+		unix.NsecToTimespec(stat.Atim.Nano()),
+		unix.NsecToTimespec(stat.Mtim.Nano()),
+	}
+
+	err = unix.UtimesNanoAt(unix.AT_FDCWD, dstPath, times, unix.AT_SYMLINK_NOFOLLOW)
+	if err != nil {
+		return bosherr.WrapError(err, "Changing timestamps")
+	}
+
+	return nil
+}
+
+func copyXattrs(srcPath, dstPath string) error {
+	size, err := unix.Llistxattr(srcPath, nil)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Listing extended attributes")
+	}
+
+	if size == 0 {
+		return nil
+	}
+
+	names := make([]byte, size)
+
+	size, err = unix.Llistxattr(srcPath, names)
+	if err != nil {
+		return bosherr.WrapError(err, "Listing extended attributes")
+	}
+
+	for _, name := range strings.Split(string(names[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		size, err = unix.Lgetxattr(srcPath, name, nil)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading extended attribute %s", name)
+		}
+
+		value := make([]byte, size)
+
+		size, err = unix.Lgetxattr(srcPath, name, value)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading extended attribute %s", name)
+		}
+
+		err = unix.Lsetxattr(dstPath, name, value[:size], 0)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing extended attribute %s", name)
+		}
+	}
+
+	return nil
+}
+
+func dropPageCache(file *os.File) error {
+	return unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
+
+func syncFilesystem(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer dir.Close()
+
+	return unix.Syncfs(int(dir.Fd()))
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"errors"
+	"os"
+)
+
+var errMigrationNotSupported = errors.New("migrating persistent disks is only supported on linux")
+
+type fileID struct{}
+
+func hardlinkID(_ os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+func isBtrfsSubvolume(_ string, _ os.FileInfo) bool {
+	return false
+}
+
+func makeSpecialFile(_ string, _ os.FileInfo) error {
+	return errMigrationNotSupported
+}
+
+func copyMetadata(_, _ string, _ os.FileInfo) error {
+	return errMigrationNotSupported
+}
+
+func dropPageCache(_ *os.File) error {
+	return errMigrationNotSupported
+}
+
+func syncFilesystem(_ string) error {
+	return errMigrationNotSupported
+}
@@ -0,0 +1,342 @@
+//go:build linux
+// +build linux
+
+package disk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxMigrator", func() {
+	var (
+		fromDir  string
+		toDir    string
+		cancelCh chan struct{}
+		progress []MigrationProgress
+		migrator Migrator
+	)
+
+	progressFunc := func(p MigrationProgress) { progress = append(progress, p) }
+
+	writeFile := func(relPath, contents string) {
+		path := filepath.Join(fromDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	readCheckpoint := func() map[string]interface{} {
+		contents, err := os.ReadFile(filepath.Join(toDir, MigrationCheckpointFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		var checkpoint map[string]interface{}
+		Expect(json.Unmarshal(contents, &checkpoint)).To(Succeed())
+
+		return checkpoint
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		fromDir, err = os.MkdirTemp("", "migrator-from")
+		Expect(err).ToNot(HaveOccurred())
+
+		toDir, err = os.MkdirTemp("", "migrator-to")
+		Expect(err).ToNot(HaveOccurred())
+
+		cancelCh = make(chan struct{}, 1)
+		progress = nil
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		migrator = NewLinuxMigrator(boshsys.NewOsFileSystem(logger), logger)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(fromDir)).To(Succeed())
+		Expect(os.RemoveAll(toDir)).To(Succeed())
+	})
+
+	Describe("Migrate", func() {
+		It("copies files, directories and symlinks with their contents", func() {
+			writeFile("a/b/file", "fake-contents")
+			writeFile("top", "fake-top")
+			Expect(os.Symlink("a/b/file", filepath.Join(fromDir, "link"))).To(Succeed())
+
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(toDir, "a/b/file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("fake-contents"))
+
+			target, err := os.Readlink(filepath.Join(toDir, "link"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("a/b/file"))
+
+			Expect(progress[len(progress)-1]).To(Equal(MigrationProgress{
+				BytesCopied: int64(len("fake-contents") + len("fake-top")),
+				FilesCopied: 3,
+			}))
+		})
+
+		It("preserves permissions, ownership and timestamps", func() {
+			writeFile("dir/file", "fake-contents")
+
+			mtime := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+			if os.Getuid() == 0 {
+				Expect(os.Lchown(filepath.Join(fromDir, "dir/file"), 1000, 1001)).To(Succeed())
+			}
+			Expect(os.Chmod(filepath.Join(fromDir, "dir/file"), 0750|os.ModeSetuid)).To(Succeed())
+			Expect(os.Chmod(filepath.Join(fromDir, "dir"), 0710)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(fromDir, "dir/file"), mtime, mtime)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(fromDir, "dir"), mtime, mtime)).To(Succeed())
+
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileInfo, err := os.Lstat(filepath.Join(toDir, "dir/file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileInfo.Mode() & os.ModePerm).To(Equal(os.FileMode(0750)))
+			Expect(fileInfo.Mode() & os.ModeSetuid).ToNot(BeZero())
+			Expect(fileInfo.ModTime().UTC()).To(Equal(mtime))
+
+			if os.Getuid() == 0 {
+				stat := fileInfo.Sys().(*syscall.Stat_t)
+				Expect(stat.Uid).To(Equal(uint32(1000)))
+				Expect(stat.Gid).To(Equal(uint32(1001)))
+			}
+
+			dirInfo, err := os.Lstat(filepath.Join(toDir, "dir"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dirInfo.Mode() & os.ModePerm).To(Equal(os.FileMode(0710)))
+			Expect(dirInfo.ModTime().UTC()).To(Equal(mtime))
+		})
+
+		It("preserves extended attributes", func() {
+			writeFile("file", "fake-contents")
+
+			err := unix.Setxattr(filepath.Join(fromDir, "file"), "user.fake-attr", []byte("fake-value"), 0)
+			if err == unix.ENOTSUP {
+				Skip("Extended attributes are not supported by the temporary directory")
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			err = migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			value := make([]byte, 64)
+			size, err := unix.Getxattr(filepath.Join(toDir, "file"), "user.fake-attr", value)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(value[:size])).To(Equal("fake-value"))
+		})
+
+		It("preserves hard links", func() {
+			writeFile("file", "fake-contents")
+			Expect(os.Link(filepath.Join(fromDir, "file"), filepath.Join(fromDir, "hardlink"))).To(Succeed())
+
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileInfo, err := os.Stat(filepath.Join(toDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			linkInfo, err := os.Stat(filepath.Join(toDir, "hardlink"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.SameFile(fileInfo, linkInfo)).To(BeTrue())
+
+			Expect(progress[len(progress)-1].BytesCopied).To(Equal(int64(len("fake-contents"))))
+		})
+
+		It("leaves a completed checkpoint that prevents copying again", func() {
+			writeFile("file", "fake-contents")
+
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readCheckpoint()["completed"]).To(BeTrue())
+
+			Expect(os.Remove(filepath.Join(toDir, "file"))).To(Succeed())
+			progress = nil
+
+			err = migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(toDir, "file")).ToNot(BeAnExistingFile())
+			Expect(progress).To(Equal([]MigrationProgress{{BytesCopied: int64(len("fake-contents")), FilesCopied: 1}}))
+		})
+
+		It("does not copy the checkpoint of an earlier migration of the old disk", func() {
+			writeFile(MigrationCheckpointFileName, `{"completed":true}`)
+			writeFile("file", "fake-contents")
+
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(filepath.Join(toDir, "file")).To(BeAnExistingFile())
+			Expect(readCheckpoint()["files_copied"]).To(BeEquivalentTo(1))
+		})
+
+		Context("when a copied file does not read back like the original", func() {
+			BeforeEach(func() {
+				writeFile("a", "fake-a")
+				writeFile("b", "fake-b")
+
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				fs := corruptingFileSystem{
+					FileSystem: boshsys.NewOsFileSystem(logger),
+					path:       filepath.Join(toDir, "b"),
+				}
+				migrator = NewLinuxMigrator(fs, logger)
+			})
+
+			It("returns an error and does not record the file in the checkpoint", func() {
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Checksum of copied file does not match"))
+
+				checkpoint := readCheckpoint()
+				Expect(checkpoint["last_path"]).To(Equal("a"))
+				Expect(checkpoint["files_copied"]).To(BeEquivalentTo(1))
+				Expect(checkpoint["bytes_copied"]).To(BeEquivalentTo(len("fake-a")))
+			})
+		})
+
+		Context("when cancelled", func() {
+			BeforeEach(func() {
+				writeFile("a", "fake-a")
+				writeFile("b", "fake-b")
+
+				cancelCh <- struct{}{}
+			})
+
+			It("stops and saves a checkpoint to resume from", func() {
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).To(Equal(ErrMigrationCancelled))
+
+				Expect(filepath.Join(toDir, "a")).ToNot(BeAnExistingFile())
+				Expect(readCheckpoint()["completed"]).To(BeFalse())
+
+				err = migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(filepath.Join(toDir, "a")).To(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "b")).To(BeAnExistingFile())
+			})
+		})
+
+		Context("when resuming from a checkpoint", func() {
+			BeforeEach(func() {
+				writeFile("a", "fake-a")
+				writeFile("b", "fake-b")
+			})
+
+			It("skips the entries that were already copied and continues counting", func() {
+				checkpoint := `{"entries":2,"last_path":"a","bytes_copied":6,"files_copied":1}`
+				Expect(os.WriteFile(filepath.Join(toDir, MigrationCheckpointFileName), []byte(checkpoint), 0644)).To(Succeed())
+
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(filepath.Join(toDir, "a")).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "b")).To(BeAnExistingFile())
+
+				Expect(progress[0]).To(Equal(MigrationProgress{BytesCopied: 6, FilesCopied: 1}))
+				Expect(progress[len(progress)-1]).To(Equal(MigrationProgress{BytesCopied: 12, FilesCopied: 2}))
+			})
+
+			It("copies everything again if the old disk does not match the checkpoint", func() {
+				checkpoint := `{"entries":2,"last_path":"other","bytes_copied":6,"files_copied":1}`
+				Expect(os.WriteFile(filepath.Join(toDir, MigrationCheckpointFileName), []byte(checkpoint), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(toDir, "other"), []byte("fake-other"), 0644)).To(Succeed())
+				Expect(os.Mkdir(filepath.Join(toDir, "lost+found"), 0700)).To(Succeed())
+
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(filepath.Join(toDir, "a")).To(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "b")).To(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "other")).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "lost+found")).To(BeADirectory())
+				Expect(progress[len(progress)-1]).To(Equal(MigrationProgress{BytesCopied: 12, FilesCopied: 2}))
+			})
+
+			It("removes stale entries inside directories when it copies everything again", func() {
+				writeFile("dir/file", "fake-file")
+
+				checkpoint := `{"entries":2,"last_path":"other","bytes_copied":6,"files_copied":1}`
+				Expect(os.WriteFile(filepath.Join(toDir, MigrationCheckpointFileName), []byte(checkpoint), 0644)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(toDir, "dir/nested"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(toDir, "dir/nested/stale"), []byte("fake-stale"), 0644)).To(Succeed())
+
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(filepath.Join(toDir, "dir/file")).To(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "dir/nested")).ToNot(BeAnExistingFile())
+				Expect(readCheckpoint()["files_copied"]).To(BeEquivalentTo(3))
+			})
+
+			It("returns an error if clearing the new disk fails", func() {
+				fs := fakesys.NewFakeFileSystem()
+				fs.WalkErr = errors.New("fake-walk-err")
+
+				checkpoint := `{"entries":2,"last_path":"other","bytes_copied":6,"files_copied":1}`
+				Expect(fs.WriteFileString(filepath.Join(toDir, MigrationCheckpointFileName), checkpoint)).To(Succeed())
+
+				migrator = NewLinuxMigrator(fs, boshlog.NewLogger(boshlog.LevelNone))
+
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-walk-err"))
+			})
+
+			It("copies everything again if the checkpoint cannot be read", func() {
+				Expect(os.WriteFile(filepath.Join(toDir, MigrationCheckpointFileName), []byte("{not-json"), 0644)).To(Succeed())
+
+				err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(filepath.Join(toDir, "a")).To(BeAnExistingFile())
+				Expect(filepath.Join(toDir, "b")).To(BeAnExistingFile())
+			})
+		})
+	})
+
+	Describe("Finish", func() {
+		It("removes the checkpoint", func() {
+			err := migrator.Migrate(fromDir, toDir, cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = migrator.Finish(toDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(toDir, MigrationCheckpointFileName)).ToNot(BeAnExistingFile())
+		})
+	})
+})
+
+// corruptingFileSystem overwrites a file right before it is opened,
+// like a disk that does not keep what was written to it
+type corruptingFileSystem struct {
+	boshsys.FileSystem
+	path string
+}
+
+func (fs corruptingFileSystem) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	if path == fs.path {
+		err := os.WriteFile(path, []byte("fake-corrupted"), 0600)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fs.FileSystem.OpenFile(path, flag, perm)
+}
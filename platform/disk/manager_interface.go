@@ -3,8 +3,10 @@ package disk
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Manager
 
 type Manager interface {
+	GetEncryptor() Encryptor
 	GetEphemeralDevicePartitioner() Partitioner
 	GetFormatter() Formatter
+	GetMigrator() Migrator
 	GetMounter() Mounter
 	GetMountsSearcher() MountsSearcher
 	GetPersistentDevicePartitioner(partitionerType string) (Partitioner, error)
@@ -0,0 +1,25 @@
+package disk
+
+import "errors"
+
+var ErrMigrationCancelled = errors.New("migration cancelled")
+
+// MigrationProgress counts what has been copied to the new disk so far,
+// including work done before the migration was interrupted
+type MigrationProgress struct {
+	BytesCopied int64 `json:"bytes_copied"`
+	FilesCopied int64 `json:"files_copied"`
+}
+
+type MigrationProgressFunc func(progress MigrationProgress)
+
+type Migrator interface {
+	// Migrate copies the contents of fromDir into toDir, preserving
+	// ownership, permissions, timestamps and extended attributes.
+	// It resumes from the checkpoint left on toDir by an interrupted
+	// migration and returns ErrMigrationCancelled when cancelCh receives.
+	Migrate(fromDir, toDir string, cancelCh <-chan struct{}, progressFunc MigrationProgressFunc) error
+
+	// Finish removes the checkpoint once the copy has been put in place
+	Finish(toDir string) error
+}
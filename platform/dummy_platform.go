@@ -9,6 +9,7 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -68,7 +69,7 @@ func NewDummyPlatform(
 		copier:             boshcmd.NewGenericCpCopier(fs, logger),
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
-		vitalsService:      boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:      boshvitals.NewService(collector, dirProvider, nil, boshdisk.NewDummyHealthChecker()),
 		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
 		logger:             logger,
 		auditLogger:        auditLogger,
@@ -268,6 +269,10 @@ func (p dummyPlatform) AdjustPersistentDiskPartitioning(diskSettings boshsetting
 	return nil
 }
 
+func (p dummyPlatform) ResizePersistentDisk(diskSettings boshsettings.DiskSettings) (uint64, uint64, error) {
+	return 0, 0, nil
+}
+
 func (p dummyPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error {
 	mounts, err := p.existingMounts()
 	if err != nil {
@@ -361,7 +366,7 @@ func (p dummyPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []str
 	return
 }
 
-func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) (err error) {
 	diskMigrationsPath := filepath.Join(p.dirProvider.BoshDir(), "disk_migrations.json")
 	var diskMigrations []diskMigration
 	if p.fs.FileExists(diskMigrationsPath) {
@@ -392,6 +397,14 @@ func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string
 	return p.fs.WriteFile(diskMigrationsPath, diskMigrationsJSON)
 }
 
+func (p dummyPlatform) FreezeFilesystem(mountPoint string) (err error) {
+	return
+}
+
+func (p dummyPlatform) ThawFilesystem(mountPoint string) (err error) {
+	return
+}
+
 func (p dummyPlatform) IsMountPoint(mountPointPath string) (partitionPath string, result bool, err error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -14,6 +15,7 @@ import (
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
@@ -47,8 +49,23 @@ const (
 	sshAuthKeysFilePermissions = os.FileMode(0600)
 
 	minRootEphemeralSpaceInBytes = uint64(1024 * 1024 * 1024)
+
+	encryptedPersistentDiskPrefix = "bosh-crypt-"
+
+	// AgentCgroup is the cgroup with reserved resources the agent and monit
+	// run in, relative to the root of the cgroup hierarchy
+	AgentCgroup = "bosh-agent"
+
+	defaultAgentReservedMemoryBytes = int64(128 * 1024 * 1024)
+	defaultAgentCPUWeight           = uint64(1000)
+	agentOOMScoreAdj                = -900
+
+	// Written by runit while it supervises monit
+	monitPidPath = "/etc/service/monit/supervise/pid"
 )
 
+var encryptedPersistentDiskNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
 type LinuxOptions struct {
 	// When set to true loop back device
 	// is not going to be overlayed over /tmp to limit /tmp dir size
@@ -76,6 +93,17 @@ type LinuxOptions struct {
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
+
+	// When set to true the agent and monit are neither moved to a cgroup
+	// with reserved resources nor made less likely to be OOM killed
+	SkipAgentProtection bool
+
+	// Memory reserved for the agent and monit; defaults to 128MiB
+	AgentReservedMemoryBytes int64
+
+	// CPU weight of the agent and monit relative to jobs' default of 100;
+	// defaults to 1000
+	AgentCPUWeight uint64
 }
 
 type linux struct {
@@ -92,6 +120,7 @@ type linux struct {
 	certManager            boshcert.Manager
 	monitRetryStrategy     boshretry.RetryStrategy
 	devicePathResolver     boshdpresolv.DevicePathResolver
+	cgroupManager          boshcgroup.Manager
 	options                LinuxOptions
 	state                  *BootstrapState
 	logger                 boshlog.Logger
@@ -114,6 +143,7 @@ func NewLinuxPlatform(
 	certManager boshcert.Manager,
 	monitRetryStrategy boshretry.RetryStrategy,
 	devicePathResolver boshdpresolv.DevicePathResolver,
+	cgroupManager boshcgroup.Manager,
 	state *BootstrapState,
 	options LinuxOptions,
 	logger boshlog.Logger,
@@ -135,6 +165,7 @@ func NewLinuxPlatform(
 		certManager:            certManager,
 		monitRetryStrategy:     monitRetryStrategy,
 		devicePathResolver:     devicePathResolver,
+		cgroupManager:          cgroupManager,
 		state:                  state,
 		options:                options,
 		logger:                 logger,
@@ -232,9 +263,87 @@ func (p linux) GetHostPublicKey() (string, error) {
 func (p linux) SetupRuntimeConfiguration() (err error) {
 	_, _, _, err = p.cmdRunner.RunCommand("bosh-agent-rc")
 	if err != nil {
-		err = bosherr.WrapError(err, "Shelling out to bosh-agent-rc")
+		return bosherr.WrapError(err, "Shelling out to bosh-agent-rc")
+	}
+
+	p.protectProcess("agent", os.Getpid())
+
+	// Monit is already running when the agent restarts
+	if p.fs.FileExists(monitPidPath) {
+		p.protectMonit()
+	}
+
+	return nil
+}
+
+// protectProcess moves the process to the agent cgroup and lowers its OOM
+// score so that it keeps running when jobs use up the VM's resources.
+// Failing to do so does not stop the agent from working.
+func (p linux) protectProcess(name string, pid int) {
+	if p.options.SkipAgentProtection {
+		return
+	}
+
+	if p.cgroupManager.Version() == boshcgroup.VersionUnsupported {
+		p.logger.Debug(logTag, "Not moving %s to a cgroup: cgroups are not supported", name)
+	} else {
+		err := p.addToAgentCgroup(pid)
+		if err != nil {
+			p.logger.Warn(logTag, "Moving %s to cgroup %s: %s", name, AgentCgroup, err.Error())
+		}
+	}
+
+	oomScoreAdjPath := fmt.Sprintf("/proc/%d/oom_score_adj", pid)
+
+	err := p.fs.WriteFileString(oomScoreAdjPath, strconv.Itoa(agentOOMScoreAdj))
+	if err != nil {
+		p.logger.Warn(logTag, "Adjusting OOM score of %s: %s", name, err.Error())
 	}
-	return
+}
+
+// protectMonit protects monit like the agent. Job processes inherit
+// monit's OOM score adjustment and cgroup until they join their own cgroup,
+// or the root cgroup when that fails, which resets the adjustment, so monit
+// is only protected when jobs run in cgroups.
+func (p linux) protectMonit() {
+	if p.cgroupManager.Version() == boshcgroup.VersionUnsupported {
+		return
+	}
+
+	contents, err := p.fs.ReadFileString(monitPidPath)
+	if err != nil {
+		p.logger.Warn(logTag, "Reading monit pid: %s", err.Error())
+		return
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil {
+		p.logger.Warn(logTag, "Parsing monit pid '%s': %s", contents, err.Error())
+		return
+	}
+
+	p.protectProcess("monit", pid)
+}
+
+func (p linux) addToAgentCgroup(pid int) error {
+	resources := boshcgroup.Resources{
+		CPUWeight:              p.options.AgentCPUWeight,
+		MemoryReservationBytes: p.options.AgentReservedMemoryBytes,
+	}
+
+	if resources.CPUWeight == 0 {
+		resources.CPUWeight = defaultAgentCPUWeight
+	}
+	if resources.MemoryReservationBytes == 0 {
+		resources.MemoryReservationBytes = defaultAgentReservedMemoryBytes
+	}
+
+	cg, err := p.cgroupManager.Create(AgentCgroup, resources)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating agent cgroup")
+	}
+
+	return cg.AddProcess(pid)
 }
 
 func (p linux) CreateUser(username, basePath string) error {
@@ -1230,17 +1339,31 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.WrapError(err, "Resizing disk partition")
 		}
 
-		err := p.diskManager.GetMounter().Mount(firstPartitionPath, mountPoint, diskSetting.MountOptions...)
+		filesystemPath := firstPartitionPath
+
+		if diskSetting.Encryption.Enabled {
+			filesystemPath, err = p.openEncryptedPersistentDisk(firstPartitionPath, diskSetting)
+			if err != nil {
+				return err
+			}
+
+			err = p.diskManager.GetEncryptor().Resize(encryptedPersistentDiskName(diskSetting), diskSetting.Encryption)
+			if err != nil {
+				return bosherr.WrapError(err, "Resizing encrypted partition")
+			}
+		}
+
+		err := p.diskManager.GetMounter().Mount(filesystemPath, mountPoint, diskSetting.MountOptions...)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to mount partition for filesystem growing")
 		}
 
-		err = p.diskManager.GetFormatter().GrowFilesystem(firstPartitionPath)
+		err = p.diskManager.GetFormatter().GrowFilesystem(filesystemPath)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow filesystem")
 		}
 
-		_, err = p.diskManager.GetMounter().Unmount(firstPartitionPath)
+		_, err = p.diskManager.GetMounter().Unmount(filesystemPath)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to unmount partition after filesystem growing")
 		}
@@ -1255,7 +1378,7 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 
 		persistentDiskFS := diskSetting.FileSystemType
 		switch persistentDiskFS {
-		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS:
+		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS, boshdisk.FileSystemBtrfs:
 		case boshdisk.FileSystemDefault:
 			persistentDiskFS = boshdisk.FileSystemExt4
 		case boshdisk.FileSystemSwap:
@@ -1264,7 +1387,16 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.Error(fmt.Sprintf(`The filesystem type "%s" is not supported`, diskSetting.FileSystemType))
 		}
 
-		err = p.diskManager.GetFormatter().Format(firstPartitionPath, persistentDiskFS)
+		filesystemPath := firstPartitionPath
+
+		if diskSetting.Encryption.Enabled {
+			filesystemPath, err = p.encryptPersistentDiskPartition(firstPartitionPath, diskSetting)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = p.diskManager.GetFormatter().Format(filesystemPath, persistentDiskFS)
 		if err != nil {
 			return bosherr.WrapError(err, fmt.Sprintf("Formatting partition with %s", diskSetting.FileSystemType))
 		}
@@ -1272,6 +1404,115 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 	return nil
 }
 
+// ResizePersistentDisk grows the partition and filesystem of a mounted
+// persistent disk after its volume was grown by the IaaS.
+// It returns the size of the partition before and after growing it.
+func (p linux) ResizePersistentDisk(diskSetting boshsettings.DiskSettings) (uint64, uint64, error) {
+	if p.options.UsePreformattedPersistentDisk {
+		return 0, 0, bosherr.Error("Resizing preformatted persistent disks is not supported")
+	}
+	p.logger.Debug(logTag, "Resizing persistent disk %+v", diskSetting)
+
+	devicePath, _, err := p.devicePathResolver.GetRealDevicePath(diskSetting)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Getting real device path")
+	}
+
+	err = p.rescanBlockDevice(devicePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	partitioner, err := p.diskManager.GetPersistentDevicePartitioner(diskSetting.Partitioner)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Selecting partitioner")
+	}
+
+	oldSize, err := p.singlePartitionSize(partitioner, devicePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	needsResize, err := partitioner.SinglePartitionNeedsResize(devicePath, boshdisk.PartitionTypeLinux)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Failed to determine whether partitions need rezising")
+	}
+
+	if !needsResize {
+		p.logger.Info(logTag, "Persistent disk partition on %s already fills the disk", devicePath)
+		return oldSize, oldSize, nil
+	}
+
+	err = partitioner.ResizeSinglePartition(devicePath)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Resizing disk partition")
+	}
+
+	filesystemPath := p.partitionPath(devicePath, 1)
+
+	if diskSetting.Encryption.Enabled {
+		encryptor := p.diskManager.GetEncryptor()
+
+		err = encryptor.Resize(encryptedPersistentDiskName(diskSetting), diskSetting.Encryption)
+		if err != nil {
+			return 0, 0, bosherr.WrapError(err, "Resizing encrypted partition")
+		}
+
+		filesystemPath = encryptor.MappedDevicePath(encryptedPersistentDiskName(diskSetting))
+	}
+
+	err = p.diskManager.GetFormatter().GrowFilesystem(filesystemPath)
+	if err != nil {
+		return 0, 0, bosherr.WrapError(err, "Failed to grow filesystem")
+	}
+
+	newSize, err := p.singlePartitionSize(partitioner, devicePath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	p.logger.Info(logTag, "Resized persistent disk partition on %s from %d to %d bytes", devicePath, oldSize, newSize)
+
+	return oldSize, newSize, nil
+}
+
+// rescanBlockDevice makes the kernel pick up the new size of a SCSI disk.
+// Other disks, e.g. virtio and NVMe, report size changes on their own.
+func (p linux) rescanBlockDevice(devicePath string) error {
+	realPath, err := p.fs.ReadAndFollowLink(devicePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resolving device path %s", devicePath)
+	}
+
+	rescanPath := path.Join("/sys/class/block", path.Base(realPath), "device", "rescan")
+	if !p.fs.FileExists(rescanPath) {
+		p.logger.Debug(logTag, "Not rescanning %s since %s does not exist", realPath, rescanPath)
+		return nil
+	}
+
+	p.logger.Info(logTag, "Rescanning block device %s", realPath)
+
+	err = p.fs.WriteFileString(rescanPath, "1")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Rescanning block device %s", realPath)
+	}
+
+	return nil
+}
+
+func (p linux) singlePartitionSize(partitioner boshdisk.Partitioner, devicePath string) (uint64, error) {
+	partitions, _, err := partitioner.GetPartitions(devicePath)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Getting existing partitions")
+	}
+
+	if len(partitions) != 1 {
+		return 0, bosherr.Errorf("Expected persistent disk %s to have 1 partition, got %d", devicePath, len(partitions))
+	}
+
+	return partitions[0].SizeInBytes, nil
+}
+
 func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountPoint string) error {
 	p.logger.Debug(logTag, "Mounting persistent disk %+v at %s", diskSetting, mountPoint)
 
@@ -1287,8 +1528,14 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	p.logger.Info(logTag, "devicePath = %s, alreadyMountedPartPath = %s, hasMountedDevice = %t", devicePath, alreadyMountedPartPath, hasMountedDevice)
 
 	firstPartitionPath := p.partitionPath(devicePath, 1)
+
+	expectedMountedPartPath := firstPartitionPath
+	if diskSetting.Encryption.Enabled {
+		expectedMountedPartPath = p.diskManager.GetEncryptor().MappedDevicePath(encryptedPersistentDiskName(diskSetting))
+	}
+
 	if hasMountedDevice {
-		if alreadyMountedPartPath == firstPartitionPath {
+		if alreadyMountedPartPath == expectedMountedPartPath {
 			p.logger.Info(logTag, "device: %s is already mounted on %s, skipping mounting", alreadyMountedPartPath, mountPoint)
 			return nil
 		}
@@ -1308,6 +1555,13 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 		partitionPathToMount = firstPartitionPath
 	}
 
+	if diskSetting.Encryption.Enabled {
+		partitionPathToMount, err = p.openEncryptedPersistentDisk(partitionPathToMount, diskSetting)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = p.diskManager.GetMounter().Mount(partitionPathToMount, mountPoint, diskSetting.MountOptions...)
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting partition")
@@ -1338,7 +1592,24 @@ func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bo
 		realPath = p.partitionPath(realPath, 1)
 	}
 
-	return p.diskManager.GetMounter().Unmount(realPath)
+	if !diskSettings.Encryption.Enabled {
+		return p.diskManager.GetMounter().Unmount(realPath)
+	}
+
+	encryptor := p.diskManager.GetEncryptor()
+	name := encryptedPersistentDiskName(diskSettings)
+
+	didUnmount, err := p.diskManager.GetMounter().Unmount(encryptor.MappedDevicePath(name))
+	if err != nil {
+		return false, err
+	}
+
+	err = encryptor.Close(name)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Closing encrypted persistent disk")
+	}
+
+	return didUnmount, nil
 }
 
 func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error) {
@@ -1370,38 +1641,43 @@ func (p linux) IsMountPoint(path string) (string, bool, error) {
 	return p.diskManager.GetMounter().IsMountPoint(path)
 }
 
-func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error {
+func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) error {
 	p.logger.Debug(logTag, "Migrating persistent disk %v to %v", fromMountPoint, toMountPoint)
 
-	err := p.diskManager.GetMounter().RemountAsReadonly(fromMountPoint)
+	// A migration resumed after the new disk already replaced the old one
+	// must not copy the new disk onto the root filesystem
+	_, isMountPoint, err := p.diskManager.GetMounter().IsMountPoint(toMountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking new persistent disk mount point")
+	}
+
+	if !isMountPoint {
+		return bosherr.Errorf("New persistent disk is not mounted on %s", toMountPoint)
+	}
+
+	err = p.diskManager.GetMounter().RemountAsReadonly(fromMountPoint)
 	if err != nil {
 		return bosherr.WrapError(err, "Remounting persistent disk as readonly")
 	}
 
-	// Golang does not implement a file copy that would allow us to preserve dates...
-	// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
-	tarCopy := fmt.Sprintf("(tar -C %s --xattrs -cf - .) | (tar -C %s --xattrs -xpf -)", fromMountPoint, toMountPoint)
-	_, _, _, err = p.cmdRunner.RunCommand("sh", "-c", tarCopy)
+	fromPartitionPath, err := p.copyPersistentDisk(fromMountPoint, toMountPoint, cancelCh, progressFunc)
 	if err != nil {
-		return bosherr.WrapError(err, "Copying files from old disk to new disk")
+		// The old disk stays in use until a later attempt finishes copying
+		remountErr := p.diskManager.GetMounter().RemountInPlace(fromMountPoint, "rw")
+		if remountErr != nil {
+			p.logger.Error(logTag, "Remounting old persistent disk as read-write: %s", remountErr.Error())
+		}
+
+		return err
 	}
 
 	// Find iSCSI device id of fromMountPoint
 	var iscsiID string
 	if p.options.DevicePathResolutionType == "iscsi" {
-		mounts, err := p.diskManager.GetMountsSearcher().SearchMounts()
-		if err != nil {
-			return bosherr.WrapError(err, "Search persistent disk as readonly")
-		}
-
 		devMapperPart1Regexp := regexp.MustCompile(`/dev/mapper/(.*?)-part1`)
-		for _, mount := range mounts {
-			if mount.MountPoint == fromMountPoint {
-				matches := devMapperPart1Regexp.FindStringSubmatch(mount.PartitionPath)
-				if len(matches) > 1 {
-					iscsiID = matches[1]
-				}
-			}
+		matches := devMapperPart1Regexp.FindStringSubmatch(fromPartitionPath)
+		if len(matches) > 1 {
+			iscsiID = matches[1]
 		}
 	}
 
@@ -1410,6 +1686,17 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Unmounting old persistent disk")
 	}
 
+	// The new disk keeps its LUKS container open when it is moved to the
+	// original mount point, only the old disk's container has to go
+	encryptor := p.diskManager.GetEncryptor()
+	fromEncryptedName := filepath.Base(fromPartitionPath)
+	if strings.HasPrefix(fromEncryptedName, encryptedPersistentDiskPrefix) && fromPartitionPath == encryptor.MappedDevicePath(fromEncryptedName) {
+		err = encryptor.Close(fromEncryptedName)
+		if err != nil {
+			return bosherr.WrapError(err, "Closing old encrypted persistent disk")
+		}
+	}
+
 	err = p.diskManager.GetMounter().Remount(toMountPoint, fromMountPoint)
 	if err != nil {
 		err = bosherr.WrapError(err, "Remounting new disk on original mountpoint")
@@ -1425,6 +1712,132 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 	return err
 }
 
+// copyPersistentDisk copies the old disk, which has to be mounted read-only,
+// onto the new one and returns the partition path of the old disk
+func (p linux) copyPersistentDisk(fromMountPoint, toMountPoint string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) (string, error) {
+	mounts, err := p.diskManager.GetMountsSearcher().SearchMounts()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Search persistent disk as readonly")
+	}
+
+	var fromPartitionPath, toPartitionPath string
+	for _, mount := range mounts {
+		switch mount.MountPoint {
+		case fromMountPoint:
+			fromPartitionPath = mount.PartitionPath
+		case toMountPoint:
+			toPartitionPath = mount.PartitionPath
+		}
+	}
+
+	if fromPartitionPath != "" && toPartitionPath != "" {
+		err = p.copyBtrfsSubvolumes(fromPartitionPath, toPartitionPath, fromMountPoint, toMountPoint)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// The migrator keeps a checkpoint on the new disk,
+	// so an interrupted migration continues where it stopped
+	migrator := p.diskManager.GetMigrator()
+
+	err = migrator.Migrate(fromMountPoint, toMountPoint, cancelCh, progressFunc)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Copying files from old disk to new disk")
+	}
+
+	err = migrator.Finish(toMountPoint)
+	if err != nil {
+		return "", err
+	}
+
+	return fromPartitionPath, nil
+}
+
+func (p linux) FreezeFilesystem(mountPoint string) error {
+	p.logger.Info(logTag, "Freezing filesystem mounted on %s", mountPoint)
+
+	_, _, _, err := p.cmdRunner.RunCommand("fsfreeze", "--freeze", mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to fsfreeze --freeze")
+	}
+
+	return nil
+}
+
+func (p linux) ThawFilesystem(mountPoint string) error {
+	p.logger.Info(logTag, "Thawing filesystem mounted on %s", mountPoint)
+
+	_, stderr, _, err := p.cmdRunner.RunCommand("fsfreeze", "--unfreeze", mountPoint)
+	if err != nil {
+		// The kernel refuses to thaw a filesystem that is not frozen
+		if strings.Contains(stderr, "Invalid argument") {
+			return nil
+		}
+
+		return bosherr.WrapError(err, "Shelling out to fsfreeze --unfreeze")
+	}
+
+	return nil
+}
+
+// copyBtrfsSubvolumes recreates the subvolumes of a btrfs disk on a new
+// btrfs disk so that the migrator fills them instead of plain directories.
+// Snapshots are copied in full and become ordinary writable subvolumes.
+func (p linux) copyBtrfsSubvolumes(fromPartitionPath, toPartitionPath, fromMountPoint, toMountPoint string) error {
+	formatter := p.diskManager.GetFormatter()
+
+	for _, partitionPath := range []string{fromPartitionPath, toPartitionPath} {
+		fsType, err := formatter.GetPartitionFormatType(partitionPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting filesystem type of %s", partitionPath)
+		}
+
+		if fsType != boshdisk.FileSystemBtrfs {
+			return nil
+		}
+	}
+
+	stdout, _, _, err := p.cmdRunner.RunCommand("btrfs", "subvolume", "list", fromMountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Listing btrfs subvolumes of old disk")
+	}
+
+	subvolumes := []string{}
+	for _, line := range strings.Split(stdout, "\n") {
+		_, subvolume, found := strings.Cut(line, " path ")
+		if found && subvolume != "" {
+			subvolumes = append(subvolumes, subvolume)
+		}
+	}
+
+	// Parents must exist before nested subvolumes are created in them
+	sort.SliceStable(subvolumes, func(i, j int) bool {
+		return strings.Count(subvolumes[i], "/") < strings.Count(subvolumes[j], "/")
+	})
+
+	for _, subvolume := range subvolumes {
+		subvolumePath := filepath.Join(toMountPoint, subvolume)
+
+		// Subvolumes are already there when resuming an interrupted migration
+		if p.fs.FileExists(subvolumePath) {
+			continue
+		}
+
+		err = p.fs.MkdirAll(filepath.Dir(subvolumePath), persistentDiskPermissions)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating parent directory of btrfs subvolume %s", subvolume)
+		}
+
+		_, _, _, err = p.cmdRunner.RunCommand("btrfs", "subvolume", "create", subvolumePath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating btrfs subvolume %s on new disk", subvolume)
+		}
+	}
+
+	return nil
+}
+
 func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (bool, error) {
 	p.logger.Debug(logTag, "Checking whether persistent disk %+v is mounted", diskSettings)
 	realPath, timedOut, err := p.devicePathResolver.GetRealDevicePath(diskSettings)
@@ -1440,9 +1853,58 @@ func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (
 		realPath = p.partitionPath(realPath, 1)
 	}
 
+	if diskSettings.Encryption.Enabled {
+		realPath = p.diskManager.GetEncryptor().MappedDevicePath(encryptedPersistentDiskName(diskSettings))
+	}
+
 	return p.diskManager.GetMounter().IsMounted(realPath)
 }
 
+// encryptedPersistentDiskName is the device mapper name of the LUKS
+// container holding a persistent disk's filesystem
+func encryptedPersistentDiskName(diskSetting boshsettings.DiskSettings) string {
+	return encryptedPersistentDiskPrefix + encryptedPersistentDiskNameRegexp.ReplaceAllString(diskSetting.ID, "-")
+}
+
+func (p linux) openEncryptedPersistentDisk(partitionPath string, diskSetting boshsettings.DiskSettings) (string, error) {
+	mappedPath, err := p.diskManager.GetEncryptor().Open(partitionPath, encryptedPersistentDiskName(diskSetting), diskSetting.Encryption)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Opening encrypted partition %s", partitionPath)
+	}
+
+	return mappedPath, nil
+}
+
+// encryptPersistentDiskPartition creates a LUKS container on a new partition
+// and opens it. Partitions that already hold a filesystem are never
+// encrypted in place as that would destroy their data.
+func (p linux) encryptPersistentDiskPartition(partitionPath string, diskSetting boshsettings.DiskSettings) (string, error) {
+	encryptor := p.diskManager.GetEncryptor()
+
+	isEncrypted, err := encryptor.IsEncrypted(partitionPath)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Checking whether partition %s is encrypted", partitionPath)
+	}
+
+	if !isEncrypted {
+		existingFS, err := p.diskManager.GetFormatter().GetPartitionFormatType(partitionPath)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Checking filesystem of partition %s", partitionPath)
+		}
+
+		if existingFS != boshdisk.FileSystemDefault {
+			return "", bosherr.Errorf("Refusing to encrypt partition %s which already has a %s filesystem", partitionPath, existingFS)
+		}
+
+		err = encryptor.Format(partitionPath, diskSetting.Encryption)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Encrypting partition %s", partitionPath)
+		}
+	}
+
+	return p.openEncryptedPersistentDisk(partitionPath, diskSetting)
+}
+
 func (p linux) StartMonit() error {
 	err := p.fs.Symlink(path.Join("/etc", "sv", "monit"), path.Join("/etc", "service", "monit"))
 	if err != nil {
@@ -1454,6 +1916,8 @@ func (p linux) StartMonit() error {
 		return bosherr.WrapError(err, "Retrying to start monit")
 	}
 
+	p.protectMonit()
+
 	return nil
 }
 
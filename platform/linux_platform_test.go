@@ -20,6 +20,8 @@ import (
 	fakedpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver/fakes"
 	fakecdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom/fakes"
 	"github.com/cloudfoundry/bosh-agent/platform/cert/certfakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup/fakes"
 	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
 	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
@@ -46,6 +48,7 @@ var _ = Describe("LinuxPlatform", func() {
 		diskManager                *diskfakes.FakeManager
 		dirProvider                boshdirs.Provider
 		devicePathResolver         *fakedpresolv.FakeDevicePathResolver
+		cgroupManager              *fakecgroup.FakeManager
 		platform                   Platform
 		cdutil                     *fakecdrom.FakeCDUtil
 		compressor                 boshcmd.Compressor
@@ -70,6 +73,8 @@ var _ = Describe("LinuxPlatform", func() {
 		mounter        *diskfakes.FakeMounter
 		mountsSearcher *fakedisk.FakeMountsSearcher
 		diskUtil       *fakedisk.FakeDiskUtil
+		encryptor      *fakedisk.FakeEncryptor
+		migrator       *fakedisk.FakeMigrator
 	)
 
 	BeforeEach(func() {
@@ -86,6 +91,7 @@ var _ = Describe("LinuxPlatform", func() {
 		certManager = new(certfakes.FakeManager)
 		monitRetryStrategy = fakeretry.NewFakeRetryStrategy()
 		devicePathResolver = fakedpresolv.NewFakeDevicePathResolver()
+		cgroupManager = fakecgroup.NewFakeManager()
 		fakeDefaultNetworkResolver = &fakenet.FakeDefaultNetworkResolver{}
 
 		fakeUUIDGenerator = fakeuuidgen.NewFakeGenerator()
@@ -124,7 +130,13 @@ var _ = Describe("LinuxPlatform", func() {
 		diskUtil = fakedisk.NewFakeDiskUtil()
 		diskManager.GetUtilReturns(diskUtil)
 
-		vitalsService = boshvitals.NewService(collector, dirProvider, mounter)
+		encryptor = fakedisk.NewFakeEncryptor()
+		diskManager.GetEncryptorReturns(encryptor)
+
+		migrator = fakedisk.NewFakeMigrator()
+		diskManager.GetMigratorReturns(migrator)
+
+		vitalsService = boshvitals.NewService(collector, dirProvider, mounter, boshdisk.NewDummyHealthChecker())
 	})
 
 	JustBeforeEach(func() {
@@ -142,6 +154,7 @@ var _ = Describe("LinuxPlatform", func() {
 			certManager,
 			monitRetryStrategy,
 			devicePathResolver,
+			cgroupManager,
 			state,
 			options,
 			logger,
@@ -159,6 +172,89 @@ var _ = Describe("LinuxPlatform", func() {
 			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
 			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"bosh-agent-rc"}))
 		})
+
+		It("moves the agent to a cgroup with reserved resources", func() {
+			err := platform.SetupRuntimeConfiguration()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cgroupManager.Resources).To(HaveKeyWithValue("bosh-agent", boshcgroup.Resources{
+				CPUWeight:              1000,
+				MemoryReservationBytes: 128 * 1024 * 1024,
+			}))
+			Expect(cgroupManager.Cgroups["bosh-agent"].AddedPids).To(Equal([]int{os.Getpid()}))
+		})
+
+		It("lowers the OOM score of the agent", func() {
+			err := platform.SetupRuntimeConfiguration()
+			Expect(err).NotTo(HaveOccurred())
+
+			oomScoreAdj, err := fs.ReadFileString(fmt.Sprintf("/proc/%d/oom_score_adj", os.Getpid()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oomScoreAdj).To(Equal("-900"))
+		})
+
+		It("protects monit when it is already running", func() {
+			err := fs.WriteFileString("/etc/service/monit/supervise/pid", "1234\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = platform.SetupRuntimeConfiguration()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cgroupManager.Cgroups["bosh-agent"].AddedPids).To(Equal([]int{os.Getpid(), 1234}))
+			Expect(fs.ReadFileString("/proc/1234/oom_score_adj")).To(Equal("-900"))
+		})
+
+		It("does not protect monit when cgroups are not supported", func() {
+			cgroupManager.VersionValue = boshcgroup.VersionUnsupported
+
+			err := fs.WriteFileString("/etc/service/monit/supervise/pid", "1234\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = platform.SetupRuntimeConfiguration()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cgroupManager.Cgroups).To(BeEmpty())
+			Expect(fs.FileExists("/proc/1234/oom_score_adj")).To(BeFalse())
+		})
+
+		It("still succeeds when the agent cannot be protected", func() {
+			cgroupManager.CreateErr = errors.New("fake-create-err")
+			fs.WriteFileErrors[fmt.Sprintf("/proc/%d/oom_score_adj", os.Getpid())] = errors.New("fake-write-err")
+
+			err := platform.SetupRuntimeConfiguration()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when agent protection is skipped", func() {
+			BeforeEach(func() {
+				options.SkipAgentProtection = true
+			})
+
+			It("leaves the agent as it is", func() {
+				err := platform.SetupRuntimeConfiguration()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cgroupManager.Cgroups).To(BeEmpty())
+				Expect(fs.FileExists(fmt.Sprintf("/proc/%d/oom_score_adj", os.Getpid()))).To(BeFalse())
+			})
+		})
+
+		Context("when the reserved resources are configured", func() {
+			BeforeEach(func() {
+				options.AgentReservedMemoryBytes = 256
+				options.AgentCPUWeight = 500
+			})
+
+			It("reserves the configured resources", func() {
+				err := platform.SetupRuntimeConfiguration()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cgroupManager.Resources).To(HaveKeyWithValue("bosh-agent", boshcgroup.Resources{
+					CPUWeight:              500,
+					MemoryReservationBytes: 256,
+				}))
+			})
+		})
 	})
 
 	Describe("CreateUser", func() {
@@ -458,6 +554,7 @@ bosh_foobar:...`
 					certManager,
 					monitRetryStrategy,
 					devicePathResolver,
+					cgroupManager,
 					state,
 					options,
 					logger,
@@ -693,6 +790,7 @@ bosh_foobar:...`
 						certManager,
 						monitRetryStrategy,
 						devicePathResolver,
+						cgroupManager,
 						state,
 						options,
 						logger,
@@ -3114,6 +3212,19 @@ sam:fakeanotheruser`)
 				})
 			})
 
+			Context("when settings specify btrfs filesysem", func() {
+				BeforeEach(func() {
+					diskSettings.FileSystemType = boshdisk.FileSystemBtrfs
+				})
+
+				It("formats with a btrfs filesysem", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(formatter.FormatFsTypes).To(Equal([]boshdisk.FileSystemType{boshdisk.FileSystemBtrfs}))
+				})
+			})
+
 			Context("when settings specify an unsupported filesystem", func() {
 				BeforeEach(func() {
 					diskSettings.FileSystemType = boshdisk.FileSystemType("blahblah")
@@ -3140,6 +3251,74 @@ sam:fakeanotheruser`)
 				})
 			})
 
+			Context("when persistent disk encryption is enabled", func() {
+				BeforeEach(func() {
+					diskSettings.Encryption = boshdisk.Encryption{Enabled: true, Key: "fake-key"}
+				})
+
+				It("encrypts the first partition and formats the opened container", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.FormatDevices).To(Equal([]string{"fake-real-device-path1"}))
+					Expect(encryptor.OpenDevices).To(Equal([]string{"fake-real-device-path1"}))
+					Expect(encryptor.OpenNames).To(Equal([]string{"bosh-crypt-fake-unique-id"}))
+					Expect(encryptor.OpenEncryptions).To(Equal([]boshdisk.Encryption{diskSettings.Encryption}))
+					Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/bosh-crypt-fake-unique-id"}))
+				})
+
+				It("does not encrypt a partition that already is encrypted", func() {
+					encryptor.Encrypted["fake-real-device-path1"] = true
+
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.FormatDevices).To(BeEmpty())
+					Expect(encryptor.OpenDevices).To(Equal([]string{"fake-real-device-path1"}))
+					Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/bosh-crypt-fake-unique-id"}))
+				})
+
+				It("refuses to encrypt a partition that already has a filesystem", func() {
+					formatter.GetFileSystemType["fake-real-device-path1"] = boshdisk.FileSystemExt4
+
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Refusing to encrypt partition fake-real-device-path1 which already has a ext4 filesystem"))
+
+					Expect(encryptor.FormatDevices).To(BeEmpty())
+					Expect(formatter.FormatCalled).To(BeFalse())
+				})
+
+				It("returns an error if the container cannot be opened", func() {
+					encryptor.OpenErr = errors.New("fake-open-err")
+
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+					Expect(formatter.FormatCalled).To(BeFalse())
+				})
+
+				Context("when partition needs resize after IaaS-native disk resize", func() {
+					BeforeEach(func() {
+						partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+					})
+
+					It("resizes the container and grows the filesystem inside it", func() {
+						err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(encryptor.OpenDevices).To(Equal([]string{"fake-real-device-path1"}))
+						Expect(encryptor.ResizedNames).To(Equal([]string{"bosh-crypt-fake-unique-id"}))
+
+						Expect(mounter.MountCallCount()).To(Equal(1))
+						partition, _, _ := mounter.MountArgsForCall(0)
+						Expect(partition).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+						Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+						Expect(mounter.UnmountArgsForCall(0)).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+					})
+				})
+			})
+
 			Context("when disk could not be formatted", func() {
 				BeforeEach(func() {
 					formatter.FormatError = errors.New("oh noes")
@@ -3158,6 +3337,141 @@ sam:fakeanotheruser`)
 		})
 	})
 
+	Describe("ResizePersistentDisk", func() {
+		var diskSettings boshsettings.DiskSettings
+
+		BeforeEach(func() {
+			diskSettings = boshsettings.DiskSettings{ID: "fake-unique-id", Path: "fake-volume-id"}
+
+			devicePathResolver.RealDevicePath = "/dev/sdf"
+			err := fs.WriteFileString("/dev/sdf", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			partitioner.GetPartitionsPartitions = []boshdisk.ExistingPartition{{Index: 1, SizeInBytes: 1024, Type: boshdisk.PartitionTypeLinux}}
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+			partitioner.ResizeSinglePartitionStub = func(string) error {
+				partitioner.GetPartitionsPartitions = []boshdisk.ExistingPartition{{Index: 1, SizeInBytes: 4096, Type: boshdisk.PartitionTypeLinux}}
+				return nil
+			}
+		})
+
+		It("grows the partition and the mounted filesystem and returns the old and new partition size", func() {
+			oldSize, newSize, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oldSize).To(Equal(uint64(1024)))
+			Expect(newSize).To(Equal(uint64(4096)))
+
+			Expect(partitioner.SinglePartitionNeedsResizeDevicePath).To(Equal("/dev/sdf"))
+			Expect(partitioner.ResizeSinglePartitionDevicePath).To(Equal("/dev/sdf"))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/sdf1"))
+
+			Expect(mounter.MountCallCount()).To(Equal(0))
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+		})
+
+		It("rescans the block device behind the device path so that the kernel sees the new size", func() {
+			devicePathResolver.RealDevicePath = "/dev/disk/by-id/fake-disk"
+			err := fs.Symlink("/dev/sdf", "/dev/disk/by-id/fake-disk")
+			Expect(err).NotTo(HaveOccurred())
+			err = fs.WriteFileString("/sys/class/block/sdf/device/rescan", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = platform.ResizePersistentDisk(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.ReadFileString("/sys/class/block/sdf/device/rescan")).To(Equal("1"))
+		})
+
+		It("does not rescan block devices that cannot be rescanned", func() {
+			_, _, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists("/sys/class/block/sdf/device/rescan")).To(BeFalse())
+		})
+
+		It("returns the current size without growing anything if the partition already fills the disk", func() {
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = false
+
+			oldSize, newSize, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oldSize).To(Equal(uint64(1024)))
+			Expect(newSize).To(Equal(uint64(1024)))
+
+			Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+		})
+
+		It("uses the requested persistent disk partitioner", func() {
+			diskSettings.Partitioner = "cool-partitioner"
+
+			_, _, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diskManager.GetPersistentDevicePartitionerArgsForCall(0)).To(Equal("cool-partitioner"))
+		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			BeforeEach(func() {
+				diskSettings.Encryption = boshdisk.Encryption{Enabled: true, Key: "fake-key"}
+			})
+
+			It("resizes the opened container and grows the filesystem inside it", func() {
+				_, _, err := platform.ResizePersistentDisk(diskSettings)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(encryptor.ResizedNames).To(Equal([]string{"bosh-crypt-fake-unique-id"}))
+				Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+			})
+
+			It("returns an error if resizing the container fails", func() {
+				encryptor.ResizeErr = errors.New("fake-resize-err")
+
+				_, _, err := platform.ResizePersistentDisk(diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-resize-err"))
+				Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+			})
+		})
+
+		It("returns an error if the disk does not have a single partition", func() {
+			partitioner.GetPartitionsPartitions = nil
+
+			_, _, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("to have 1 partition, got 0"))
+		})
+
+		It("returns an error if resizing the partition fails", func() {
+			partitioner.ResizeSinglePartitionStub = func(string) error { return errors.New("fake-growpart-err") }
+
+			_, _, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-growpart-err"))
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+		})
+
+		It("returns an error if growing the filesystem fails", func() {
+			formatter.GrowFilesystemError = errors.New("fake-grow-err")
+
+			_, _, err := platform.ResizePersistentDisk(diskSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-grow-err"))
+		})
+
+		Context("when UsePreformattedPersistentDisk set to true", func() {
+			BeforeEach(func() {
+				options.UsePreformattedPersistentDisk = true
+			})
+
+			It("returns an error", func() {
+				_, _, err := platform.ResizePersistentDisk(diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("preformatted"))
+				Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+			})
+		})
+	})
+
 	Describe("MountPersistentDisk", func() {
 		var (
 			diskSettings boshsettings.DiskSettings
@@ -3462,6 +3776,44 @@ sam:fakeanotheruser`)
 				Expect(err.Error()).To(ContainSubstring("fake-get-real-device-path-err"))
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				diskSettings.Encryption = boshdisk.Encryption{Enabled: true, KeyFile: "/fake-key-file"}
+			})
+
+			It("opens the container on the first partition and mounts it", func() {
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(encryptor.OpenDevices).To(Equal([]string{"fake-real-device-path1"}))
+				Expect(encryptor.OpenNames).To(Equal([]string{"bosh-crypt-fake-unique-id"}))
+
+				Expect(mounter.MountCallCount()).To(Equal(1))
+				partition, mntPt, _ := mounter.MountArgsForCall(0)
+				Expect(partition).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+				Expect(mntPt).To(Equal("/mnt/point"))
+			})
+
+			It("skips mounting if the container already is mounted", func() {
+				mounter.IsMountPointReturns("/dev/mapper/bosh-crypt-fake-unique-id", true, nil)
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(encryptor.OpenDevices).To(BeEmpty())
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the container cannot be opened", func() {
+				encryptor.OpenErr = errors.New("fake-open-err")
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("UnmountPersistentDisk", func() {
@@ -3663,18 +4015,75 @@ sam:fakeanotheruser`)
 				Expect(realPath).To(Equal(""))
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			var diskSettings boshsettings.DiskSettings
+
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				diskSettings = boshsettings.DiskSettings{
+					ID:         "fake-unique-id",
+					Encryption: boshdisk.Encryption{Enabled: true, Key: "fake-key"},
+				}
+			})
+
+			It("unmounts the opened container and closes it", func() {
+				mounter.UnmountReturns(true, nil)
+
+				didUnmount, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(didUnmount).To(BeTrue())
+				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+				Expect(encryptor.ClosedNames).To(Equal([]string{"bosh-crypt-fake-unique-id"}))
+			})
+
+			It("does not close the container if unmounting fails", func() {
+				mounter.UnmountReturns(false, errors.New("fake-unmount-err"))
+
+				_, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(encryptor.ClosedNames).To(BeEmpty())
+			})
+
+			It("returns an error if closing the container fails", func() {
+				encryptor.CloseErr = errors.New("fake-close-err")
+
+				_, err := platform.UnmountPersistentDisk(diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-close-err"))
+			})
+		})
 	})
 
 	Describe("MigratePersistentDisk", func() {
+		var (
+			cancelCh     chan struct{}
+			progress     []boshdisk.MigrationProgress
+			progressFunc boshdisk.MigrationProgressFunc
+		)
+
+		BeforeEach(func() {
+			cancelCh = make(chan struct{}, 1)
+			progress = nil
+			progressFunc = func(p boshdisk.MigrationProgress) { progress = append(progress, p) }
+
+			mounter.IsMountPointReturns("/dev/sdc1", true, nil)
+		})
+
 		It("migrate persistent disk", func() {
-			err := platform.MigratePersistentDisk("/from/path", "/to/path")
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
 			Expect(err).ToNot(HaveOccurred())
 
+			Expect(mounter.IsMountPointArgsForCall(0)).To(Equal("/to/path"))
+
 			Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
 			Expect(mounter.RemountAsReadonlyArgsForCall(0)).To(Equal("/from/path"))
 
-			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /from/path --xattrs -cf - .) | (tar -C /to/path --xattrs -xpf -)"}))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+			Expect(migrator.MigrateFromDirs).To(Equal([]string{"/from/path"}))
+			Expect(migrator.MigrateToDirs).To(Equal([]string{"/to/path"}))
+			Expect(migrator.FinishedDirs).To(Equal([]string{"/to/path"}))
+			Expect(mounter.RemountInPlaceCallCount()).To(Equal(0))
 
 			Expect(mounter.UnmountCallCount()).To(Equal(1))
 			Expect(mounter.UnmountArgsForCall(0)).To(Equal("/from/path"))
@@ -3686,6 +4095,91 @@ sam:fakeanotheruser`)
 			Expect(options).To(BeEmpty())
 		})
 
+		It("reports the progress of copying files", func() {
+			migrator.MigrateProgress = []boshdisk.MigrationProgress{
+				{BytesCopied: 1024, FilesCopied: 1},
+				{BytesCopied: 3072, FilesCopied: 2},
+			}
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(progress).To(Equal(migrator.MigrateProgress))
+		})
+
+		It("returns an error without touching the disks if the new disk is not mounted", func() {
+			mounter.IsMountPointReturns("", false, nil)
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("New persistent disk is not mounted on /to/path"))
+
+			Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(0))
+			Expect(migrator.MigrateFromDirs).To(BeEmpty())
+		})
+
+		It("returns an error if checking the mount point of the new disk fails", func() {
+			mounter.IsMountPointReturns("", false, errors.New("fake-is-mount-point-err"))
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-is-mount-point-err"))
+		})
+
+		It("keeps the old disk mounted and remounts it as read-write if copying files is cancelled", func() {
+			migrator.MigrateErr = boshdisk.ErrMigrationCancelled
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("migration cancelled"))
+
+			Expect(migrator.FinishedDirs).To(BeEmpty())
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+			Expect(mounter.RemountCallCount()).To(Equal(0))
+
+			Expect(mounter.RemountInPlaceCallCount()).To(Equal(1))
+			mountPoint, options := mounter.RemountInPlaceArgsForCall(0)
+			Expect(mountPoint).To(Equal("/from/path"))
+			Expect(options).To(Equal([]string{"rw"}))
+		})
+
+		It("remounts the old disk as read-write if copying files fails", func() {
+			migrator.MigrateErr = errors.New("fake-migrate-err")
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-migrate-err"))
+
+			Expect(mounter.RemountInPlaceCallCount()).To(Equal(1))
+			mountPoint, options := mounter.RemountInPlaceArgsForCall(0)
+			Expect(mountPoint).To(Equal("/from/path"))
+			Expect(options).To(Equal([]string{"rw"}))
+		})
+
+		It("returns the copy error if remounting the old disk as read-write fails", func() {
+			migrator.MigrateErr = errors.New("fake-migrate-err")
+			mounter.RemountInPlaceReturns(errors.New("fake-remount-err"))
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-migrate-err"))
+			Expect(err.Error()).ToNot(ContainSubstring("fake-remount-err"))
+		})
+
+		It("returns an error if removing the migration checkpoint fails", func() {
+			migrator.FinishErr = errors.New("fake-finish-err")
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-finish-err"))
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+
+			Expect(mounter.RemountInPlaceCallCount()).To(Equal(1))
+			mountPoint, options := mounter.RemountInPlaceArgsForCall(0)
+			Expect(mountPoint).To(Equal("/from/path"))
+			Expect(options).To(Equal([]string{"rw"}))
+		})
+
 		Context("when device path resolution type is iscsi", func() {
 			BeforeEach(func() {
 				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
@@ -3719,6 +4213,7 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					certManager,
 					monitRetryStrategy,
 					devicePathResolver,
+					cgroupManager,
 					state,
 					options,
 					logger,
@@ -3727,14 +4222,14 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					fakeAuditLogger,
 				)
 
-				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path")
+				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
 				Expect(mounter.RemountAsReadonlyArgsForCall(0)).To(Equal("/from/path"))
 
-				Expect(len(cmdRunner.RunCommands)).To(Equal(3))
-				Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /from/path --xattrs -cf - .) | (tar -C /to/path --xattrs -xpf -)"}))
+				Expect(len(cmdRunner.RunCommands)).To(Equal(2))
+				Expect(migrator.MigrateToDirs).To(Equal([]string{"/to/path"}))
 
 				Expect(mounter.UnmountCallCount()).To(Equal(1))
 				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/from/path"))
@@ -3745,10 +4240,157 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 				Expect(toPath).To(Equal("/from/path"))
 				Expect(options).To(BeEmpty())
 
-				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"multipath", "-ll"}))
-				Expect(cmdRunner.RunCommands[2]).To(Equal([]string{"multipath", "-f", "from-device-path"}))
+				Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"multipath", "-ll"}))
+				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"multipath", "-f", "from-device-path"}))
 			})
 		})
+
+		Context("when both disks use btrfs", func() {
+			var subvolumeList string
+
+			BeforeEach(func() {
+				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/sdb1", MountPoint: "/from/path"},
+					{PartitionPath: "/dev/sdc1", MountPoint: "/to/path"},
+				}
+				formatter.GetFileSystemType["/dev/sdb1"] = boshdisk.FileSystemBtrfs
+				formatter.GetFileSystemType["/dev/sdc1"] = boshdisk.FileSystemBtrfs
+
+				subvolumeList = "ID 256 gen 9 top level 5 path data\nID 258 gen 12 top level 256 path data/snapshots/daily\n"
+			})
+
+			JustBeforeEach(func() {
+				cmdRunner.AddCmdResult("btrfs subvolume list /from/path", fakesys.FakeCmdResult{Stdout: subvolumeList})
+			})
+
+			It("recreates the subvolumes on the new disk before copying files", func() {
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{
+					{"btrfs", "subvolume", "list", "/from/path"},
+					{"btrfs", "subvolume", "create", "/to/path/data"},
+					{"btrfs", "subvolume", "create", "/to/path/data/snapshots/daily"},
+				}))
+				Expect(fs.FileExists("/to/path/data/snapshots")).To(BeTrue())
+				Expect(migrator.MigrateToDirs).To(Equal([]string{"/to/path"}))
+			})
+
+			Context("when nested subvolumes are listed before their parents", func() {
+				BeforeEach(func() {
+					// e.g. a subvolume moved into one created later
+					subvolumeList = "ID 258 gen 12 top level 259 path data/snapshots\nID 259 gen 14 top level 5 path data\n"
+				})
+
+				It("creates parent subvolumes first", func() {
+					err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(cmdRunner.RunCommands).To(Equal([][]string{
+						{"btrfs", "subvolume", "list", "/from/path"},
+						{"btrfs", "subvolume", "create", "/to/path/data"},
+						{"btrfs", "subvolume", "create", "/to/path/data/snapshots"},
+					}))
+				})
+			})
+
+			It("keeps subvolumes created by an interrupted migration", func() {
+				err := fs.MkdirAll("/to/path/data", 0755)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{
+					{"btrfs", "subvolume", "list", "/from/path"},
+					{"btrfs", "subvolume", "create", "/to/path/data/snapshots/daily"},
+				}))
+			})
+
+			It("only copies files if the new disk does not use btrfs", func() {
+				formatter.GetFileSystemType["/dev/sdc1"] = boshdisk.FileSystemExt4
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+				Expect(migrator.MigrateToDirs).To(Equal([]string{"/to/path"}))
+			})
+
+			It("returns an error if creating a subvolume fails", func() {
+				cmdRunner.AddCmdResult("btrfs subvolume create /to/path/data", fakesys.FakeCmdResult{Error: errors.New("fake-btrfs-err")})
+
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-btrfs-err"))
+				Expect(mounter.UnmountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the old disk is encrypted", func() {
+			BeforeEach(func() {
+				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/mapper/bosh-crypt-old-disk", MountPoint: "/from/path"},
+					{PartitionPath: "/dev/mapper/bosh-crypt-new-disk", MountPoint: "/to/path"},
+				}
+			})
+
+			It("closes the old container after unmounting it", func() {
+				err := platform.MigratePersistentDisk("/from/path", "/to/path", cancelCh, progressFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/from/path"))
+				Expect(encryptor.ClosedNames).To(Equal([]string{"bosh-crypt-old-disk"}))
+
+				fromPath, toPath, _ := mounter.RemountArgsForCall(0)
+				Expect(fromPath).To(Equal("/to/path"))
+				Expect(toPath).To(Equal("/from/path"))
+			})
+		})
+	})
+
+	Describe("FreezeFilesystem", func() {
+		It("freezes the filesystem with fsfreeze", func() {
+			err := platform.FreezeFilesystem("/fake/store")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--freeze", "/fake/store"}}))
+		})
+
+		It("returns an error if fsfreeze fails", func() {
+			cmdRunner.AddCmdResult("fsfreeze --freeze /fake/store", fakesys.FakeCmdResult{Error: errors.New("fake-fsfreeze-err")})
+
+			err := platform.FreezeFilesystem("/fake/store")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-fsfreeze-err"))
+		})
+	})
+
+	Describe("ThawFilesystem", func() {
+		It("thaws the filesystem with fsfreeze", func() {
+			err := platform.ThawFilesystem("/fake/store")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fsfreeze", "--unfreeze", "/fake/store"}}))
+		})
+
+		It("succeeds if the filesystem is not frozen", func() {
+			cmdRunner.AddCmdResult("fsfreeze --unfreeze /fake/store", fakesys.FakeCmdResult{
+				Stderr: "fsfreeze: /fake/store: unfreeze failed: Invalid argument",
+				Error:  errors.New("fake-fsfreeze-err"),
+			})
+
+			err := platform.ThawFilesystem("/fake/store")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if fsfreeze fails otherwise", func() {
+			cmdRunner.AddCmdResult("fsfreeze --unfreeze /fake/store", fakesys.FakeCmdResult{Error: errors.New("fake-fsfreeze-err")})
+
+			err := platform.ThawFilesystem("/fake/store")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-fsfreeze-err"))
+		})
 	})
 
 	Describe("IsPersistentDiskMounted", func() {
@@ -3922,6 +4564,22 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 				Expect(isMounted).To(BeFalse())
 			})
 		})
+
+		Context("when persistent disk encryption is enabled", func() {
+			It("checks whether the opened container is mounted", func() {
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				mounter.IsMountedReturns(true, nil)
+
+				isMounted, err := platform.IsPersistentDiskMounted(boshsettings.DiskSettings{
+					ID:         "fake-unique-id",
+					Path:       "fake-device-path",
+					Encryption: boshdisk.Encryption{Enabled: true, Key: "fake-key"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(isMounted).To(BeTrue())
+				Expect(mounter.IsMountedArgsForCall(0)).To(Equal("/dev/mapper/bosh-crypt-fake-unique-id"))
+			})
+		})
 	})
 
 	Describe("IsPersistentDiskMountable", func() {
@@ -4016,6 +4674,17 @@ unit: sectors
 			Expect(monitRetryStrategy.TryCalled).To(BeTrue())
 		})
 
+		It("protects monit once it is running", func() {
+			err := fs.WriteFileString("/etc/service/monit/supervise/pid", "1234\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = platform.StartMonit()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cgroupManager.Cgroups["bosh-agent"].AddedPids).To(Equal([]int{1234}))
+			Expect(fs.ReadFileString("/proc/1234/oom_score_adj")).To(Equal("-900"))
+		})
+
 		It("returns error if retrying to start monit fails", func() {
 			monitRetryStrategy.TryErr = errors.New("fake-retry-monit-error")
 
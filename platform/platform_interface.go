@@ -6,6 +6,7 @@ import (
 	"log"
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -74,9 +75,12 @@ type Platform interface {
 
 	// Disk management
 	AdjustPersistentDiskPartitioning(diskSettings boshsettings.DiskSettings, mountPoint string) error
+	ResizePersistentDisk(diskSettings boshsettings.DiskSettings) (oldSizeInBytes, newSizeInBytes uint64, err error)
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error)
-	MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error)
+	MigratePersistentDisk(fromMountPoint, toMountPoint string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) (err error)
+	FreezeFilesystem(mountPoint string) (err error)
+	ThawFilesystem(mountPoint string) (err error)
 	GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error)
 	IsMountPoint(path string) (partitionPath string, result bool, err error)
 	IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (result bool, err error)
@@ -7,6 +7,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/platform/cert"
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/settings"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -85,6 +86,17 @@ type FakePlatform struct {
 	deleteEphemeralUsersMatchingReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FreezeFilesystemStub        func(string) error
+	freezeFilesystemMutex       sync.RWMutex
+	freezeFilesystemArgsForCall []struct {
+		arg1 string
+	}
+	freezeFilesystemReturns struct {
+		result1 error
+	}
+	freezeFilesystemReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetAgentSettingsPathStub        func(bool) string
 	getAgentSettingsPathMutex       sync.RWMutex
 	getAgentSettingsPathArgsForCall []struct {
@@ -339,11 +351,13 @@ type FakePlatform struct {
 		result1 bool
 		result2 error
 	}
-	MigratePersistentDiskStub        func(string, string) error
+	MigratePersistentDiskStub        func(string, string, <-chan struct{}, disk.MigrationProgressFunc) error
 	migratePersistentDiskMutex       sync.RWMutex
 	migratePersistentDiskArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 <-chan struct{}
+		arg4 disk.MigrationProgressFunc
 	}
 	migratePersistentDiskReturns struct {
 		result1 error
@@ -395,6 +409,21 @@ type FakePlatform struct {
 	removeStaticLibrariesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ResizePersistentDiskStub        func(settings.DiskSettings) (uint64, uint64, error)
+	resizePersistentDiskMutex       sync.RWMutex
+	resizePersistentDiskArgsForCall []struct {
+		arg1 settings.DiskSettings
+	}
+	resizePersistentDiskReturns struct {
+		result1 uint64
+		result2 uint64
+		result3 error
+	}
+	resizePersistentDiskReturnsOnCall map[int]struct {
+		result1 uint64
+		result2 uint64
+		result3 error
+	}
 	SaveDNSRecordsStub        func(settings.DNSRecords, string) error
 	saveDNSRecordsMutex       sync.RWMutex
 	saveDNSRecordsArgsForCall []struct {
@@ -677,6 +706,17 @@ type FakePlatform struct {
 	startMonitReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ThawFilesystemStub        func(string) error
+	thawFilesystemMutex       sync.RWMutex
+	thawFilesystemArgsForCall []struct {
+		arg1 string
+	}
+	thawFilesystemReturns struct {
+		result1 error
+	}
+	thawFilesystemReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnmountPersistentDiskStub        func(settings.DiskSettings) (bool, error)
 	unmountPersistentDiskMutex       sync.RWMutex
 	unmountPersistentDiskArgsForCall []struct {
@@ -1069,6 +1109,67 @@ func (fake *FakePlatform) DeleteEphemeralUsersMatchingReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakePlatform) FreezeFilesystem(arg1 string) error {
+	fake.freezeFilesystemMutex.Lock()
+	ret, specificReturn := fake.freezeFilesystemReturnsOnCall[len(fake.freezeFilesystemArgsForCall)]
+	fake.freezeFilesystemArgsForCall = append(fake.freezeFilesystemArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.FreezeFilesystemStub
+	fakeReturns := fake.freezeFilesystemReturns
+	fake.recordInvocation("FreezeFilesystem", []interface{}{arg1})
+	fake.freezeFilesystemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) FreezeFilesystemCallCount() int {
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
+	return len(fake.freezeFilesystemArgsForCall)
+}
+
+func (fake *FakePlatform) FreezeFilesystemCalls(stub func(string) error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = stub
+}
+
+func (fake *FakePlatform) FreezeFilesystemArgsForCall(i int) string {
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
+	argsForCall := fake.freezeFilesystemArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) FreezeFilesystemReturns(result1 error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = nil
+	fake.freezeFilesystemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) FreezeFilesystemReturnsOnCall(i int, result1 error) {
+	fake.freezeFilesystemMutex.Lock()
+	defer fake.freezeFilesystemMutex.Unlock()
+	fake.FreezeFilesystemStub = nil
+	if fake.freezeFilesystemReturnsOnCall == nil {
+		fake.freezeFilesystemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.freezeFilesystemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) GetAgentSettingsPath(arg1 bool) string {
 	fake.getAgentSettingsPathMutex.Lock()
 	ret, specificReturn := fake.getAgentSettingsPathReturnsOnCall[len(fake.getAgentSettingsPathArgsForCall)]
@@ -2349,19 +2450,21 @@ func (fake *FakePlatform) IsPersistentDiskMountedReturnsOnCall(i int, result1 bo
 	}{result1, result2}
 }
 
-func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string) error {
+func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string, arg3 <-chan struct{}, arg4 disk.MigrationProgressFunc) error {
 	fake.migratePersistentDiskMutex.Lock()
 	ret, specificReturn := fake.migratePersistentDiskReturnsOnCall[len(fake.migratePersistentDiskArgsForCall)]
 	fake.migratePersistentDiskArgsForCall = append(fake.migratePersistentDiskArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
+		arg3 <-chan struct{}
+		arg4 disk.MigrationProgressFunc
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.MigratePersistentDiskStub
 	fakeReturns := fake.migratePersistentDiskReturns
-	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2})
+	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2, arg3, arg4})
 	fake.migratePersistentDiskMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -2375,17 +2478,17 @@ func (fake *FakePlatform) MigratePersistentDiskCallCount() int {
 	return len(fake.migratePersistentDiskArgsForCall)
 }
 
-func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string) error) {
+func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string, <-chan struct{}, disk.MigrationProgressFunc) error) {
 	fake.migratePersistentDiskMutex.Lock()
 	defer fake.migratePersistentDiskMutex.Unlock()
 	fake.MigratePersistentDiskStub = stub
 }
 
-func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string) {
+func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string, <-chan struct{}, disk.MigrationProgressFunc) {
 	fake.migratePersistentDiskMutex.RLock()
 	defer fake.migratePersistentDiskMutex.RUnlock()
 	argsForCall := fake.migratePersistentDiskArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakePlatform) MigratePersistentDiskReturns(result1 error) {
@@ -2648,6 +2751,73 @@ func (fake *FakePlatform) RemoveStaticLibrariesReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakePlatform) ResizePersistentDisk(arg1 settings.DiskSettings) (uint64, uint64, error) {
+	fake.resizePersistentDiskMutex.Lock()
+	ret, specificReturn := fake.resizePersistentDiskReturnsOnCall[len(fake.resizePersistentDiskArgsForCall)]
+	fake.resizePersistentDiskArgsForCall = append(fake.resizePersistentDiskArgsForCall, struct {
+		arg1 settings.DiskSettings
+	}{arg1})
+	stub := fake.ResizePersistentDiskStub
+	fakeReturns := fake.resizePersistentDiskReturns
+	fake.recordInvocation("ResizePersistentDisk", []interface{}{arg1})
+	fake.resizePersistentDiskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakePlatform) ResizePersistentDiskCallCount() int {
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
+	return len(fake.resizePersistentDiskArgsForCall)
+}
+
+func (fake *FakePlatform) ResizePersistentDiskCalls(stub func(settings.DiskSettings) (uint64, uint64, error)) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = stub
+}
+
+func (fake *FakePlatform) ResizePersistentDiskArgsForCall(i int) settings.DiskSettings {
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
+	argsForCall := fake.resizePersistentDiskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) ResizePersistentDiskReturns(result1 uint64, result2 uint64, result3 error) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = nil
+	fake.resizePersistentDiskReturns = struct {
+		result1 uint64
+		result2 uint64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakePlatform) ResizePersistentDiskReturnsOnCall(i int, result1 uint64, result2 uint64, result3 error) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = nil
+	if fake.resizePersistentDiskReturnsOnCall == nil {
+		fake.resizePersistentDiskReturnsOnCall = make(map[int]struct {
+			result1 uint64
+			result2 uint64
+			result3 error
+		})
+	}
+	fake.resizePersistentDiskReturnsOnCall[i] = struct {
+		result1 uint64
+		result2 uint64
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakePlatform) SaveDNSRecords(arg1 settings.DNSRecords, arg2 string) error {
 	fake.saveDNSRecordsMutex.Lock()
 	ret, specificReturn := fake.saveDNSRecordsReturnsOnCall[len(fake.saveDNSRecordsArgsForCall)]
@@ -4154,6 +4324,67 @@ func (fake *FakePlatform) StartMonitReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakePlatform) ThawFilesystem(arg1 string) error {
+	fake.thawFilesystemMutex.Lock()
+	ret, specificReturn := fake.thawFilesystemReturnsOnCall[len(fake.thawFilesystemArgsForCall)]
+	fake.thawFilesystemArgsForCall = append(fake.thawFilesystemArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ThawFilesystemStub
+	fakeReturns := fake.thawFilesystemReturns
+	fake.recordInvocation("ThawFilesystem", []interface{}{arg1})
+	fake.thawFilesystemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) ThawFilesystemCallCount() int {
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
+	return len(fake.thawFilesystemArgsForCall)
+}
+
+func (fake *FakePlatform) ThawFilesystemCalls(stub func(string) error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = stub
+}
+
+func (fake *FakePlatform) ThawFilesystemArgsForCall(i int) string {
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
+	argsForCall := fake.thawFilesystemArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePlatform) ThawFilesystemReturns(result1 error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = nil
+	fake.thawFilesystemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePlatform) ThawFilesystemReturnsOnCall(i int, result1 error) {
+	fake.thawFilesystemMutex.Lock()
+	defer fake.thawFilesystemMutex.Unlock()
+	fake.ThawFilesystemStub = nil
+	if fake.thawFilesystemReturnsOnCall == nil {
+		fake.thawFilesystemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.thawFilesystemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePlatform) UnmountPersistentDisk(arg1 settings.DiskSettings) (bool, error) {
 	fake.unmountPersistentDiskMutex.Lock()
 	ret, specificReturn := fake.unmountPersistentDiskReturnsOnCall[len(fake.unmountPersistentDiskArgsForCall)]
@@ -4233,6 +4464,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.deleteARPEntryWithIPMutex.RUnlock()
 	fake.deleteEphemeralUsersMatchingMutex.RLock()
 	defer fake.deleteEphemeralUsersMatchingMutex.RUnlock()
+	fake.freezeFilesystemMutex.RLock()
+	defer fake.freezeFilesystemMutex.RUnlock()
 	fake.getAgentSettingsPathMutex.RLock()
 	defer fake.getAgentSettingsPathMutex.RUnlock()
 	fake.getAuditLoggerMutex.RLock()
@@ -4287,6 +4520,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.removeDevToolsMutex.RUnlock()
 	fake.removeStaticLibrariesMutex.RLock()
 	defer fake.removeStaticLibrariesMutex.RUnlock()
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
 	fake.saveDNSRecordsMutex.RLock()
 	defer fake.saveDNSRecordsMutex.RUnlock()
 	fake.setTimeWithNtpServersMutex.RLock()
@@ -4339,6 +4574,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.shutdownMutex.RUnlock()
 	fake.startMonitMutex.RLock()
 	defer fake.startMonitMutex.RUnlock()
+	fake.thawFilesystemMutex.RLock()
+	defer fake.thawFilesystemMutex.RUnlock()
 	fake.unmountPersistentDiskMutex.RLock()
 	defer fake.unmountPersistentDiskMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
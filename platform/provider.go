@@ -9,6 +9,7 @@ import (
 
 	boshcdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
@@ -35,6 +36,7 @@ const (
 
 const (
 	SigarStatsCollectionInterval = 10 * time.Second
+	DiskHealthCheckInterval      = 5 * time.Minute
 )
 
 type Provider interface {
@@ -72,7 +74,13 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Kick of stats collection as soon as possible
 	statsCollector.StartCollecting(SigarStatsCollectionInterval, nil)
 
-	vitalsService := boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter())
+	// Only Linux platforms check disk health, which keeps doing so in the background
+	linuxVitalsService := func() boshvitals.Service {
+		healthChecker := boshdisk.NewCachingHealthChecker(boshdisk.NewLinuxHealthChecker(runner, fs, logger), DiskHealthCheckInterval, clock, logger)
+		healthChecker.StartChecking()
+
+		return boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter(), healthChecker)
+	}
 
 	ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -126,6 +134,9 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 		iscsiAdm := boshiscsi.NewConcreteOpenIscsiAdmin(fs, runner, logger)
 		iscsiPathResolver := devicepathresolver.NewIscsiDevicePathResolver(50000*time.Millisecond, runner, iscsiAdm, fs, dirProvider, logger)
 		devicePathResolver = devicepathresolver.NewMultipathDevicePathResolver(identityPathResolver, iscsiPathResolver, logger)
+	case "nvme":
+		mappedDevicePathResolver := devicepathresolver.NewMappedDevicePathResolver(30000*time.Millisecond, fs)
+		devicePathResolver = devicepathresolver.NewNVMeDevicePathResolver(50000*time.Millisecond, mappedDevicePathResolver, fs, logger)
 
 	default:
 		devicePathResolver = devicepathresolver.NewIdentityDevicePathResolver()
@@ -133,6 +144,8 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 
 	uuidGenerator := boshuuid.NewGenerator()
 
+	cgroupManager := boshcgroup.NewManager(fs, boshcgroup.DefaultMountPoint)
+
 	var centos = func() Platform {
 		return NewLinuxPlatform(
 			fs,
@@ -141,13 +154,14 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			compressor,
 			copier,
 			dirProvider,
-			vitalsService,
+			linuxVitalsService(),
 			linuxCdutil,
 			linuxDiskManager,
 			centosNetManager,
 			centosCertManager,
 			monitRetryStrategy,
 			devicePathResolver,
+			cgroupManager,
 			bootstrapState,
 			options.Linux,
 			logger,
@@ -165,13 +179,14 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			compressor,
 			copier,
 			dirProvider,
-			vitalsService,
+			linuxVitalsService(),
 			linuxCdutil,
 			linuxDiskManager,
 			ubuntuNetManager,
 			ubuntuCertManager,
 			monitRetryStrategy,
 			devicePathResolver,
+			cgroupManager,
 			bootstrapState,
 			options.Linux,
 			logger,
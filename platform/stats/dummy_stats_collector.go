@@ -42,3 +42,15 @@ func (p dummyStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
 	stats.Secs = 5
 	return
 }
+
+func (p dummyStatsCollector) GetNetworkStats() (stats []NetworkStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetDiskIOStats() (stats []DiskIOStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetPressureStats() (stats []PressureStats, err error) {
+	return
+}
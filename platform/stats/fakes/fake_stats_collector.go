@@ -20,6 +20,15 @@ type FakeCollector struct {
 	DiskStats map[string]boshstats.DiskStats
 
 	UptimeStats boshstats.UptimeStats
+
+	NetworkStats    []boshstats.NetworkStats
+	NetworkStatsErr error
+
+	DiskIOStats    []boshstats.DiskIOStats
+	DiskIOStatsErr error
+
+	PressureStats    []boshstats.PressureStats
+	PressureStatsErr error
 }
 
 func (c *FakeCollector) StartCollecting(collectionInterval time.Duration, latestGotUpdated chan struct{}) {
@@ -57,3 +66,15 @@ func (c *FakeCollector) GetUptimeStats() (stats boshstats.UptimeStats, err error
 	stats = c.UptimeStats
 	return
 }
+
+func (c *FakeCollector) GetNetworkStats() ([]boshstats.NetworkStats, error) {
+	return c.NetworkStats, c.NetworkStatsErr
+}
+
+func (c *FakeCollector) GetDiskIOStats() ([]boshstats.DiskIOStats, error) {
+	return c.DiskIOStats, c.DiskIOStatsErr
+}
+
+func (c *FakeCollector) GetPressureStats() ([]boshstats.PressureStats, error) {
+	return c.PressureStats, c.PressureStatsErr
+}
@@ -30,6 +30,42 @@ type UptimeStats struct {
 	Secs uint64
 }
 
+type NetworkStats struct {
+	Interface string
+
+	RxBytes   uint64
+	TxBytes   uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+type DiskIOStats struct {
+	Device string
+
+	ReadBytesPerSecond  float64
+	WriteBytesPerSecond float64
+	ReadsPerSecond      float64
+	WritesPerSecond     float64
+}
+
+// PressureAverages are the percentages of time some or all tasks were
+// stalled on a resource, averaged over 10s, 60s and 300s
+type PressureAverages struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+}
+
+type PressureStats struct {
+	// Resource is one of cpu, memory or io
+	Resource string
+
+	Some PressureAverages
+	Full PressureAverages
+}
+
 type Collector interface {
 	StartCollecting(time.Duration, chan struct{})
 
@@ -41,6 +77,13 @@ type Collector interface {
 	GetDiskStats(mountedPath string) (stats DiskStats, err error)
 
 	GetUptimeStats() (stats UptimeStats, err error)
+
+	GetNetworkStats() (stats []NetworkStats, err error)
+	// Rates are averaged over the collection interval passed to StartCollecting
+	GetDiskIOStats() (stats []DiskIOStats, err error)
+
+	// GetPressureStats reports pressure stall information (PSI)
+	GetPressureStats() (stats []PressureStats, err error)
 }
 
 func (cpuStats CPUStats) UserPercent() Percentage {
@@ -2,6 +2,7 @@ package vitals
 
 import (
 	"fmt"
+	"path/filepath"
 
 	sigar "github.com/cloudfoundry/gosigar"
 
@@ -21,17 +22,20 @@ type concreteService struct {
 	statsCollector boshstats.Collector
 	dirProvider    boshdirs.Provider
 	diskMounter    boshdisk.Mounter
+	healthChecker  boshdisk.HealthChecker
 }
 
 func NewService(
 	statsCollector boshstats.Collector,
 	dirProvider boshdirs.Provider,
 	diskMounter boshdisk.Mounter,
+	healthChecker boshdisk.HealthChecker,
 ) Service {
 	return concreteService{
 		statsCollector: statsCollector,
 		dirProvider:    dirProvider,
 		diskMounter:    diskMounter,
+		healthChecker:  healthChecker,
 	}
 }
 
@@ -43,6 +47,9 @@ func (s concreteService) Get() (Vitals, error) {
 		swapStats   boshstats.Usage
 		uptimeStats boshstats.UptimeStats
 		diskStats   DiskVitals
+		diskIOStats DiskIOVitals
+		netStats    NetworkVitals
+		pressure    PressureVitals
 	)
 
 	vitals := Vitals{}
@@ -67,7 +74,17 @@ func (s concreteService) Get() (Vitals, error) {
 		return vitals, bosherr.WrapError(err, "Getting Swap Stats")
 	}
 
-	diskStats, err = s.getDiskStats()
+	diskIOStats, err = s.getDiskIOStats()
+	if err != nil {
+		return vitals, bosherr.WrapError(err, "Getting Disk IO Stats")
+	}
+
+	netStats, err = s.getNetworkStats()
+	if err != nil {
+		return vitals, bosherr.WrapError(err, "Getting Network Stats")
+	}
+
+	diskStats, err = s.getDiskStats(diskIOStats)
 	if err != nil {
 		return vitals, bosherr.WrapError(err, "Getting Disk Stats")
 	}
@@ -77,6 +94,11 @@ func (s concreteService) Get() (Vitals, error) {
 		return vitals, bosherr.WrapError(err, "Getting Uptime Stats")
 	}
 
+	pressure, err = s.getPressureStats()
+	if err != nil {
+		return vitals, bosherr.WrapError(err, "Getting Pressure Stats")
+	}
+
 	return Vitals{
 		Load: createLoadVitals(loadStats),
 		CPU: CPUVitals{
@@ -84,14 +106,95 @@ func (s concreteService) Get() (Vitals, error) {
 			Sys:  cpuStats.SysPercent().FormatFractionOf100(1),
 			Wait: cpuStats.WaitPercent().FormatFractionOf100(1),
 		},
-		Mem:    createMemVitals(memStats),
-		Swap:   createMemVitals(swapStats),
-		Disk:   diskStats,
-		Uptime: UptimeVitals{Secs: uptimeStats.Secs},
+		Mem:      createMemVitals(memStats),
+		Swap:     createMemVitals(swapStats),
+		Disk:     diskStats,
+		DiskIO:   diskIOStats,
+		Network:  netStats,
+		Uptime:   UptimeVitals{Secs: uptimeStats.Secs},
+		Pressure: pressure,
 	}, nil
 }
 
-func (s concreteService) getDiskStats() (DiskVitals, error) {
+func (s concreteService) getDiskIOStats() (DiskIOVitals, error) {
+	stats, err := s.statsCollector.GetDiskIOStats()
+	if err == sigar.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	diskIOStats := make(DiskIOVitals, len(stats))
+	for _, stat := range stats {
+		diskIOStats[stat.Device] = SpecificDiskIOVitals{
+			ReadBytesPerSecond:  stat.ReadBytesPerSecond,
+			WriteBytesPerSecond: stat.WriteBytesPerSecond,
+			ReadIOPS:            stat.ReadsPerSecond,
+			WriteIOPS:           stat.WritesPerSecond,
+		}
+	}
+
+	return diskIOStats, nil
+}
+
+func (s concreteService) getNetworkStats() (NetworkVitals, error) {
+	stats, err := s.statsCollector.GetNetworkStats()
+	if err == sigar.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	netStats := make(NetworkVitals, len(stats))
+	for _, stat := range stats {
+		netStats[stat.Interface] = SpecificNetworkVitals{
+			RxBytes:   stat.RxBytes,
+			TxBytes:   stat.TxBytes,
+			RxErrors:  stat.RxErrors,
+			TxErrors:  stat.TxErrors,
+			RxDropped: stat.RxDropped,
+			TxDropped: stat.TxDropped,
+		}
+	}
+
+	return netStats, nil
+}
+
+func (s concreteService) getPressureStats() (PressureVitals, error) {
+	stats, err := s.statsCollector.GetPressureStats()
+	if err == sigar.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	pressure := make(PressureVitals, len(stats))
+	for _, stat := range stats {
+		pressure[stat.Resource] = SpecificPressureVitals{
+			Some: createPressureAverageVitals(stat.Some),
+			Full: createPressureAverageVitals(stat.Full),
+		}
+	}
+
+	return pressure, nil
+}
+
+func (s concreteService) getDiskStats(diskIOStats DiskIOVitals) (DiskVitals, error) {
 	disks := map[string]string{
 		"/":                      "system",
 		s.dirProvider.DataDir():  "ephemeral",
@@ -100,7 +203,7 @@ func (s concreteService) getDiskStats() (DiskVitals, error) {
 	diskStats := make(DiskVitals, len(disks))
 
 	for path, name := range disks {
-		diskStats, err := s.addDiskStats(diskStats, path, name)
+		diskStats, err := s.addDiskStats(diskStats, diskIOStats, path, name)
 		if err != nil {
 			return diskStats, err
 		}
@@ -109,10 +212,13 @@ func (s concreteService) getDiskStats() (DiskVitals, error) {
 	return diskStats, nil
 }
 
-func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (DiskVitals, error) {
+func (s concreteService) addDiskStats(diskStats DiskVitals, diskIOStats DiskIOVitals, path, name string) (DiskVitals, error) {
+	var partitionPath string
+
 	if s.diskMounter != nil {
 		var isMountPoint bool
-		_, isMountPoint, err := s.diskMounter.IsMountPoint(path)
+		var err error
+		partitionPath, isMountPoint, err = s.diskMounter.IsMountPoint(path)
 		if err != nil {
 			return diskStats, bosherr.WrapError(err, fmt.Sprintf("Verifying if '%s' is a mount point", path))
 		}
@@ -129,10 +235,29 @@ func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (
 		return diskStats, nil
 	}
 
-	diskStats[name] = SpecificDiskVitals{
+	specificDiskStats := SpecificDiskVitals{
 		Percent:      stat.DiskUsage.Percent().FormatFractionOf100(0),
 		InodePercent: stat.InodeUsage.Percent().FormatFractionOf100(0),
 	}
+
+	if ioStats, found := diskIOStats[filepath.Base(partitionPath)]; found && partitionPath != "" {
+		specificDiskStats.IO = &ioStats
+	}
+
+	// A disk whose health cannot be checked still reports its usage
+	if partitionPath != "" {
+		health, err := s.healthChecker.CheckHealth(partitionPath)
+		if err == nil {
+			specificDiskStats.Health = &SpecificDiskHealthVitals{
+				Devices:     health.Devices,
+				Status:      string(health.Status),
+				MediaErrors: health.MediaErrors,
+				IOErrors:    health.IOErrors,
+			}
+		}
+	}
+
+	diskStats[name] = specificDiskStats
 	return diskStats, nil
 }
 
@@ -142,3 +267,11 @@ func createMemVitals(memUsage boshstats.Usage) MemoryVitals {
 		Kb:      fmt.Sprintf("%d", memUsage.Used/1024),
 	}
 }
+
+func createPressureAverageVitals(averages boshstats.PressureAverages) PressureAverageVitals {
+	return PressureAverageVitals{
+		Avg10:  averages.Avg10,
+		Avg60:  averages.Avg60,
+		Avg300: averages.Avg300,
+	}
+}
@@ -1,6 +1,7 @@
 package vitals_test
 
 import (
+	"errors"
 	"path/filepath"
 	"runtime"
 	"time"
@@ -8,12 +9,15 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	. "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	sigar "github.com/cloudfoundry/gosigar"
 )
 
 const Windows = runtime.GOOS == "windows"
@@ -23,6 +27,7 @@ var _ = Describe("Vitals service", func() {
 		dirProvider    boshdirs.Provider
 		statsCollector *fakestats.FakeCollector
 		mounter        *diskfakes.FakeMounter
+		healthChecker  *fakedisk.FakeHealthChecker
 		service        Service
 	)
 
@@ -70,7 +75,10 @@ var _ = Describe("Vitals service", func() {
 		mounter = &diskfakes.FakeMounter{}
 		mounter.IsMountPointReturns("/dev/fake-partition-device", true, nil)
 
-		service = NewService(statsCollector, dirProvider, mounter)
+		healthChecker = fakedisk.NewFakeHealthChecker()
+		healthChecker.CheckHealthErr = errors.New("fake-health-error")
+
+		service = NewService(statsCollector, dirProvider, mounter, healthChecker)
 		statsCollector.StartCollecting(1*time.Millisecond, nil)
 	})
 
@@ -168,4 +176,138 @@ var _ = Describe("Vitals service", func() {
 			boshassert.LacksJSONKey(GinkgoT(), vitals.Disk, "persistent")
 		})
 	})
+
+	Context("when network and disk IO stats are available", func() {
+		BeforeEach(func() {
+			statsCollector.NetworkStats = []boshstats.NetworkStats{
+				{Interface: "eth0", RxBytes: 1000, TxBytes: 2000, RxErrors: 1, TxErrors: 3, RxDropped: 2, TxDropped: 4},
+			}
+			statsCollector.DiskIOStats = []boshstats.DiskIOStats{
+				{Device: "fake-partition-device", ReadBytesPerSecond: 512, WriteBytesPerSecond: 1024, ReadsPerSecond: 1, WritesPerSecond: 2},
+				{Device: "sdb", ReadBytesPerSecond: 4096, ReadsPerSecond: 4},
+			}
+		})
+
+		It("returns typed network and disk IO vitals", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Network).To(Equal(NetworkVitals{
+				"eth0": {RxBytes: 1000, TxBytes: 2000, RxErrors: 1, TxErrors: 3, RxDropped: 2, TxDropped: 4},
+			}))
+			Expect(vitals.DiskIO).To(Equal(DiskIOVitals{
+				"fake-partition-device": {ReadBytesPerSecond: 512, WriteBytesPerSecond: 1024, ReadIOPS: 1, WriteIOPS: 2},
+				"sdb":                   {ReadBytesPerSecond: 4096, ReadIOPS: 4},
+			}))
+		})
+
+		It("attaches the IO of the mounted partition to each disk", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Disk["persistent"].IO).To(Equal(&SpecificDiskIOVitals{
+				ReadBytesPerSecond:  512,
+				WriteBytesPerSecond: 1024,
+				ReadIOPS:            1,
+				WriteIOPS:           2,
+			}))
+		})
+
+		It("returns an error when network stats cannot be collected", func() {
+			statsCollector.NetworkStatsErr = errors.New("fake-network-error")
+
+			_, err := service.Get()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-network-error"))
+		})
+
+		It("ignores network stats that are not implemented on this platform", func() {
+			statsCollector.NetworkStatsErr = sigar.ErrNotImplemented
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Network).To(BeNil())
+		})
+	})
+
+	Context("when disk health can be checked", func() {
+		BeforeEach(func() {
+			healthChecker.CheckHealthErr = nil
+			healthChecker.CheckHealthHealth["/dev/fake-partition-device"] = boshdisk.Health{
+				Devices:     []string{"sdb"},
+				Status:      boshdisk.HealthStatusFailing,
+				MediaErrors: 3,
+				IOErrors:    7,
+			}
+		})
+
+		It("attaches the health of the devices backing each disk", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(healthChecker.CheckHealthPartitionPaths).To(ConsistOf(
+				"/dev/fake-partition-device",
+				"/dev/fake-partition-device",
+				"/dev/fake-partition-device",
+			))
+			Expect(vitals.Disk["persistent"].Health).To(Equal(&SpecificDiskHealthVitals{
+				Devices:     []string{"sdb"},
+				Status:      "failing",
+				MediaErrors: 3,
+				IOErrors:    7,
+			}))
+		})
+	})
+
+	Context("when disk health cannot be checked", func() {
+		It("still returns disk usage without health", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Disk["persistent"].Percent).To(Equal("100"))
+			Expect(vitals.Disk["persistent"].Health).To(BeNil())
+		})
+	})
+
+	Context("when pressure stats are available", func() {
+		BeforeEach(func() {
+			statsCollector.PressureStats = []boshstats.PressureStats{
+				{Resource: "cpu", Some: boshstats.PressureAverages{Avg10: 1.5, Avg60: 0.75, Avg300: 0.2}},
+				{
+					Resource: "memory",
+					Some:     boshstats.PressureAverages{Avg10: 10, Avg60: 5, Avg300: 1},
+					Full:     boshstats.PressureAverages{Avg10: 4, Avg60: 2, Avg300: 0.5},
+				},
+			}
+		})
+
+		It("returns pressure vitals per resource", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Pressure).To(Equal(PressureVitals{
+				"cpu": {Some: PressureAverageVitals{Avg10: 1.5, Avg60: 0.75, Avg300: 0.2}},
+				"memory": {
+					Some: PressureAverageVitals{Avg10: 10, Avg60: 5, Avg300: 1},
+					Full: PressureAverageVitals{Avg10: 4, Avg60: 2, Avg300: 0.5},
+				},
+			}))
+		})
+
+		It("returns an error when pressure stats cannot be collected", func() {
+			statsCollector.PressureStatsErr = errors.New("fake-pressure-error")
+
+			_, err := service.Get()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-pressure-error"))
+		})
+
+		It("ignores pressure stats that are not implemented on this kernel", func() {
+			statsCollector.PressureStatsErr = sigar.ErrNotImplemented
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Pressure).To(BeNil())
+		})
+	})
 })
@@ -1,12 +1,16 @@
 package vitals
 
 type Vitals struct {
-	CPU    CPUVitals    `json:"cpu"`
-	Disk   DiskVitals   `json:"disk,omitempty"`
-	Load   []string     `json:"load,omitempty"`
-	Mem    MemoryVitals `json:"mem"`
-	Swap   MemoryVitals `json:"swap"`
-	Uptime UptimeVitals `json:"uptime"`
+	CPU     CPUVitals     `json:"cpu"`
+	Disk    DiskVitals    `json:"disk,omitempty"`
+	DiskIO  DiskIOVitals  `json:"disk_io,omitempty"`
+	Load    []string      `json:"load,omitempty"`
+	Mem     MemoryVitals  `json:"mem"`
+	Network NetworkVitals `json:"network,omitempty"`
+	Swap    MemoryVitals  `json:"swap"`
+	Uptime  UptimeVitals  `json:"uptime"`
+
+	Pressure PressureVitals `json:"pressure,omitempty"`
 }
 
 type CPUVitals struct {
@@ -20,6 +24,57 @@ type DiskVitals map[string]SpecificDiskVitals
 type SpecificDiskVitals struct {
 	InodePercent string `json:"inode_percent,omitempty"`
 	Percent      string `json:"percent,omitempty"`
+
+	// IO of the partition mounted for this disk
+	IO *SpecificDiskIOVitals `json:"io,omitempty"`
+
+	// Health of the devices backing the partition mounted for this disk
+	Health *SpecificDiskHealthVitals `json:"health,omitempty"`
+}
+
+type SpecificDiskHealthVitals struct {
+	Devices     []string `json:"devices"`
+	Status      string   `json:"status"`
+	MediaErrors uint64   `json:"media_errors"`
+	IOErrors    uint64   `json:"io_errors"`
+}
+
+// DiskIOVitals is keyed by block device name, e.g. "sda1"
+type DiskIOVitals map[string]SpecificDiskIOVitals
+
+type SpecificDiskIOVitals struct {
+	ReadBytesPerSecond  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSecond float64 `json:"write_bytes_per_sec"`
+	ReadIOPS            float64 `json:"read_iops"`
+	WriteIOPS           float64 `json:"write_iops"`
+}
+
+// NetworkVitals is keyed by interface name, e.g. "eth0"
+type NetworkVitals map[string]SpecificNetworkVitals
+
+type SpecificNetworkVitals struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	RxErrors  uint64 `json:"rx_errors"`
+	TxErrors  uint64 `json:"tx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
+
+// PressureVitals is keyed by resource, i.e. "cpu", "memory" and "io"
+type PressureVitals map[string]SpecificPressureVitals
+
+// SpecificPressureVitals are the percentages of time some or all tasks
+// were stalled on the resource
+type SpecificPressureVitals struct {
+	Some PressureAverageVitals `json:"some"`
+	Full PressureAverageVitals `json:"full"`
+}
+
+type PressureAverageVitals struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
 }
 
 type MemoryVitals struct {
@@ -13,6 +13,7 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -87,7 +88,7 @@ func NewWindowsPlatform(
 		dirProvider:            dirProvider,
 		netManager:             netManager,
 		devicePathResolver:     devicePathResolver,
-		vitalsService:          boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:          boshvitals.NewService(collector, dirProvider, nil, boshdisk.NewDummyHealthChecker()),
 		certManager:            certManager,
 		options:                options,
 		defaultNetworkResolver: defaultNetworkResolver,
@@ -605,6 +606,11 @@ func (p WindowsPlatform) AdjustPersistentDiskPartitioning(diskSettings boshsetti
 	return
 }
 
+func (p WindowsPlatform) ResizePersistentDisk(diskSettings boshsettings.DiskSettings) (oldSizeInBytes, newSizeInBytes uint64, err error) {
+	err = bosherr.Error("Resizing persistent disks is not supported on windows")
+	return
+}
+
 func (p WindowsPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (err error) {
 	return
 }
@@ -658,7 +664,15 @@ func (p WindowsPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []s
 	return
 }
 
-func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, cancelCh <-chan struct{}, progressFunc boshdisk.MigrationProgressFunc) (err error) {
+	return
+}
+
+func (p WindowsPlatform) FreezeFilesystem(mountPoint string) (err error) {
+	return bosherr.Error("Freezing filesystems is not supported on windows")
+}
+
+func (p WindowsPlatform) ThawFilesystem(mountPoint string) (err error) {
 	return
 }
 
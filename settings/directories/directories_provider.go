@@ -50,6 +50,14 @@ func (p Provider) CompileDir() string {
 	return filepath.Join(p.DataDir(), "compile")
 }
 
+func (p Provider) CompileCacheDir() string {
+	return filepath.Join(p.DataDir(), "compile_cache")
+}
+
+func (p Provider) AlertSpoolDir() string {
+	return filepath.Join(p.BoshDir(), "alert_spool")
+}
+
 func (p Provider) MonitJobsDir() string {
 	return filepath.Join(p.BaseDir(), "monit", "job")
 }
@@ -58,6 +66,10 @@ func (p Provider) MonitDir() string {
 	return filepath.Join(p.BaseDir(), "monit")
 }
 
+func (p Provider) SystemdDir() string {
+	return filepath.Join(p.BaseDir(), "systemd")
+}
+
 func (p Provider) JobsDir() string {
 	return filepath.Join(p.BaseDir(), "jobs")
 }
@@ -26,8 +26,11 @@ var _ = Describe("Provider", func() {
 		Entry("StoreMigrationDir()", p.StoreMigrationDir(), "/some/dir/store_migration_target"),
 		Entry("PkgDir()", p.PkgDir(), "/some/dir/data/packages"),
 		Entry("CompileDir()", p.CompileDir(), "/some/dir/data/compile"),
+		Entry("CompileCacheDir()", p.CompileCacheDir(), "/some/dir/data/compile_cache"),
+		Entry("AlertSpoolDir()", p.AlertSpoolDir(), "/some/dir/bosh/alert_spool"),
 		Entry("MonitJobsDir()", p.MonitJobsDir(), "/some/dir/monit/job"),
 		Entry("MonitDir()", p.MonitDir(), "/some/dir/monit"),
+		Entry("SystemdDir()", p.SystemdDir(), "/some/dir/systemd"),
 		Entry("JobsDir()", p.JobsDir(), "/some/dir/jobs"),
 		Entry("DataJobsDir()", p.DataJobsDir(), "/some/dir/data/jobs"),
 		Entry("JobBinDir(jobName)", p.JobBinDir("myJob"), "/some/dir/jobs/myJob/bin"),
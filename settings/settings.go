@@ -84,6 +84,8 @@ type DiskSettings struct {
 	MountOptions   []string
 
 	Partitioner string
+
+	Encryption disk.Encryption
 }
 
 type ISCSISettings struct {
@@ -230,6 +232,7 @@ func (s Settings) populatePersistentDiskSettings(diskID string, settingsInfo int
 	diskSettings.FileSystemType = s.Env.PersistentDiskFS
 	diskSettings.MountOptions = s.Env.PersistentDiskMountOptions
 	diskSettings.Partitioner = s.Env.PersistentDiskPartitioner
+	diskSettings.Encryption = s.Env.PersistentDiskEncryption
 
 	return diskSettings
 }
@@ -239,6 +242,7 @@ type Env struct {
 	PersistentDiskFS           disk.FileSystemType `json:"persistent_disk_fs"`
 	PersistentDiskMountOptions []string            `json:"persistent_disk_mount_options"`
 	PersistentDiskPartitioner  string              `json:"persistent_disk_partitioner"`
+	PersistentDiskEncryption   disk.Encryption     `json:"persistent_disk_encryption"`
 }
 
 func (e Env) GetPassword() string {
@@ -293,6 +297,59 @@ type BoshEnv struct {
 	Blobstores            []Blobstore `json:"blobstores"`
 	NTP                   []string    `json:"ntp"`
 	Parallel              *int        `json:"parallel"`
+	Alerts                Alerts      `json:"alerts"`
+}
+
+type Alerts struct {
+	Rules []AlertRule `json:"rules"`
+	Sinks AlertSinks  `json:"sinks"`
+}
+
+// AlertSinks deliver alerts locally in addition to the message bus.
+// Sinks without an address or URL are disabled.
+type AlertSinks struct {
+	Syslog  SyslogAlertSink  `json:"syslog"`
+	Webhook WebhookAlertSink `json:"webhook"`
+	Spool   AlertSpool       `json:"spool"`
+}
+
+type SyslogAlertSink struct {
+	// Address is host:port of an RFC 5424 syslog receiver
+	Address string `json:"address"`
+
+	// Transport is either udp (default) or tcp
+	Transport string `json:"transport"`
+}
+
+type WebhookAlertSink struct {
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers"`
+	CA          string            `json:"ca"`
+	MaxAttempts int               `json:"max_attempts"`
+}
+
+// AlertSpool keeps alerts that could not be sent over the message bus on
+// disk until it is reachable again
+type AlertSpool struct {
+	Enabled   bool `json:"enabled"`
+	MaxAlerts int  `json:"max_alerts"`
+}
+
+// AlertRule changes how matching monit alerts are reported. Service, event
+// and action are case-insensitive glob patterns; empty patterns match
+// anything. The first matching rule applies.
+type AlertRule struct {
+	Service string `json:"service"`
+	Event   string `json:"event"`
+	Action  string `json:"action"`
+
+	// Severity overrides the severity of the alert: alert, critical, error or warning
+	Severity string `json:"severity"`
+
+	Suppress bool `json:"suppress"`
+
+	// RateLimitWindowInSeconds drops duplicates of an alert sent within the window
+	RateLimitWindowInSeconds int `json:"rate_limit_window_in_seconds"`
 }
 
 type AgentEnv struct {
@@ -304,8 +361,16 @@ type AgentSettings struct {
 }
 
 type MBus struct {
-	Cert CertKeyPair `json:"cert"`
-	URLs []string    `json:"urls"`
+	Cert      CertKeyPair `json:"cert"`
+	URLs      []string    `json:"urls"`
+	JetStream JetStream   `json:"jetstream"`
+}
+
+// JetStream switches the NATS handler to durable delivery. Streams capturing
+// agent.<agent-id> and hm.agent.{alert,shutdown}.<agent-id> are expected to
+// be provisioned on the NATS server.
+type JetStream struct {
+	Enabled bool `json:"enabled"`
 }
 
 type CertKeyPair struct {
@@ -128,6 +128,18 @@ var _ = Describe("Settings", func() {
 						FileSystemType: disk.FileSystemType("blahblah"),
 					}))
 				})
+
+				It("gets persistent disk encryption settings from env", func() {
+					settingsJSON := `{"env": {"persistent_disk_encryption": {"enabled": true, "key_file": "/var/vcap/bosh/disk.key"}}}`
+
+					err := json.Unmarshal([]byte(settingsJSON), &settings)
+					Expect(err).NotTo(HaveOccurred())
+					diskSettings, _ := settings.PersistentDiskSettings("fake-disk-id")
+					Expect(diskSettings.Encryption).To(Equal(disk.Encryption{
+						Enabled: true,
+						KeyFile: "/var/vcap/bosh/disk.key",
+					}))
+				})
 			})
 		})
 
@@ -857,6 +869,17 @@ var _ = Describe("Settings", func() {
     ],
     "swap_size": 2048,
     "parallel": 10,
+    "alerts": {
+      "rules": [
+        {"service": "nats*", "event": "pid failed", "severity": "warning", "rate_limit_window_in_seconds": 300},
+        {"action": "alert", "suppress": true}
+      ],
+      "sinks": {
+        "syslog": {"address": "10.0.0.1:514", "transport": "tcp"},
+        "webhook": {"url": "https://example.com/alerts", "headers": {"Authorization": "Bearer token"}, "max_attempts": 5},
+        "spool": {"enabled": true, "max_alerts": 100}
+      }
+    },
 	"blobstores": [
 		{
 			"options": {
@@ -886,6 +909,19 @@ var _ = Describe("Settings", func() {
 			Expect(env.GetAuthorizedKeys()).To(ConsistOf("fake-key"))
 			Expect(*env.GetSwapSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
 			Expect(*env.GetParallel()).To(Equal(10))
+			Expect(env.Bosh.Alerts.Rules).To(Equal([]AlertRule{
+				{Service: "nats*", Event: "pid failed", Severity: "warning", RateLimitWindowInSeconds: 300},
+				{Action: "alert", Suppress: true},
+			}))
+			Expect(env.Bosh.Alerts.Sinks).To(Equal(AlertSinks{
+				Syslog: SyslogAlertSink{Address: "10.0.0.1:514", Transport: "tcp"},
+				Webhook: WebhookAlertSink{
+					URL:         "https://example.com/alerts",
+					Headers:     map[string]string{"Authorization": "Bearer token"},
+					MaxAttempts: 5,
+				},
+				Spool: AlertSpool{Enabled: true, MaxAlerts: 100},
+			}))
 			Expect(env.Bosh.Blobstores).To(Equal(
 				[](Blobstore){
 					Blobstore{
@@ -939,6 +975,18 @@ var _ = Describe("Settings", func() {
 			Expect(*env.GetSwapSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
 		})
 
+		It("can enable durable NATS JetStream delivery", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {} }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Mbus.JetStream).To(Equal(JetStream{}))
+
+			env = Env{}
+			err = json.Unmarshal([]byte(`{"bosh": {"mbus": {"jetstream": {"enabled": true} } } }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Mbus.JetStream).To(Equal(JetStream{Enabled: true}))
+		})
+
 		It("can enable ipv6", func() {
 			env := Env{}
 			err := json.Unmarshal([]byte(`{"bosh": {} }`), &env)
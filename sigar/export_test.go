@@ -0,0 +1,11 @@
+package sigar
+
+import (
+	"time"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
+)
+
+func SampleDiskIOStats(collector boshstats.Collector, sampledAt time.Time) {
+	collector.(*sigarStatsCollector).sampleDiskIOStats(sampledAt)
+}
@@ -0,0 +1,308 @@
+package sigar
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	procStatsLogTag = "sigarStatsCollector"
+
+	procNetDevPath    = "/proc/net/dev"
+	procDiskStatsPath = "/proc/diskstats"
+	procPressureDir   = "/proc/pressure"
+
+	// /proc/diskstats always counts in 512 byte sectors regardless of the device
+	diskStatsSectorSize = 512
+)
+
+type diskCounters struct {
+	reads          uint64
+	sectorsRead    uint64
+	writes         uint64
+	sectorsWritten uint64
+}
+
+func (s *sigarStatsCollector) GetNetworkStats() ([]boshstats.NetworkStats, error) {
+	if !s.fs.FileExists(procNetDevPath) {
+		return nil, sigar.ErrNotImplemented
+	}
+
+	contents, err := s.fs.ReadFileString(procNetDevPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading network device stats")
+	}
+
+	return parseNetDev(contents, s.logger), nil
+}
+
+func (s *sigarStatsCollector) GetDiskIOStats() ([]boshstats.DiskIOStats, error) {
+	if !s.fs.FileExists(procDiskStatsPath) {
+		return nil, sigar.ErrNotImplemented
+	}
+
+	s.latestDiskIOStatsLock.RLock()
+	defer s.latestDiskIOStatsLock.RUnlock()
+
+	return s.latestDiskIOStats, nil
+}
+
+func (s *sigarStatsCollector) GetPressureStats() ([]boshstats.PressureStats, error) {
+	if !s.fs.FileExists(procPressureDir) {
+		return nil, sigar.ErrNotImplemented
+	}
+
+	stats := []boshstats.PressureStats{}
+
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		resourcePath := filepath.Join(procPressureDir, resource)
+		if !s.fs.FileExists(resourcePath) {
+			continue
+		}
+
+		contents, err := s.fs.ReadFileString(resourcePath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading %s pressure", resource)
+		}
+
+		stat, err := parsePressure(resource, contents)
+		if err != nil {
+			// Pressure of the other resources is still reported
+			s.logger.Warn(procStatsLogTag, "Skipping %s pressure: %s", resource, err.Error())
+			continue
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+func (s *sigarStatsCollector) collectDiskIOStats(collectionInterval time.Duration) {
+	ticker := time.NewTicker(collectionInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sampleDiskIOStats(time.Now())
+		<-ticker.C
+	}
+}
+
+func (s *sigarStatsCollector) sampleDiskIOStats(sampledAt time.Time) {
+	if !s.fs.FileExists(procDiskStatsPath) {
+		return
+	}
+
+	contents, err := s.fs.ReadFileString(procDiskStatsPath)
+	if err != nil {
+		return
+	}
+
+	counters := parseDiskStats(contents)
+
+	s.latestDiskIOStatsLock.Lock()
+	defer s.latestDiskIOStatsLock.Unlock()
+
+	if s.previousDiskCounters != nil {
+		s.latestDiskIOStats = diskIORates(s.previousDiskCounters, counters, sampledAt.Sub(s.previousDiskSampledAt))
+	}
+
+	s.previousDiskCounters = counters
+	s.previousDiskSampledAt = sampledAt
+}
+
+func diskIORates(previous, current map[string]diskCounters, elapsed time.Duration) []boshstats.DiskIOStats {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+
+	rate := func(before, after uint64) float64 {
+		// Counters wrap or reset when devices are re-attached
+		if after < before {
+			return 0
+		}
+		return float64(after-before) / seconds
+	}
+
+	stats := []boshstats.DiskIOStats{}
+
+	for device, after := range current {
+		before, found := previous[device]
+		if !found {
+			continue
+		}
+
+		stats = append(stats, boshstats.DiskIOStats{
+			Device:              device,
+			ReadBytesPerSecond:  rate(before.sectorsRead, after.sectorsRead) * diskStatsSectorSize,
+			WriteBytesPerSecond: rate(before.sectorsWritten, after.sectorsWritten) * diskStatsSectorSize,
+			ReadsPerSecond:      rate(before.reads, after.reads),
+			WritesPerSecond:     rate(before.writes, after.writes),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Device < stats[j].Device })
+
+	return stats
+}
+
+// parseNetDev parses /proc/net/dev, e.g.
+//
+//	Inter-|   Receive                                                |  Transmit
+//	 face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+//	  eth0: 1000      10      1    2    0     0          0         0     2000      20    3    4    0     0       0          0
+//
+// Lines that cannot be parsed are skipped so that one odd interface
+// does not hide the stats of all others.
+func parseNetDev(contents string, logger boshlog.Logger) []boshstats.NetworkStats {
+	stats := []boshstats.NetworkStats{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) < 12 {
+			continue
+		}
+
+		values, err := parseNetDevValues(fields[:12])
+		if err != nil {
+			logger.Warn(procStatsLogTag, "Skipping network device stats line '%s': %s", line, err.Error())
+			continue
+		}
+
+		stats = append(stats, boshstats.NetworkStats{
+			Interface: strings.TrimSpace(parts[0]),
+			RxBytes:   values[0],
+			RxErrors:  values[2],
+			RxDropped: values[3],
+			TxBytes:   values[8],
+			TxErrors:  values[10],
+			TxDropped: values[11],
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Interface < stats[j].Interface })
+
+	return stats
+}
+
+func parseNetDevValues(fields []string) ([]uint64, error) {
+	values := make([]uint64, len(fields))
+
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// parsePressure parses a file in /proc/pressure, e.g.
+//
+//	some avg10=1.50 avg60=0.75 avg300=0.20 total=123456
+//	full avg10=0.50 avg60=0.25 avg300=0.10 total=45678
+//
+// The full line is missing for cpu on older kernels.
+func parsePressure(resource, contents string) (boshstats.PressureStats, error) {
+	stats := boshstats.PressureStats{Resource: resource}
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var averages *boshstats.PressureAverages
+		switch fields[0] {
+		case "some":
+			averages = &stats.Some
+		case "full":
+			averages = &stats.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found || key == "total" {
+				continue
+			}
+
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return stats, bosherr.WrapErrorf(err, "Parsing %s pressure line '%s'", resource, line)
+			}
+
+			switch key {
+			case "avg10":
+				averages.Avg10 = number
+			case "avg60":
+				averages.Avg60 = number
+			case "avg300":
+				averages.Avg300 = number
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// parseDiskStats parses /proc/diskstats, e.g.
+//
+//	8       0 sda 100 0 2000 50 200 0 4000 80 0 100 130
+//
+// Loop and ram devices are ignored since they do not map to disks.
+func parseDiskStats(contents string) map[string]diskCounters {
+	counters := map[string]diskCounters{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+
+		device := fields[2]
+		if strings.HasPrefix(device, "loop") || strings.HasPrefix(device, "ram") {
+			continue
+		}
+
+		values := make([]uint64, 0, 4)
+		for _, i := range []int{3, 5, 7, 9} {
+			value, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				break
+			}
+			values = append(values, value)
+		}
+
+		if len(values) != 4 {
+			continue
+		}
+
+		counters[device] = diskCounters{
+			reads:          values[0],
+			sectorsRead:    values[1],
+			writes:         values[2],
+			sectorsWritten: values[3],
+		}
+	}
+
+	return counters
+}
@@ -8,17 +8,28 @@ import (
 
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type sigarStatsCollector struct {
 	statsSigar         sigar.Sigar
+	fs                 boshsys.FileSystem
+	logger             boshlog.Logger
 	latestCPUStats     boshstats.CPUStats
 	latestCPUStatsLock sync.RWMutex
+
+	latestDiskIOStats     []boshstats.DiskIOStats
+	latestDiskIOStatsLock sync.RWMutex
+	previousDiskCounters  map[string]diskCounters
+	previousDiskSampledAt time.Time
 }
 
-func NewSigarStatsCollector(sigar sigar.Sigar) boshstats.Collector {
+func NewSigarStatsCollector(sigar sigar.Sigar, fs boshsys.FileSystem, logger boshlog.Logger) boshstats.Collector {
 	return &sigarStatsCollector{
 		statsSigar: sigar,
+		fs:         fs,
+		logger:     logger,
 	}
 }
 
@@ -40,6 +51,8 @@ func (s *sigarStatsCollector) StartCollecting(collectionInterval time.Duration,
 			}
 		}
 	}()
+
+	go s.collectDiskIOStats(collectionInterval)
 }
 
 func (s *sigarStatsCollector) GetCPULoad() (load boshstats.CPULoad, err error) {
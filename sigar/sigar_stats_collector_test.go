@@ -8,6 +8,8 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshsigar "github.com/cloudfoundry/bosh-agent/sigar"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	sigar "github.com/cloudfoundry/gosigar"
 	fakesigar "github.com/cloudfoundry/gosigar/fakes"
 )
@@ -16,11 +18,13 @@ var _ = Describe("sigarStatsCollector", func() {
 	var (
 		collector Collector
 		fakeSigar *fakesigar.FakeSigar
+		fs        *fakesys.FakeFileSystem
 	)
 
 	BeforeEach(func() {
 		fakeSigar = fakesigar.NewFakeSigar()
-		collector = boshsigar.NewSigarStatsCollector(fakeSigar)
+		fs = fakesys.NewFakeFileSystem()
+		collector = boshsigar.NewSigarStatsCollector(fakeSigar, fs, boshlog.NewLogger(boshlog.LevelNone))
 	})
 
 	Describe("GetCPULoad", func() {
@@ -135,4 +139,146 @@ var _ = Describe("sigarStatsCollector", func() {
 			Expect(stats.InodeUsage.Used).To(Equal(uint64(400)))
 		})
 	})
+
+	Describe("GetNetworkStats", func() {
+		It("returns counters per interface", func() {
+			err := fs.WriteFileString("/proc/net/dev", `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:     500       5    0    0    0     0          0         0      500       5    0    0    0     0       0          0
+  eth0:    1000      10    1    2    0     0          0         0     2000      20    3    4    0     0       0          0
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			stats, err := collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stats).To(Equal([]NetworkStats{
+				{Interface: "eth0", RxBytes: 1000, TxBytes: 2000, RxErrors: 1, TxErrors: 3, RxDropped: 2, TxDropped: 4},
+				{Interface: "lo", RxBytes: 500, TxBytes: 500},
+			}))
+		})
+
+		It("skips interfaces whose counters cannot be parsed", func() {
+			err := fs.WriteFileString("/proc/net/dev", `eth0: a b c d e f g h i j k l
+  eth1:    1000      10      1    2    0     0          0         0     2000      20    3    4    0     0       0          0
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			stats, err := collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stats).To(Equal([]NetworkStats{
+				{Interface: "eth1", RxBytes: 1000, TxBytes: 2000, RxErrors: 1, TxErrors: 3, RxDropped: 2, TxDropped: 4},
+			}))
+		})
+
+		It("returns not implemented when network stats are not available", func() {
+			_, err := collector.GetNetworkStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+		})
+	})
+
+	Describe("GetPressureStats", func() {
+		It("returns the averages per resource", func() {
+			err := fs.WriteFileString("/proc/pressure/cpu", "some avg10=1.50 avg60=0.75 avg300=0.20 total=123456\n")
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.WriteFileString("/proc/pressure/memory", `some avg10=10.00 avg60=5.00 avg300=1.00 total=1000
+full avg10=4.00 avg60=2.00 avg300=0.50 total=500
+`)
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.WriteFileString("/proc/pressure/io", `some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			stats, err := collector.GetPressureStats()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stats).To(Equal([]PressureStats{
+				{Resource: "cpu", Some: PressureAverages{Avg10: 1.5, Avg60: 0.75, Avg300: 0.2}},
+				{
+					Resource: "memory",
+					Some:     PressureAverages{Avg10: 10, Avg60: 5, Avg300: 1},
+					Full:     PressureAverages{Avg10: 4, Avg60: 2, Avg300: 0.5},
+				},
+				{Resource: "io"},
+			}))
+		})
+
+		It("skips resources whose averages cannot be parsed", func() {
+			err := fs.WriteFileString("/proc/pressure/cpu", "some avg10=a avg60=0.75 avg300=0.20 total=123456\n")
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.WriteFileString("/proc/pressure/io", "some avg10=1.00 avg60=0.00 avg300=0.00 total=0\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			stats, err := collector.GetPressureStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal([]PressureStats{
+				{Resource: "io", Some: PressureAverages{Avg10: 1}},
+			}))
+		})
+
+		It("returns not implemented when the kernel does not report pressure", func() {
+			_, err := collector.GetPressureStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+		})
+	})
+
+	Describe("GetDiskIOStats", func() {
+		It("returns rates per device once two samples were collected", func() {
+			sampledAt := time.Now()
+
+			err := fs.WriteFileString("/proc/diskstats", `   8       0 sda 100 0 2000 50 200 0 4000 80 0 100 130
+   7       0 loop0 1 0 1 0 0 0 0 0 0 0 0
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			boshsigar.SampleDiskIOStats(collector, sampledAt)
+
+			stats, err := collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(BeEmpty())
+
+			err = fs.WriteFileString("/proc/diskstats", `   8       0 sda 200 0 4000 50 400 0 8000 80 0 100 130
+   8       1 sda1 10 0 10 0 10 0 10 0 0 0 0
+   7       0 loop0 2 0 2 0 0 0 0 0 0 0 0
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			boshsigar.SampleDiskIOStats(collector, sampledAt.Add(10*time.Second))
+
+			stats, err = collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal([]DiskIOStats{
+				{
+					Device:              "sda",
+					ReadBytesPerSecond:  2000 * 512 / 10,
+					WriteBytesPerSecond: 4000 * 512 / 10,
+					ReadsPerSecond:      10,
+					WritesPerSecond:     20,
+				},
+			}))
+		})
+
+		It("reports zero rates when counters were reset", func() {
+			sampledAt := time.Now()
+
+			err := fs.WriteFileString("/proc/diskstats", "   8       0 sda 200 0 4000 50 400 0 8000 80 0 100 130\n")
+			Expect(err).ToNot(HaveOccurred())
+			boshsigar.SampleDiskIOStats(collector, sampledAt)
+
+			err = fs.WriteFileString("/proc/diskstats", "   8       0 sda 100 0 2000 50 200 0 4000 80 0 100 130\n")
+			Expect(err).ToNot(HaveOccurred())
+			boshsigar.SampleDiskIOStats(collector, sampledAt.Add(time.Second))
+
+			stats, err := collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal([]DiskIOStats{{Device: "sda"}}))
+		})
+
+		It("returns not implemented when disk stats are not available", func() {
+			_, err := collector.GetDiskIOStats()
+			Expect(err).To(Equal(sigar.ErrNotImplemented))
+		})
+	})
 })